@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/endpoint"
+)
+
+const (
+	schemeApiKey = "ApiKey"
+
+	defaultApiKeyHeader = "X-Api-Key"
+)
+
+var ErrInvalidApiKey = errors.New("invalid API key")
+
+// ApiKeyStore looks up the principals of API keys.
+type ApiKeyStore interface {
+	// LookupApiKey returns the principal of the API key.
+	// Returns ErrKeyNotFound if the API key does not exist.
+	LookupApiKey(ctx context.Context, apiKey string) (any, error)
+}
+
+// StaticApiKeys is a fixed ApiKeyStore of API key to principal.
+type StaticApiKeys map[string]any
+
+func (self StaticApiKeys) LookupApiKey(ctx context.Context, apiKey string) (any, error) {
+	principal, exists := self[apiKey]
+	if !exists {
+		return nil, ErrKeyNotFound
+	}
+
+	return principal, nil
+}
+
+// ApiKeyConfig of an ApiKeyAuthenticator.
+type ApiKeyConfig struct {
+	// Header that contains the API key. Defaults to X-Api-Key if QueryParam is not set.
+	Header string
+	// QueryParam that contains the API key. Checked after Header.
+	QueryParam string
+	// Store of API keys.
+	Store ApiKeyStore
+	// StoreResource is the name of the ApiKeyStore in the Resources of the endpoint config. Used if Store is nil.
+	StoreResource string
+}
+
+// ApiKeyAuthenticator authenticates requests with an API key in a header or query parameter.
+// The principal is returned by the ApiKeyStore.
+type ApiKeyAuthenticator struct {
+	config ApiKeyConfig
+}
+
+var _ endpoint.Authenticator = (*ApiKeyAuthenticator)(nil)
+
+func NewApiKeyAuthenticator(config ApiKeyConfig) *ApiKeyAuthenticator {
+	if config.Header == "" && config.QueryParam == "" {
+		config.Header = defaultApiKeyHeader
+	}
+
+	return &ApiKeyAuthenticator{
+		config: config,
+	}
+}
+
+func (self *ApiKeyAuthenticator) Scheme() string {
+	return schemeApiKey
+}
+
+func (self *ApiKeyAuthenticator) Authenticate(ctx context.Context, requester endpoint.Requester) (any, error) {
+	var apiKey []byte
+	if self.config.Header != "" {
+		apiKey = requester.PeekHeader(self.config.Header)
+	}
+	if len(apiKey) == 0 && self.config.QueryParam != "" {
+		apiKey, _ = requester.QueryParam(self.config.QueryParam)
+	}
+	if len(apiKey) == 0 {
+		return nil, endpoint.ErrNoCredentials
+	}
+
+	store := self.config.Store
+	if store == nil {
+		var err error
+		if store, err = resource[ApiKeyStore](ctx, self.config.StoreResource); err != nil {
+			return nil, err
+		}
+	}
+
+	principal, err := store.LookupApiKey(ctx, string(apiKey))
+	if err != nil {
+		return nil, errors.Wrap(err, ErrInvalidApiKey)
+	}
+
+	return principal, nil
+}
+
+// resource of the endpoint being executed.
+func resource[T any](ctx context.Context, name string) (T, error) {
+	var zero T
+
+	routeInfo, ok := endpoint.RequestRoute(ctx)
+	if !ok || routeInfo.Endpoint == nil {
+		return zero, errors.New("resource %s: no endpoint", name)
+	}
+
+	value, ok := routeInfo.Endpoint.Config.Resources[name].(T)
+	if !ok {
+		return zero, errors.New("resource %s: not found or wrong type", name)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,78 @@
+package auth_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+
+	"github.com/wspowell/spiderweb/auth"
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+type partner struct {
+	Name string
+}
+
+type partnerResponse struct {
+	Name string `json:"name"`
+}
+
+type partnerEndpoint struct {
+	Partner      *partner         `spiderweb:"auth"`
+	ResponseBody *partnerResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *partnerEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &partnerResponse{Name: self.Partner.Name}
+
+	return httpstatus.OK, nil
+}
+
+func Test_ApiKeyAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	keys := auth.StaticApiKeys{
+		"key-1": &partner{Name: "acme"},
+	}
+
+	testCases := []struct {
+		name               string
+		config             auth.ApiKeyConfig
+		resources          map[string]any
+		target             string
+		header             string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{name: "header", config: auth.ApiKeyConfig{Store: keys}, target: "/partner", header: "key-1", expectedHttpStatus: httpstatus.OK, expectedBody: `{"name":"acme"}`},
+		{name: "query", config: auth.ApiKeyConfig{Header: "X-Api-Key", QueryParam: "api_key", Store: keys}, target: "/partner?api_key=key-1", expectedHttpStatus: httpstatus.OK, expectedBody: `{"name":"acme"}`},
+		{name: "query not allowed", config: auth.ApiKeyConfig{Store: keys}, target: "/partner?api_key=key-1", expectedHttpStatus: httpstatus.Unauthorized, expectedBody: `{"message":"unauthorized"}`},
+		{name: "unknown", config: auth.ApiKeyConfig{Store: keys}, target: "/partner", header: "key-2", expectedHttpStatus: httpstatus.Unauthorized, expectedBody: `{"message":"unauthorized"}`},
+		{name: "resource", config: auth.ApiKeyConfig{StoreResource: "apiKeys"}, resources: map[string]any{"apiKeys": keys}, target: "/partner", header: "key-1", expectedHttpStatus: httpstatus.OK, expectedBody: `{"name":"acme"}`},
+		{name: "missing resource", config: auth.ApiKeyConfig{StoreResource: "apiKeys"}, target: "/partner", header: "key-1", expectedHttpStatus: httpstatus.Unauthorized, expectedBody: `{"message":"unauthorized"}`},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, testCase.target, nil)
+			assert.Nil(t, err)
+			if testCase.header != "" {
+				req.Header.Add("X-Api-Key", testCase.header)
+			}
+
+			httpStatus, responseBody := executeEndpoint(t, &endpoint.Config{
+				Authenticators: []endpoint.Authenticator{auth.NewApiKeyAuthenticator(testCase.config)},
+				Resources:      testCase.resources,
+			}, &partnerEndpoint{}, req)
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedBody, responseBody)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+)
+
+// Claims of a validated JWT.
+// Claims is the principal returned by the JwtAuthenticator.
+type Claims struct {
+	Issuer    string      `json:"iss,omitempty"`
+	Subject   string      `json:"sub,omitempty"`
+	Audience  Audience    `json:"aud,omitempty"`
+	ExpiresAt NumericDate `json:"exp,omitempty"`
+	NotBefore NumericDate `json:"nbf,omitempty"`
+	IssuedAt  NumericDate `json:"iat,omitempty"`
+	Id        string      `json:"jti,omitempty"`
+	// Scope is a space separated list of scopes.
+	Scope string   `json:"scope,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+
+	// Raw claims, including custom claims.
+	Raw map[string]any `json:"-"`
+}
+
+// HasScope is true if the scope is in the "scope" claim.
+func (self *Claims) HasScope(scope string) bool {
+	for _, claimScope := range strings.Fields(self.Scope) {
+		if claimScope == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasRole is true if the role is in the "roles" claim.
+func (self *Claims) HasRole(role string) bool {
+	for _, claimRole := range self.Roles {
+		if claimRole == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Audience is a single audience or a list of audiences.
+type Audience []string
+
+// Contains is true if the audience is in the list.
+func (self Audience) Contains(audience string) bool {
+	for _, claimAudience := range self {
+		if claimAudience == audience {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (self *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*self = Audience{single}
+
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*self = multiple
+
+	return nil
+}
+
+// NumericDate is a number of seconds since the Unix epoch.
+// The zero value means the claim was not set.
+type NumericDate struct {
+	time.Time
+}
+
+func (self *NumericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+
+	whole, fraction := math.Modf(seconds)
+	self.Time = time.Unix(int64(whole), int64(fraction*float64(time.Second))).UTC()
+
+	return nil
+}
+
+func (self NumericDate) MarshalJSON() ([]byte, error) {
+	if self.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(self.Unix())
+}
@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+)
+
+const (
+	// SchemeHmac of the Authorization header.
+	// Ex: Authorization: HMAC-SHA256 keyId=partner-1,signedHeaders=content-type;x-request-id,signature=<base64>
+	SchemeHmac = "HMAC-SHA256"
+
+	// HeaderTimestamp is the Unix time, in seconds, that the request was signed.
+	HeaderTimestamp = "X-Timestamp"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrReplayedRequest  = errors.New("replayed request")
+)
+
+// HmacKey shared with a client.
+type HmacKey struct {
+	Secret []byte
+	// Principal of requests signed with the key.
+	Principal any
+}
+
+// HmacKeyStore looks up shared keys.
+type HmacKeyStore interface {
+	// LookupHmacKey returns the key with the key ID.
+	// Returns ErrKeyNotFound if the key does not exist.
+	LookupHmacKey(ctx context.Context, keyId string) (*HmacKey, error)
+}
+
+// StaticHmacKeys is a fixed HmacKeyStore of key ID to key.
+type StaticHmacKeys map[string]*HmacKey
+
+func (self StaticHmacKeys) LookupHmacKey(ctx context.Context, keyId string) (*HmacKey, error) {
+	key, exists := self[keyId]
+	if !exists {
+		return nil, ErrKeyNotFound
+	}
+
+	return key, nil
+}
+
+// HmacConfig of an HmacAuthenticator.
+type HmacConfig struct {
+	// Store of shared keys.
+	Store HmacKeyStore
+	// StoreResource is the name of the HmacKeyStore in the Resources of the endpoint config. Used if Store is nil.
+	StoreResource string
+	// RequiredHeaders that must be signed, in addition to the headers chosen by the client.
+	RequiredHeaders []string
+	// ReplayWindow is how far the timestamp may be from the current time. Defaults to 5 minutes.
+	// Signatures are also remembered for twice the window so that a request can never be sent twice.
+	ReplayWindow time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// HmacAuthenticator authenticates requests signed with a shared key.
+// The principal is the Principal of the HmacKey.
+//
+// The signature is the base64 HMAC-SHA256 of:
+//
+//	<METHOD>\n
+//	<path>\n
+//	<query>\n
+//	<signed header name>:<value>\n (for each signed header, in the order listed)
+//	<timestamp>\n
+//	<hex SHA-256 of the request body>
+//
+// The query is every query parameter, sorted by name and encoded as url.Values.Encode() does, so a signed request cannot be replayed with a different query.
+// The request body is read into memory to be hashed.
+type HmacAuthenticator struct {
+	config HmacConfig
+
+	mutex sync.Mutex
+	// seen signatures.
+	seen map[string]struct{}
+	// expiries of the seen signatures, oldest first.
+	expiries []seenSignature
+}
+
+type seenSignature struct {
+	signature string
+	expiresAt time.Time
+}
+
+var _ endpoint.Authenticator = (*HmacAuthenticator)(nil)
+
+func NewHmacAuthenticator(config HmacConfig) *HmacAuthenticator {
+	if config.ReplayWindow == 0 {
+		config.ReplayWindow = 5 * time.Minute
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &HmacAuthenticator{
+		config: config,
+		seen:   map[string]struct{}{},
+	}
+}
+
+func (self *HmacAuthenticator) Scheme() string {
+	return SchemeHmac
+}
+
+type hmacAuthorization struct {
+	keyId         string
+	signedHeaders []string
+	signature     []byte
+}
+
+func (self *HmacAuthenticator) Authenticate(ctx context.Context, requester endpoint.Requester) (any, error) {
+	authorization := string(requester.PeekHeader(httpheader.Authorization))
+	if !strings.HasPrefix(authorization, SchemeHmac+" ") {
+		return nil, endpoint.ErrNoCredentials
+	}
+
+	parsed, err := parseHmacAuthorization(strings.TrimPrefix(authorization, SchemeHmac+" "))
+	if err != nil {
+		return nil, errors.Wrap(err, ErrInvalidSignature)
+	}
+
+	for _, requiredHeader := range self.config.RequiredHeaders {
+		if !containsFold(parsed.signedHeaders, requiredHeader) {
+			return nil, errors.Wrap(errors.New("header must be signed: %s", requiredHeader), ErrInvalidSignature)
+		}
+	}
+
+	timestamp := string(requester.PeekHeader(HeaderTimestamp))
+	timestampSeconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(errors.New("invalid %s: %s", HeaderTimestamp, timestamp), ErrInvalidSignature)
+	}
+	now := self.config.Now()
+	signedAt := time.Unix(timestampSeconds, 0)
+	if signedAt.Before(now.Add(-self.config.ReplayWindow)) || signedAt.After(now.Add(self.config.ReplayWindow)) {
+		return nil, errors.Wrap(errors.New("timestamp outside of replay window: %s", timestamp), ErrReplayedRequest)
+	}
+
+	store := self.config.Store
+	if store == nil {
+		if store, err = resource[HmacKeyStore](ctx, self.config.StoreResource); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := store.LookupHmacKey(ctx, parsed.keyId)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrInvalidSignature)
+	}
+
+	expected := SignRequest(key.Secret, string(requester.Method()), string(requester.Path()), canonicalQuery(requester), signedHeaderValues(requester, parsed.signedHeaders), timestamp, requester.RequestBody())
+	if !hmac.Equal(expected, parsed.signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	// The signature is only remembered once verified so that invalid requests cannot fill the cache.
+	if !self.remember(base64.StdEncoding.EncodeToString(parsed.signature), now) {
+		return nil, ErrReplayedRequest
+	}
+
+	return key.Principal, nil
+}
+
+// remember the signature for the replay window. Returns false if it was already seen.
+func (self *HmacAuthenticator) remember(signature string, now time.Time) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	// Every signature is remembered for the same duration, so the oldest expire first.
+	expired := 0
+	for expired < len(self.expiries) && now.After(self.expiries[expired].expiresAt) {
+		delete(self.seen, self.expiries[expired].signature)
+		expired++
+	}
+	self.expiries = self.expiries[expired:]
+
+	if _, exists := self.seen[signature]; exists {
+		return false
+	}
+	// Timestamps may be up to the window in the future, so the signature is remembered for twice the window.
+	self.seen[signature] = struct{}{}
+	self.expiries = append(self.expiries, seenSignature{
+		signature: signature,
+		expiresAt: now.Add(2 * self.config.ReplayWindow),
+	})
+
+	return true
+}
+
+// SignRequest returns the HMAC-SHA256 signature of the request.
+// The query is encoded by url.Values.Encode(), ex: request.URL.Query().Encode().
+// Headers are "<name>:<value>" in the order they are listed in the Authorization header.
+func SignRequest(secret []byte, method string, path string, query string, headers []string, timestamp string, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+
+	stringToSign := strings.Builder{}
+	stringToSign.WriteString(strings.ToUpper(method) + "\n")
+	stringToSign.WriteString(path + "\n")
+	stringToSign.WriteString(query + "\n")
+	for _, header := range headers {
+		stringToSign.WriteString(header + "\n")
+	}
+	stringToSign.WriteString(timestamp + "\n")
+	stringToSign.WriteString(hex.EncodeToString(bodyHash[:]))
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(stringToSign.String()))
+
+	return mac.Sum(nil)
+}
+
+// canonicalQuery of the request, which is the same however the client ordered the query parameters.
+// VisitQueryParams does not order the parameters, so Encode sorting them by key is what makes the query canonical.
+func canonicalQuery(requester endpoint.Requester) string {
+	query := url.Values{}
+	requester.VisitQueryParams(func(key []byte, value []byte) {
+		query.Add(string(key), string(value))
+	})
+
+	return query.Encode()
+}
+
+func signedHeaderValues(requester endpoint.Requester, signedHeaders []string) []string {
+	headers := make([]string, 0, len(signedHeaders))
+	for _, signedHeader := range signedHeaders {
+		headers = append(headers, strings.ToLower(signedHeader)+":"+strings.TrimSpace(string(requester.PeekHeader(textproto.CanonicalMIMEHeaderKey(signedHeader)))))
+	}
+
+	return headers
+}
+
+func parseHmacAuthorization(value string) (hmacAuthorization, error) {
+	parsed := hmacAuthorization{}
+
+	for _, part := range strings.Split(value, ",") {
+		name, partValue, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return parsed, errors.New("malformed authorization: %s", part)
+		}
+
+		switch name {
+		case "keyId":
+			parsed.keyId = partValue
+		case "signedHeaders":
+			if partValue != "" {
+				parsed.signedHeaders = strings.Split(partValue, ";")
+			}
+		case "signature":
+			signature, err := base64.StdEncoding.DecodeString(partValue)
+			if err != nil {
+				return parsed, errors.New("malformed signature: %v", err)
+			}
+			parsed.signature = signature
+		}
+	}
+
+	if parsed.keyId == "" || len(parsed.signature) == 0 {
+		return parsed, errors.New("keyId and signature are required")
+	}
+
+	return parsed, nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,243 @@
+package auth_test
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+
+	"github.com/wspowell/spiderweb/auth"
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+type signedRequest struct {
+	method    string
+	path      string
+	body      string
+	keyId     string
+	secret    string
+	timestamp time.Time
+	// tamper changes the request after it is signed.
+	tamper func(req *http.Request)
+}
+
+func newSignedRequest(t *testing.T, signed signedRequest) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), signed.method, signed.path, strings.NewReader(signed.body))
+	assert.Nil(t, err)
+
+	timestamp := strconv.FormatInt(signed.timestamp.Unix(), 10)
+	req.Header.Set(httpheader.ContentType, "application/json")
+	req.Header.Set(auth.HeaderTimestamp, timestamp)
+
+	signature := auth.SignRequest([]byte(signed.secret), signed.method, req.URL.Path, req.URL.Query().Encode(), []string{"content-type:application/json"}, timestamp, []byte(signed.body))
+	req.Header.Set(httpheader.Authorization, auth.SchemeHmac+" keyId="+signed.keyId+",signedHeaders=content-type,signature="+base64.StdEncoding.EncodeToString(signature))
+
+	if signed.tamper != nil {
+		signed.tamper(req)
+	}
+
+	return req
+}
+
+type hmacRequestBody struct {
+	Amount int `json:"amount"`
+}
+
+type hmacEndpoint struct {
+	Partner      *partner         `spiderweb:"auth"`
+	RequestBody  *hmacRequestBody `spiderweb:"request,mime=application/json"`
+	ResponseBody *partnerResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *hmacEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &partnerResponse{Name: self.Partner.Name + ":" + strconv.Itoa(self.RequestBody.Amount)}
+
+	return httpstatus.OK, nil
+}
+
+func Test_HmacAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	validRequest := signedRequest{method: http.MethodPost, path: "/transfers", body: `{"amount":5}`, keyId: "partner-1", secret: "shared-secret", timestamp: testNow}
+
+	testCases := []struct {
+		name               string
+		request            func() signedRequest
+		requiredHeaders    []string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{
+			name:               "valid",
+			request:            func() signedRequest { return validRequest },
+			expectedHttpStatus: httpstatus.OK,
+			expectedBody:       `{"name":"acme:5"}`,
+		},
+		{
+			name:               "required header signed",
+			request:            func() signedRequest { return validRequest },
+			requiredHeaders:    []string{"Content-Type"},
+			expectedHttpStatus: httpstatus.OK,
+			expectedBody:       `{"name":"acme:5"}`,
+		},
+		{
+			name:               "required header not signed",
+			request:            func() signedRequest { return validRequest },
+			requiredHeaders:    []string{"X-Request-Id"},
+			expectedHttpStatus: httpstatus.Unauthorized,
+			expectedBody:       `{"message":"unauthorized"}`,
+		},
+		{
+			name: "wrong secret",
+			request: func() signedRequest {
+				request := validRequest
+				request.secret = "wrong"
+
+				return request
+			},
+			expectedHttpStatus: httpstatus.Unauthorized,
+			expectedBody:       `{"message":"unauthorized"}`,
+		},
+		{
+			name: "unknown key",
+			request: func() signedRequest {
+				request := validRequest
+				request.keyId = "partner-2"
+
+				return request
+			},
+			expectedHttpStatus: httpstatus.Unauthorized,
+			expectedBody:       `{"message":"unauthorized"}`,
+		},
+		{
+			name: "tampered body",
+			request: func() signedRequest {
+				request := validRequest
+				request.tamper = func(req *http.Request) {
+					req.Body = io.NopCloser(strings.NewReader(`{"amount":5000}`))
+				}
+
+				return request
+			},
+			expectedHttpStatus: httpstatus.Unauthorized,
+			expectedBody:       `{"message":"unauthorized"}`,
+		},
+		{
+			name: "signed query in any order",
+			request: func() signedRequest {
+				request := validRequest
+				request.path = "/transfers?to=b&from=a&memo=rent+due"
+				request.tamper = func(req *http.Request) {
+					req.URL.RawQuery = "memo=rent%20due&from=a&to=b"
+				}
+
+				return request
+			},
+			expectedHttpStatus: httpstatus.OK,
+			expectedBody:       `{"name":"acme:5"}`,
+		},
+		{
+			name: "tampered query",
+			request: func() signedRequest {
+				request := validRequest
+				request.path = "/transfers?to=b"
+				request.tamper = func(req *http.Request) {
+					req.URL.RawQuery = "to=c"
+				}
+
+				return request
+			},
+			expectedHttpStatus: httpstatus.Unauthorized,
+			expectedBody:       `{"message":"unauthorized"}`,
+		},
+		{
+			name: "tampered header",
+			request: func() signedRequest {
+				request := validRequest
+				request.tamper = func(req *http.Request) {
+					req.Header.Set(httpheader.ContentType, "application/json; charset=utf-8")
+				}
+
+				return request
+			},
+			expectedHttpStatus: httpstatus.Unauthorized,
+			expectedBody:       `{"message":"unauthorized"}`,
+		},
+		{
+			name: "outside replay window",
+			request: func() signedRequest {
+				request := validRequest
+				request.timestamp = testNow.Add(-10 * time.Minute)
+
+				return request
+			},
+			expectedHttpStatus: httpstatus.Unauthorized,
+			expectedBody:       `{"message":"unauthorized"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			authenticator := auth.NewHmacAuthenticator(auth.HmacConfig{
+				Store: auth.StaticHmacKeys{
+					"partner-1": {Secret: []byte("shared-secret"), Principal: &partner{Name: "acme"}},
+				},
+				RequiredHeaders: testCase.requiredHeaders,
+				Now: func() time.Time {
+					return testNow.Add(time.Minute)
+				},
+			})
+
+			httpStatus, responseBody := executeEndpoint(t, &endpoint.Config{
+				Authenticators: []endpoint.Authenticator{authenticator},
+			}, &hmacEndpoint{}, newSignedRequest(t, testCase.request()))
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedBody, responseBody)
+		})
+	}
+}
+
+func Test_HmacAuthenticator_Replay(t *testing.T) {
+	t.Parallel()
+
+	authenticator := auth.NewHmacAuthenticator(auth.HmacConfig{
+		StoreResource: "hmacKeys",
+		Now: func() time.Time {
+			return testNow
+		},
+	})
+	config := &endpoint.Config{
+		Authenticators: []endpoint.Authenticator{authenticator},
+		Resources: map[string]any{
+			"hmacKeys": auth.StaticHmacKeys{
+				"partner-1": {Secret: []byte("shared-secret"), Principal: &partner{Name: "acme"}},
+			},
+		},
+	}
+	request := signedRequest{method: http.MethodPost, path: "/transfers", body: `{"amount":5}`, keyId: "partner-1", secret: "shared-secret", timestamp: testNow}
+
+	httpStatus, _ := executeEndpoint(t, config, &hmacEndpoint{}, newSignedRequest(t, request))
+	assert.Equal(t, httpstatus.OK, httpStatus)
+
+	httpStatus, _ = executeEndpoint(t, config, &hmacEndpoint{}, newSignedRequest(t, request))
+	assert.Equal(t, httpstatus.Unauthorized, httpStatus)
+
+	// The same request with a different query is a different request.
+	request.path = "/transfers?to=b"
+	httpStatus, _ = executeEndpoint(t, config, &hmacEndpoint{}, newSignedRequest(t, request))
+	assert.Equal(t, httpstatus.OK, httpStatus)
+}
@@ -0,0 +1,220 @@
+// Package auth contains endpoint.Authenticator implementations.
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmHS256 = "HS256"
+
+	schemeBearer = "Bearer"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// JwtConfig of a JwtAuthenticator.
+type JwtConfig struct {
+	// Keys that verify token signatures.
+	Keys KeySet
+	// Algorithms allowed. Defaults to RS256, ES256, and HS256.
+	// The key type must also match the algorithm, so an RSA public key can never be used as an HS256 secret.
+	Algorithms []string
+	// Issuer that must match the "iss" claim, if set.
+	Issuer string
+	// Audience that must be in the "aud" claim, if set.
+	Audience string
+	// ClockSkew allowed when checking "exp" and "nbf".
+	ClockSkew time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// JwtAuthenticator authenticates "Authorization: Bearer <jwt>" requests.
+// The principal is the *Claims of the token.
+type JwtAuthenticator struct {
+	config     JwtConfig
+	algorithms map[string]struct{}
+}
+
+var _ endpoint.Authenticator = (*JwtAuthenticator)(nil)
+
+func NewJwtAuthenticator(config JwtConfig) *JwtAuthenticator {
+	if len(config.Algorithms) == 0 {
+		config.Algorithms = []string{AlgorithmRS256, AlgorithmES256, AlgorithmHS256}
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	algorithms := map[string]struct{}{}
+	for _, algorithm := range config.Algorithms {
+		algorithms[algorithm] = struct{}{}
+	}
+
+	return &JwtAuthenticator{
+		config:     config,
+		algorithms: algorithms,
+	}
+}
+
+func (self *JwtAuthenticator) Scheme() string {
+	return schemeBearer
+}
+
+func (self *JwtAuthenticator) Authenticate(ctx context.Context, requester endpoint.Requester) (any, error) {
+	authorization := requester.PeekHeader(httpheader.Authorization)
+	if len(authorization) <= len(schemeBearer)+1 || !strings.EqualFold(string(authorization[:len(schemeBearer)+1]), schemeBearer+" ") {
+		return nil, endpoint.ErrNoCredentials
+	}
+
+	return self.Validate(ctx, strings.TrimSpace(string(authorization[len(schemeBearer)+1:])))
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyId     string `json:"kid"`
+}
+
+// Validate the token signature and claims.
+func (self *JwtAuthenticator) Validate(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.Wrap(errors.New("malformed token"), ErrInvalidToken)
+	}
+
+	header := &jwtHeader{}
+	if err := decodeSegment(parts[0], header); err != nil {
+		return nil, errors.Wrap(errors.New("malformed header: %v", err), ErrInvalidToken)
+	}
+	if _, allowed := self.algorithms[header.Algorithm]; !allowed {
+		return nil, errors.Wrap(errors.New("algorithm not allowed: %s", header.Algorithm), ErrInvalidToken)
+	}
+
+	key, err := self.config.Keys.Key(ctx, header.KeyId)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrInvalidToken)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(errors.New("malformed signature: %v", err), ErrInvalidToken)
+	}
+	if err := verifySignature(header.Algorithm, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, errors.Wrap(err, ErrInvalidToken)
+	}
+
+	claims := &Claims{}
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, errors.Wrap(errors.New("malformed claims: %v", err), ErrInvalidToken)
+	}
+	if err := decodeSegment(parts[1], &claims.Raw); err != nil {
+		return nil, errors.Wrap(errors.New("malformed claims: %v", err), ErrInvalidToken)
+	}
+
+	if err := self.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (self *JwtAuthenticator) validateClaims(claims *Claims) error {
+	now := self.config.Now()
+
+	if claims.ExpiresAt.IsZero() {
+		return errors.Wrap(errors.New("missing exp"), ErrInvalidToken)
+	}
+	if now.After(claims.ExpiresAt.Add(self.config.ClockSkew)) {
+		return ErrTokenExpired
+	}
+	if !claims.NotBefore.IsZero() && now.Add(self.config.ClockSkew).Before(claims.NotBefore.Time) {
+		return errors.Wrap(errors.New("token not valid yet"), ErrInvalidToken)
+	}
+	if self.config.Issuer != "" && claims.Issuer != self.config.Issuer {
+		return errors.Wrap(errors.New("invalid issuer: %s", claims.Issuer), ErrInvalidToken)
+	}
+	if self.config.Audience != "" && !claims.Audience.Contains(self.config.Audience) {
+		return errors.Wrap(errors.New("invalid audience: %v", claims.Audience), ErrInvalidToken)
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, value any) error {
+	segmentBytes, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(segmentBytes))
+	decoder.UseNumber()
+
+	return decoder.Decode(value)
+}
+
+func verifySignature(algorithm string, key any, signingInput []byte, signature []byte) error {
+	digest := sha256.Sum256(signingInput)
+
+	switch algorithm {
+	case AlgorithmRS256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key is not an RSA public key")
+		}
+
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature)
+	case AlgorithmES256:
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || publicKey.Curve.Params().BitSize != 256 {
+			return errors.New("key is not a P-256 public key")
+		}
+		if len(signature) != 64 {
+			return errors.New("invalid signature length")
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, digest[:], r, s) {
+			return errors.New("invalid signature")
+		}
+
+		return nil
+	case AlgorithmHS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return errors.New("key is not a secret")
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signingInput)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("invalid signature")
+		}
+
+		return nil
+	}
+
+	return errors.New("unsupported algorithm: %s", algorithm)
+}
@@ -0,0 +1,391 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/auth"
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+// nolint:gochecknoglobals // reason: keys are expensive to generate
+var (
+	rsaKey, _   = rsa.GenerateKey(rand.Reader, 2048)
+	ecdsaKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	hmacSecret  = []byte("0123456789abcdef0123456789abcdef")
+
+	testNow = time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func encodeSegment(value any) string {
+	valueBytes, _ := json.Marshal(value)
+
+	return base64.RawURLEncoding.EncodeToString(valueBytes)
+}
+
+func signToken(algorithm string, keyId string, claims map[string]any) string {
+	signingInput := encodeSegment(map[string]any{"alg": algorithm, "kid": keyId, "typ": "JWT"}) + "." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch algorithm {
+	case auth.AlgorithmRS256:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	case auth.AlgorithmES256:
+		r, s, _ := ecdsa.Sign(rand.Reader, ecdsaKey, digest[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case auth.AlgorithmHS256:
+		mac := hmac.New(sha256.New, hmacSecret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encodeBigInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+func writeJwks(t *testing.T, path string, rsaKeyId string) {
+	t.Helper()
+
+	jwks := map[string]any{
+		"keys": []map[string]any{
+			{"kty": "RSA", "kid": rsaKeyId, "use": "sig", "n": encodeBigInt(rsaKey.N), "e": encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encodeBigInt(ecdsaKey.X), "y": encodeBigInt(ecdsaKey.Y)},
+			{"kty": "oct", "kid": "hmac-1", "k": base64.RawURLEncoding.EncodeToString(hmacSecret)},
+			{"kty": "RSA", "kid": "encryption", "use": "enc", "n": encodeBigInt(rsaKey.N), "e": "AQAB"},
+		},
+	}
+	jwksBytes, err := json.Marshal(jwks)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(path, jwksBytes, 0o600))
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":   "https://issuer.example.com",
+		"sub":   "user-1",
+		"aud":   []string{"api", "other"},
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"nbf":   testNow.Add(-time.Minute).Unix(),
+		"scope": "notes:read notes:write",
+		"org":   "example",
+	}
+}
+
+func withClaim(name string, value any) map[string]any {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, name)
+	} else {
+		claims[name] = value
+	}
+
+	return claims
+}
+
+func newJwtAuthenticator(t *testing.T) (*auth.JwtAuthenticator, string) {
+	t.Helper()
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJwks(t, jwksPath, "rsa-1")
+
+	keySet, err := auth.NewJwksKeySet(context.Background(), auth.JwksConfig{
+		Source:             jwksPath,
+		MinRefreshInterval: time.Nanosecond,
+	})
+	assert.Nil(t, err)
+
+	return auth.NewJwtAuthenticator(auth.JwtConfig{
+		Keys:      keySet,
+		Issuer:    "https://issuer.example.com",
+		Audience:  "api",
+		ClockSkew: time.Minute,
+		Now: func() time.Time {
+			return testNow
+		},
+	}), jwksPath
+}
+
+func Test_JwtAuthenticator_Validate(t *testing.T) {
+	t.Parallel()
+
+	authenticator, _ := newJwtAuthenticator(t)
+
+	testCases := []struct {
+		name          string
+		token         string
+		expectedError error
+	}{
+		{name: "RS256", token: signToken(auth.AlgorithmRS256, "rsa-1", validClaims())},
+		{name: "ES256", token: signToken(auth.AlgorithmES256, "ec-1", validClaims())},
+		{name: "HS256", token: signToken(auth.AlgorithmHS256, "hmac-1", validClaims())},
+		{name: "single audience", token: signToken(auth.AlgorithmRS256, "rsa-1", withClaim("aud", "api"))},
+		{name: "expired within skew", token: signToken(auth.AlgorithmRS256, "rsa-1", withClaim("exp", testNow.Add(-30*time.Second).Unix()))},
+		{name: "expired", token: signToken(auth.AlgorithmRS256, "rsa-1", withClaim("exp", testNow.Add(-2*time.Minute).Unix())), expectedError: auth.ErrTokenExpired},
+		{name: "missing exp", token: signToken(auth.AlgorithmRS256, "rsa-1", withClaim("exp", nil)), expectedError: auth.ErrInvalidToken},
+		{name: "not before", token: signToken(auth.AlgorithmRS256, "rsa-1", withClaim("nbf", testNow.Add(2*time.Minute).Unix())), expectedError: auth.ErrInvalidToken},
+		{name: "wrong issuer", token: signToken(auth.AlgorithmRS256, "rsa-1", withClaim("iss", "https://evil.example.com")), expectedError: auth.ErrInvalidToken},
+		{name: "wrong audience", token: signToken(auth.AlgorithmRS256, "rsa-1", withClaim("aud", "other")), expectedError: auth.ErrInvalidToken},
+		{name: "unknown key", token: signToken(auth.AlgorithmRS256, "rsa-2", validClaims()), expectedError: auth.ErrInvalidToken},
+		{name: "encryption key", token: signToken(auth.AlgorithmRS256, "encryption", validClaims()), expectedError: auth.ErrInvalidToken},
+		{name: "algorithm confusion", token: signToken(auth.AlgorithmHS256, "rsa-1", validClaims()), expectedError: auth.ErrInvalidToken},
+		{name: "algorithm none", token: encodeSegment(map[string]any{"alg": "none"}) + "." + encodeSegment(validClaims()) + ".", expectedError: auth.ErrInvalidToken},
+		{name: "tampered", token: signToken(auth.AlgorithmRS256, "rsa-1", validClaims())[:20] + encodeSegment(withClaim("sub", "admin")) + ".AAAA", expectedError: auth.ErrInvalidToken},
+		{name: "malformed", token: "not-a-token", expectedError: auth.ErrInvalidToken},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			claims, err := authenticator.Validate(context.Background(), testCase.token)
+			if testCase.expectedError != nil {
+				assert.True(t, errors.Is(err, testCase.expectedError), "%v", err)
+				assert.Nil(t, claims)
+
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, "user-1", claims.Subject)
+			assert.True(t, claims.HasScope("notes:write"))
+			assert.Equal(t, "example", claims.Raw["org"])
+		})
+	}
+}
+
+func Test_JwksKeySet_Rotation(t *testing.T) {
+	t.Parallel()
+
+	authenticator, jwksPath := newJwtAuthenticator(t)
+
+	token := signToken(auth.AlgorithmRS256, "rsa-2", validClaims())
+	_, err := authenticator.Validate(context.Background(), token)
+	assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+
+	// Rotate the key. The unknown key ID reloads the JWKS.
+	writeJwks(t, jwksPath, "rsa-2")
+
+	claims, err := authenticator.Validate(context.Background(), token)
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+}
+
+func Test_JwksKeySet_Unknown_Key_Refresh(t *testing.T) {
+	t.Parallel()
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJwks(t, jwksPath, "rsa-1")
+	initialJwks, err := os.ReadFile(jwksPath)
+	assert.Nil(t, err)
+	writeJwks(t, jwksPath, "rsa-2")
+	rotatedJwks, err := os.ReadFile(jwksPath)
+	assert.Nil(t, err)
+
+	var mutex sync.Mutex
+	requests := 0
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		requests++
+		count := requests
+		mutex.Unlock()
+
+		switch count {
+		case 1:
+			_, _ = writer.Write(initialJwks)
+		case 2:
+			received <- struct{}{}
+			<-release
+			_, _ = writer.Write(rotatedJwks)
+		default:
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	keySet, err := auth.NewJwksKeySet(context.Background(), auth.JwksConfig{
+		Source:             server.URL,
+		MinRefreshInterval: 200 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	// Concurrent unknown key IDs share one refresh.
+	time.Sleep(200 * time.Millisecond)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			key, err := keySet.Key(context.Background(), "rsa-2")
+			assert.Nil(t, err)
+			assert.NotNil(t, key)
+		}()
+	}
+	<-received
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// A failed refresh is still an attempt, so unknown key IDs do not refresh again within MinRefreshInterval.
+	time.Sleep(200 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		_, err = keySet.Key(context.Background(), "rsa-3")
+		assert.True(t, errors.Is(err, auth.ErrKeyNotFound))
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, 3, requests)
+}
+
+func Test_ParseJwks(t *testing.T) {
+	t.Parallel()
+
+	rsaJwk := map[string]any{"kty": "RSA", "kid": "rsa-1", "n": encodeBigInt(rsaKey.N), "e": encodeBigInt(big.NewInt(int64(rsaKey.E)))}
+	p384Jwk := map[string]any{"kty": "EC", "kid": "ec-384", "crv": "P-384", "x": "AQAB", "y": "AQAB"}
+	ed25519Jwk := map[string]any{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": "AQAB"}
+
+	testCases := []struct {
+		name         string
+		keys         []map[string]any
+		expectedKeys []string
+		expectedErr  error
+	}{
+		{name: "mixed key types", keys: []map[string]any{p384Jwk, rsaJwk, ed25519Jwk}, expectedKeys: []string{"rsa-1"}},
+		{name: "no usable keys", keys: []map[string]any{p384Jwk, ed25519Jwk}, expectedErr: auth.ErrInvalidJwks},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			jwksBytes, err := json.Marshal(map[string]any{"keys": testCase.keys})
+			assert.Nil(t, err)
+
+			keys, err := auth.ParseJwks(context.Background(), jwksBytes)
+			assert.ErrorIs(t, err, testCase.expectedErr)
+
+			keyIds := []string{}
+			for keyId := range keys {
+				keyIds = append(keyIds, keyId)
+			}
+			if testCase.expectedKeys == nil {
+				assert.Empty(t, keyIds)
+			} else {
+				assert.Equal(t, testCase.expectedKeys, keyIds)
+			}
+		})
+	}
+
+	_, err := auth.ParseJwks(context.Background(), []byte(`{"keys": [`))
+	assert.ErrorIs(t, err, auth.ErrInvalidJwks)
+}
+
+type claimsResponse struct {
+	Subject string `json:"subject"`
+}
+
+type claimsEndpoint struct {
+	Claims       *auth.Claims    `spiderweb:"auth"`
+	ResponseBody *claimsResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *claimsEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &claimsResponse{Subject: self.Claims.Subject}
+
+	return httpstatus.OK, nil
+}
+
+func Test_JwtAuthenticator_Endpoint(t *testing.T) {
+	t.Parallel()
+
+	authenticator, _ := newJwtAuthenticator(t)
+
+	testCases := []struct {
+		name               string
+		authorization      string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{name: "valid", authorization: "Bearer " + signToken(auth.AlgorithmES256, "ec-1", validClaims()), expectedHttpStatus: httpstatus.OK, expectedBody: `{"subject":"user-1"}`},
+		{name: "lowercase scheme", authorization: "bearer " + signToken(auth.AlgorithmES256, "ec-1", validClaims()), expectedHttpStatus: httpstatus.OK, expectedBody: `{"subject":"user-1"}`},
+		{name: "invalid", authorization: "Bearer invalid", expectedHttpStatus: httpstatus.Unauthorized, expectedBody: `{"message":"unauthorized"}`},
+		{name: "other scheme", authorization: "Basic dXNlcjpwYXNz", expectedHttpStatus: httpstatus.Unauthorized, expectedBody: `{"message":"unauthorized"}`},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/claims", nil)
+			assert.Nil(t, err)
+			req.Header.Add(httpheader.Authorization, testCase.authorization)
+
+			httpStatus, responseBody := executeEndpoint(t, &endpoint.Config{
+				Authenticators: []endpoint.Authenticator{authenticator},
+			}, &claimsEndpoint{}, req)
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedBody, responseBody)
+		})
+	}
+}
+
+func executeEndpoint(t *testing.T, config *endpoint.Config, handler endpoint.Handler, req *http.Request) (int, string) {
+	t.Helper()
+
+	ctx := context.Background()
+	ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+	config.LogConfig = log.NewConfig().WithLevel(log.LevelError)
+	testEndpoint := endpoint.NewEndpoint(ctx, config, handler)
+
+	requester, err := endpoint.NewHttpRequester(req.URL.Path, req)
+	assert.Nil(t, err)
+
+	var httpStatus int
+	var responseBodyBytes []byte
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+	}()
+	wg.Wait()
+
+	return httpStatus, string(responseBodyBytes)
+}
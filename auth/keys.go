@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+)
+
+var (
+	ErrKeyNotFound = errors.New("key not found")
+	ErrInvalidJwks = errors.New("invalid JWKS")
+)
+
+// KeySet provides the keys that verify token signatures.
+// Keys are *rsa.PublicKey for RS256, *ecdsa.PublicKey for ES256, and []byte for HS256.
+type KeySet interface {
+	// Key with the key ID. The key ID is empty if the token header has no "kid".
+	// Returns ErrKeyNotFound if there is no such key.
+	Key(ctx context.Context, keyId string) (any, error)
+}
+
+// StaticKeys is a fixed KeySet of key ID to key.
+// The empty key ID matches tokens without a "kid".
+type StaticKeys map[string]any
+
+func (self StaticKeys) Key(ctx context.Context, keyId string) (any, error) {
+	key, exists := self[keyId]
+	if !exists {
+		return nil, errors.Wrap(errors.New("kid: %s", keyId), ErrKeyNotFound)
+	}
+
+	return key, nil
+}
+
+// JwksConfig of a JwksKeySet.
+type JwksConfig struct {
+	// Source of the JWKS. Either an http(s) URL or a file path.
+	Source string
+	// RefreshInterval between scheduled reloads of the JWKS. Defaults to 1 hour.
+	RefreshInterval time.Duration
+	// MinRefreshInterval between reloads triggered by an unknown key ID, which happen when keys are rotated. Defaults to 1 minute.
+	MinRefreshInterval time.Duration
+	// HttpClient used to fetch URL sources. Defaults to a client with a 10 second timeout.
+	HttpClient *http.Client
+}
+
+// JwksKeySet is a KeySet loaded from a JSON Web Key Set that is refreshed on a schedule.
+type JwksKeySet struct {
+	config JwksConfig
+
+	mutex sync.RWMutex
+	keys  StaticKeys
+	// lastRefresh is the time of the last refresh attempt, successful or not.
+	lastRefresh time.Time
+	// refreshing is closed when the running refresh for an unknown key ID finishes.
+	refreshing chan struct{}
+}
+
+// NewJwksKeySet loads the JWKS and refreshes it until the context is done.
+func NewJwksKeySet(ctx context.Context, config JwksConfig) (*JwksKeySet, error) {
+	if config.RefreshInterval == 0 {
+		config.RefreshInterval = time.Hour
+	}
+	if config.MinRefreshInterval == 0 {
+		config.MinRefreshInterval = time.Minute
+	}
+	if config.HttpClient == nil {
+		config.HttpClient = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+
+	keySet := &JwksKeySet{
+		config: config,
+	}
+	if err := keySet.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	go keySet.refreshForever(ctx)
+
+	return keySet, nil
+}
+
+func (self *JwksKeySet) refreshForever(ctx context.Context) {
+	ticker := time.NewTicker(self.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := self.Refresh(ctx); err != nil {
+				// Keep using the current keys.
+				log.Error(ctx, "failed to refresh JWKS: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh the keys from the source.
+func (self *JwksKeySet) Refresh(ctx context.Context) error {
+	// Recorded before loading so that a failing source is not retried by every request.
+	self.mutex.Lock()
+	self.lastRefresh = time.Now()
+	self.mutex.Unlock()
+
+	jwksBytes, err := self.load(ctx)
+	if err != nil {
+		return errors.Wrap(err, ErrInvalidJwks)
+	}
+
+	keys, err := ParseJwks(ctx, jwksBytes)
+	if err != nil {
+		return err
+	}
+
+	self.mutex.Lock()
+	self.keys = keys
+	self.mutex.Unlock()
+
+	return nil
+}
+
+func (self *JwksKeySet) load(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(self.config.Source, "http://") && !strings.HasPrefix(self.config.Source, "https://") {
+		return os.ReadFile(self.config.Source)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, self.config.Source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := self.config.HttpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected status: %d", response.StatusCode)
+	}
+
+	return io.ReadAll(response.Body)
+}
+
+// Key with the key ID.
+// Unknown key IDs refresh the keys, at most once per MinRefreshInterval, since the keys may have been rotated.
+// Concurrent requests with unknown key IDs wait for the same refresh.
+func (self *JwksKeySet) Key(ctx context.Context, keyId string) (any, error) {
+	self.mutex.RLock()
+	key, exists := self.keys[keyId]
+	self.mutex.RUnlock()
+
+	if exists {
+		return key, nil
+	}
+
+	self.refreshUnknownKey(ctx)
+
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	return self.keys.Key(ctx, keyId)
+}
+
+func (self *JwksKeySet) refreshUnknownKey(ctx context.Context) {
+	self.mutex.Lock()
+	if refreshing := self.refreshing; refreshing != nil {
+		self.mutex.Unlock()
+
+		select {
+		case <-refreshing:
+		case <-ctx.Done():
+		}
+
+		return
+	}
+	if time.Since(self.lastRefresh) < self.config.MinRefreshInterval {
+		self.mutex.Unlock()
+
+		return
+	}
+	refreshing := make(chan struct{})
+	self.refreshing = refreshing
+	self.mutex.Unlock()
+
+	defer func() {
+		self.mutex.Lock()
+		self.refreshing = nil
+		self.mutex.Unlock()
+		close(refreshing)
+	}()
+
+	if err := self.Refresh(ctx); err != nil {
+		log.Error(ctx, "failed to refresh JWKS: %v", err)
+	}
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyId   string `json:"kid"`
+	Use     string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+
+	// Symmetric
+	K string `json:"k"`
+}
+
+// ParseJwks parses RSA, P-256 EC, and symmetric keys from a JSON Web Key Set.
+// Keys that are not used for signatures are skipped, as are keys that cannot be used, such as other curves.
+// Returns ErrInvalidJwks if the JWKS is malformed or has no usable signing keys.
+func ParseJwks(ctx context.Context, jwksBytes []byte) (StaticKeys, error) {
+	jwks := &jsonWebKeySet{}
+	if err := json.Unmarshal(jwksBytes, jwks); err != nil {
+		return nil, errors.Wrap(err, ErrInvalidJwks)
+	}
+
+	keys := StaticKeys{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.key()
+		if err != nil {
+			// Providers publish keys of every type they support, so one unusable key must not reject the whole set.
+			log.Warn(ctx, "skipping JWKS key %s: %v", jwk.KeyId, err)
+
+			continue
+		}
+		keys[jwk.KeyId] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.Wrap(errors.New("no usable signing keys"), ErrInvalidJwks)
+	}
+
+	return keys, nil
+}
+
+func (self jsonWebKey) key() (any, error) {
+	switch self.KeyType {
+	case "RSA":
+		modulus, err := decodeBigInt(self.N)
+		if err != nil {
+			return nil, err
+		}
+		exponent, err := decodeBigInt(self.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}, nil
+	case "EC":
+		if self.Curve != "P-256" {
+			return nil, errors.New("unsupported curve: %s", self.Curve)
+		}
+		x, err := decodeBigInt(self.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(self.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(self.K)
+	}
+
+	return nil, errors.New("unsupported key type: %s", self.KeyType)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	valueBytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(valueBytes), nil
+}
@@ -0,0 +1,197 @@
+// Package client calls spiderweb APIs.
+// It is the runtime used by clients generated with clientgen, but it may also be used directly.
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+)
+
+// RequestEditor is called before every request is sent. Ex: add an Authorization header.
+type RequestEditor func(ctx context.Context, request *http.Request) error
+
+// Option configures the Client.
+type Option func(client *Client)
+
+// WithHttpClient used to send requests. Defaults to http.DefaultClient.
+func WithHttpClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithMimeTypeHandlers used to encode request bodies and decode response bodies. Defaults to endpoint.NewMimeTypeHandlers().
+func WithMimeTypeHandlers(mimeTypeHandlers endpoint.MimeTypeHandlers) Option {
+	return func(client *Client) {
+		for mimeType, handler := range mimeTypeHandlers {
+			client.mimeTypeHandlers[mimeType] = handler
+		}
+	}
+}
+
+// WithRequestEditor called before every request is sent.
+func WithRequestEditor(requestEditor RequestEditor) Option {
+	return func(client *Client) {
+		client.requestEditors = append(client.requestEditors, requestEditor)
+	}
+}
+
+// Client sends requests to a spiderweb API.
+type Client struct {
+	baseUrl          string
+	httpClient       *http.Client
+	mimeTypeHandlers endpoint.MimeTypeHandlers
+	requestEditors   []RequestEditor
+}
+
+// New client for the API at the base URL. Ex: https://api.example.com
+func New(baseUrl string, options ...Option) *Client {
+	client := &Client{
+		baseUrl:          strings.TrimSuffix(baseUrl, "/"),
+		httpClient:       http.DefaultClient,
+		mimeTypeHandlers: endpoint.NewMimeTypeHandlers(),
+	}
+
+	for _, option := range options {
+		option(client)
+	}
+
+	return client
+}
+
+// Do sends the request and decodes the response body into responseBody, which must be a pointer or nil.
+// Responses with a status of 400 or greater return an *Error with the error response body decoded into E.
+func Do[E any](ctx context.Context, client *Client, request *Request, responseBody any) error {
+	response, err := client.send(ctx, request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	responseBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return newError[E](client, response, responseBytes)
+	}
+
+	if responseBody == nil || len(responseBytes) == 0 {
+		return nil
+	}
+
+	mimeTypeHandler, err := client.responseMimeTypeHandler(response, request)
+	if err != nil {
+		return err
+	}
+	if err := mimeTypeHandler.Unmarshal(responseBytes, responseBody); err != nil {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	return nil
+}
+
+// DoStream sends the request and returns the response body without reading it.
+// The caller must close the returned body.
+// Responses with a status of 400 or greater return an *Error with the error response body decoded into E.
+func DoStream[E any](ctx context.Context, client *Client, request *Request) (io.ReadCloser, error) {
+	response, err := client.send(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		defer response.Body.Close()
+
+		responseBytes, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		return nil, newError[E](client, response, responseBytes)
+	}
+
+	return response.Body, nil
+}
+
+func (self *Client) send(ctx context.Context, request *Request) (*http.Response, error) {
+	var body io.Reader
+	switch {
+	case request.bodyStream != nil:
+		body = request.bodyStream
+	case request.body != nil:
+		mimeTypeHandler, exists := self.mimeTypeHandlers[request.contentType]
+		if !exists {
+			return nil, errors.New("no MimeTypeHandler registered for request MIME type: %s", request.contentType)
+		}
+
+		bodyBytes, err := mimeTypeHandler.Marshal(request.body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		body = bytes.NewReader(bodyBytes)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, request.method, self.baseUrl+request.url(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for name, values := range request.header {
+		httpRequest.Header[name] = values
+	}
+	if body != nil && request.contentType != "" {
+		httpRequest.Header.Set(httpheader.ContentType, request.contentType)
+	}
+	if request.accept != "" {
+		httpRequest.Header.Set(httpheader.Accept, request.accept)
+	}
+	for _, cookie := range request.cookies {
+		httpRequest.AddCookie(cookie)
+	}
+
+	for _, requestEditor := range self.requestEditors {
+		if err := requestEditor(ctx, httpRequest); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := self.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %s %s: %w", request.method, request.path, err)
+	}
+
+	return response, nil
+}
+
+// responseMimeTypeHandler for the response Content-Type, falling back to the requested MIME type.
+func (self *Client) responseMimeTypeHandler(response *http.Response, request *Request) (*endpoint.MimeTypeHandler, error) {
+	mimeType := request.accept
+	if contentType := response.Header.Get(httpheader.ContentType); contentType != "" {
+		if parsed, _, err := mime.ParseMediaType(contentType); err == nil {
+			mimeType = parsed
+		}
+	}
+
+	mimeTypeHandler, exists := self.mimeTypeHandlers[mimeType]
+	if !exists && strings.HasSuffix(mimeType, "+json") {
+		// Structured syntax suffix (RFC 6839), ex: application/problem+json
+		mimeTypeHandler, exists = self.mimeTypeHandlers["application/json"]
+	}
+	if !exists {
+		return nil, errors.New("no MimeTypeHandler registered for response MIME type: %s", mimeType)
+	}
+
+	return mimeTypeHandler, nil
+}
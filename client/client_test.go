@@ -0,0 +1,122 @@
+package client_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/client"
+)
+
+type note struct {
+	Id   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+func Test_FormatValue(t *testing.T) {
+	t.Parallel()
+
+	limit := 10
+	var missing *int
+
+	testCases := []struct {
+		name     string
+		value    any
+		expected []string
+	}{
+		{name: "string", value: "value", expected: []string{"value"}},
+		{name: "int", value: -5, expected: []string{"-5"}},
+		{name: "bool", value: true, expected: []string{"true"}},
+		{name: "float", value: 1.5, expected: []string{"1.5"}},
+		{name: "pointer", value: &limit, expected: []string{"10"}},
+		{name: "nil pointer", value: missing, expected: nil},
+		{name: "slice", value: []int{1, 2}, expected: []string{"1", "2"}},
+		{name: "duration", value: 90 * time.Second, expected: []string{"1m30s"}},
+		{name: "time", value: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), expected: []string{"2022-01-02T03:04:05Z"}},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testCase.expected, client.FormatValue(testCase.value))
+		})
+	}
+}
+
+func Test_Do(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+
+		if request.URL.Path != "/notes/abc" {
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = writer.Write([]byte(`{"message":"not found"}`))
+
+			return
+		}
+
+		cookie, _ := request.Cookie("session")
+		_, _ = writer.Write([]byte(`{"id":"abc","text":"` +
+			request.URL.Query().Get("limit") + "," + request.Header.Get("X-Tenant-Id") + "," + cookie.Value + `"}`))
+	}))
+	defer server.Close()
+
+	apiClient := client.New(server.URL + "/")
+
+	request := client.NewRequest(http.MethodGet, "/notes/{id}").
+		SetPathParam("id", "abc").
+		AddQueryParam("limit", 10).
+		AddHeader("x-tenant-id", "tenant").
+		AddCookie("session", "secret").
+		SetAccept("application/json")
+
+	responseBody := &note{}
+	err := client.Do[errorResponse](context.Background(), apiClient, request, responseBody)
+	assert.Nil(t, err)
+	assert.Equal(t, &note{Id: "abc", Text: "10,tenant,secret"}, responseBody)
+
+	request = client.NewRequest(http.MethodGet, "/notes/{id}").SetPathParam("id", "xyz")
+	err = client.Do[errorResponse](context.Background(), apiClient, request, responseBody)
+
+	var clientError *client.Error[errorResponse]
+	assert.True(t, errors.As(err, &clientError))
+	assert.Equal(t, http.StatusNotFound, clientError.StatusCode)
+	assert.Equal(t, &errorResponse{Message: "not found"}, clientError.Response)
+}
+
+func Test_Do_Error_Cause(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write([]byte(`{"id":`))
+	}))
+	defer server.Close()
+
+	apiClient := client.New(server.URL)
+
+	err := client.Do[errorResponse](context.Background(), apiClient, client.NewRequest(http.MethodGet, "/notes"), &note{})
+	var syntaxError *json.SyntaxError
+	assert.True(t, errors.As(err, &syntaxError))
+	assert.Contains(t, err.Error(), "failed to decode response body: ")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = client.Do[errorResponse](ctx, apiClient, client.NewRequest(http.MethodGet, "/notes"), &note{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, err.Error(), "request failed: GET /notes: ")
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Error is a response with a status of 400 or greater.
+// E is the type of response body created by the ErrorHandler of the endpoint.
+type Error[E any] struct {
+	StatusCode int
+	// Response is the decoded error response body. Nil if the body could not be decoded.
+	Response *E
+	// Body is the raw error response body.
+	Body []byte
+}
+
+func newError[E any](client *Client, response *http.Response, responseBytes []byte) *Error[E] {
+	clientError := &Error[E]{
+		StatusCode: response.StatusCode,
+		Body:       responseBytes,
+	}
+
+	if len(responseBytes) != 0 {
+		if mimeTypeHandler, err := client.responseMimeTypeHandler(response, &Request{}); err == nil {
+			errorResponse := new(E)
+			if err := mimeTypeHandler.Unmarshal(responseBytes, errorResponse); err == nil {
+				clientError.Response = errorResponse
+			}
+		}
+	}
+
+	return clientError
+}
+
+func (self *Error[E]) Error() string {
+	return fmt.Sprintf("%d %s: %s", self.StatusCode, http.StatusText(self.StatusCode), self.Body)
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"encoding"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nolint:gochecknoglobals // reason: cached reflection types
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	durationType      = reflect.TypeOf(time.Duration(0))
+)
+
+// Request to a route.
+// Parameter values are formatted the same way the spiderweb struct tags bind them.
+// Nil pointers and nil slices are not sent.
+type Request struct {
+	method     string
+	path       string
+	pathParams map[string]string
+	query      url.Values
+	header     http.Header
+	cookies    []*http.Cookie
+
+	contentType string
+	accept      string
+	body        any
+	bodyStream  io.Reader
+}
+
+// NewRequest for the route. Path parameters use the route syntax, ex: /resources/{id}
+func NewRequest(method string, path string) *Request {
+	return &Request{
+		method:     method,
+		path:       path,
+		pathParams: map[string]string{},
+		query:      url.Values{},
+		header:     http.Header{},
+	}
+}
+
+// SetPathParam replaces "{name}" in the route path.
+func (self *Request) SetPathParam(name string, value any) *Request {
+	if values := FormatValue(value); len(values) != 0 {
+		self.pathParams[name] = strings.Join(values, ",")
+	}
+
+	return self
+}
+
+// AddQueryParam adds a query parameter. Slices add one value per item.
+func (self *Request) AddQueryParam(name string, value any) *Request {
+	for _, formatted := range FormatValue(value) {
+		self.query.Add(name, formatted)
+	}
+
+	return self
+}
+
+// AddHeader adds a request header. Slices are comma separated, since the server only reads the first header line.
+func (self *Request) AddHeader(name string, value any) *Request {
+	if values := FormatValue(value); len(values) != 0 {
+		self.header.Add(textproto.CanonicalMIMEHeaderKey(name), strings.Join(values, ","))
+	}
+
+	return self
+}
+
+// AddCookie adds a request cookie. Slices are comma separated.
+func (self *Request) AddCookie(name string, value any) *Request {
+	if values := FormatValue(value); len(values) != 0 {
+		self.cookies = append(self.cookies, &http.Cookie{Name: name, Value: strings.Join(values, ",")})
+	}
+
+	return self
+}
+
+// SetBody encoded using the MimeTypeHandler of the content type.
+func (self *Request) SetBody(contentType string, body any) *Request {
+	self.contentType = contentType
+	self.body = body
+
+	return self
+}
+
+// SetBodyStream sent as-is.
+func (self *Request) SetBodyStream(contentType string, body io.Reader) *Request {
+	self.contentType = contentType
+	self.bodyStream = body
+
+	return self
+}
+
+// SetAccept sets the MIME type of the response body.
+func (self *Request) SetAccept(mimeType string) *Request {
+	self.accept = mimeType
+
+	return self
+}
+
+func (self *Request) url() string {
+	path := self.path
+	for name, value := range self.pathParams {
+		path = replacePathParam(path, name, url.PathEscape(value))
+	}
+
+	if len(self.query) == 0 {
+		return path
+	}
+
+	return path + "?" + self.query.Encode()
+}
+
+// replacePathParam replaces "{name}", including any route options (ex: "{name:regex}" or "{name?}").
+func replacePathParam(path string, name string, value string) string {
+	for _, suffix := range []string{"}", "?}", ":"} {
+		start := strings.Index(path, "{"+name+suffix)
+		if start == -1 {
+			continue
+		}
+
+		end := strings.Index(path[start:], "}")
+		if end == -1 {
+			return path
+		}
+
+		return path[:start] + value + path[start+end+1:]
+	}
+
+	return path
+}
+
+// FormatValue formats a parameter value as strings.
+// Pointers are dereferenced, slices are formatted per item, and nil values return no strings.
+// encoding.TextMarshaler and time.Duration use their text forms.
+func FormatValue(value any) []string {
+	return formatValue(reflect.ValueOf(value))
+}
+
+func formatValue(value reflect.Value) []string {
+	if !value.IsValid() {
+		return nil
+	}
+
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	if value.Type().Implements(textMarshalerType) {
+		// nolint:forcetypeassert // reason: checked by Implements
+		text, err := value.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil
+		}
+
+		return []string{string(text)}
+	}
+
+	if value.Type() == durationType {
+		return []string{time.Duration(value.Int()).String()}
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil
+		}
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return []string{string(value.Bytes())}
+		}
+
+		values := []string{}
+		for index := 0; index < value.Len(); index++ {
+			values = append(values, formatValue(value.Index(index))...)
+		}
+
+		return values
+	case reflect.String:
+		return []string{value.String()}
+	case reflect.Bool:
+		return []string{strconv.FormatBool(value.Bool())}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(value.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(value.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return []string{strconv.FormatFloat(value.Float(), 'f', -1, value.Type().Bits())}
+	}
+
+	return nil
+}
@@ -0,0 +1,329 @@
+// Package clientgen generates typed Go clients from spiderweb routes.
+//
+// Generation is done by a small program in the service repository that passes its routes to Generate,
+// which is then run by "go generate". Ex:
+//
+//	//go:generate go run ./cmd/clientgen -out client/client_gen.go
+//
+// Each route becomes a Client method named after the handler struct. Path, query, header, and cookie
+// parameters are passed in a "<Method>Params" struct, the request body is passed as its own argument,
+// and the response body is returned. Responses with a status of 400 or greater return a *client.Error
+// with the response body decoded into the type created by the ErrorHandler.
+package clientgen
+
+import (
+	"fmt"
+	"go/format"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/server/route"
+)
+
+const (
+	clientPackagePath = "github.com/wspowell/spiderweb/client"
+
+	mimeTypeMultipartFormData = "multipart/form-data"
+)
+
+// Config of the generated client.
+type Config struct {
+	// PackageName of the generated file.
+	PackageName string
+	// EndpointConfig the routes are handled with.
+	// The ErrorHandler determines the type of error responses and the MimeTypeHandlers must include every route MIME type.
+	EndpointConfig *endpoint.Config
+}
+
+// Generate the source of a client with one method per route.
+func Generate(ctx context.Context, config Config, routes []route.Route) ([]byte, error) {
+	if config.PackageName == "" {
+		return nil, errors.New("client package name is required")
+	}
+
+	endpointConfig := config.EndpointConfig
+	if endpointConfig == nil {
+		endpointConfig = &endpoint.Config{}
+	}
+
+	generator := &generator{
+		types:       newTypes(),
+		methodNames: map[string]struct{}{},
+		errorTypes:  map[string]struct{}{},
+	}
+	for _, reserved := range []string{"Client", "New", "Error"} {
+		generator.types.reserve(reserved)
+	}
+
+	// Always used by the Client.
+	generator.types.importPackage("github.com/wspowell/context")
+	generator.types.importPackage(clientPackagePath)
+
+	methods := strings.Builder{}
+	for _, routeDefinition := range routes {
+		routeEndpoint := endpoint.NewEndpoint(ctx, routeDefinition.EndpointConfig(endpointConfig), routeDefinition.Handler)
+		methods.WriteString(generator.method(routeDefinition, routeEndpoint.Describe(ctx)))
+	}
+
+	source := strings.Builder{}
+	source.WriteString("// Code generated by spiderweb clientgen. DO NOT EDIT.\n\n")
+	source.WriteString("package " + config.PackageName + "\n\n")
+
+	// Models must be found before the imports are written.
+	models := generator.types.modelDefinitions()
+
+	source.WriteString(generator.importBlock())
+	source.WriteString(`
+// Client calls the API.
+type Client struct {
+	client *client.Client
+}
+
+// New client for the API at the base URL. Ex: https://api.example.com
+func New(baseUrl string, options ...client.Option) *Client {
+	return &Client{
+		client: client.New(baseUrl, options...),
+	}
+}
+
+`)
+
+	if len(generator.errorTypes) == 1 {
+		for errorType := range generator.errorTypes {
+			source.WriteString("// Error returned by every method when the response status is 400 or greater.\n")
+			source.WriteString("type Error = client.Error[" + errorType + "]\n\n")
+		}
+	}
+
+	source.WriteString(methods.String())
+	source.WriteString(models)
+
+	formatted, err := format.Source([]byte(source.String()))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.New("failed to format generated client"))
+	}
+
+	return formatted, nil
+}
+
+type generator struct {
+	types       *types
+	methodNames map[string]struct{}
+	errorTypes  map[string]struct{}
+}
+
+func (self *generator) importBlock() string {
+	paths := make([]string, 0, len(self.types.imports))
+	for pkgPath := range self.types.imports {
+		paths = append(paths, pkgPath)
+	}
+	sort.Strings(paths)
+
+	// Standard library imports are grouped first.
+	sort.SliceStable(paths, func(i int, j int) bool {
+		return !isThirdParty(paths[i]) && isThirdParty(paths[j])
+	})
+
+	block := strings.Builder{}
+	block.WriteString("import (\n")
+	for index, pkgPath := range paths {
+		if index != 0 && isThirdParty(pkgPath) && !isThirdParty(paths[index-1]) {
+			block.WriteString("\n")
+		}
+
+		name := self.types.imports[pkgPath]
+		if name == path.Base(pkgPath) {
+			block.WriteString("\t" + strconv.Quote(pkgPath) + "\n")
+		} else {
+			block.WriteString("\t" + name + " " + strconv.Quote(pkgPath) + "\n")
+		}
+	}
+	block.WriteString(")\n")
+
+	return block.String()
+}
+
+func (self *generator) methodName(handlerName string) string {
+	name := exportedIdentifier(handlerName)
+	unique := name
+	for suffix := 2; ; suffix++ {
+		_, methodExists := self.methodNames[unique]
+		_, modelExists := self.types.modelNames[unique+"Params"]
+		if !methodExists && !modelExists {
+			break
+		}
+		unique = name + strconv.Itoa(suffix)
+	}
+	self.methodNames[unique] = struct{}{}
+	self.types.reserve(unique + "Params")
+
+	return unique
+}
+
+// method generates the Client method of a route.
+// Unsupported routes generate a comment explaining why they were skipped.
+func (self *generator) method(routeDefinition route.Route, description endpoint.Description) string {
+	name := self.methodName(description.Name)
+
+	requestMimeType := ""
+	for _, mimeType := range description.RequestMimeTypes {
+		if mimeType != mimeTypeMultipartFormData {
+			requestMimeType = mimeType
+
+			break
+		}
+	}
+	if description.RequestBodyType != nil && requestMimeType == "" {
+		return fmt.Sprintf("// %s (%s %s) is not generated since multipart/form-data requests are not supported.\n\n", name, routeDefinition.HttpMethod, routeDefinition.Path)
+	}
+
+	errorType := "struct{}"
+	if description.ErrorBodyType != nil {
+		errorType = self.types.expr(description.ErrorBodyType)
+	}
+	self.errorTypes[errorType] = struct{}{}
+
+	method := strings.Builder{}
+
+	arguments := []string{"ctx context.Context"}
+	if len(description.Parameters) != 0 {
+		method.WriteString(self.paramsStruct(name, description.Parameters))
+		arguments = append(arguments, "params "+name+"Params")
+	}
+
+	var requestBodyType string
+	if description.RequestBodyType != nil {
+		requestBodyType = self.bodyExpr(description.RequestBodyType)
+		if description.IsRequestStream {
+			requestBodyType = self.types.importPackage("io") + ".Reader"
+		}
+		arguments = append(arguments, "requestBody "+requestBodyType)
+	}
+
+	var responseBodyType string
+	results := "error"
+	if description.ResponseBodyType != nil {
+		responseBodyType = self.bodyExpr(description.ResponseBodyType)
+		if description.IsResponseStream {
+			responseBodyType = self.types.importPackage("io") + ".ReadCloser"
+		}
+		results = "(" + responseBodyType + ", error)"
+	}
+
+	fmt.Fprintf(&method, "// %s calls %s %s.\n", name, routeDefinition.HttpMethod, routeDefinition.Path)
+	fmt.Fprintf(&method, "func (self *Client) %s(%s) %s {\n", name, strings.Join(arguments, ", "), results)
+	fmt.Fprintf(&method, "\trequest := client.NewRequest(%s, %s)\n", methodExpr(self.types, routeDefinition.HttpMethod), strconv.Quote(routeDefinition.Path))
+
+	for _, parameter := range description.Parameters {
+		setter := map[string]string{
+			endpoint.ParameterInPath:   "SetPathParam",
+			endpoint.ParameterInQuery:  "AddQueryParam",
+			endpoint.ParameterInHeader: "AddHeader",
+			endpoint.ParameterInCookie: "AddCookie",
+		}[parameter.In]
+		fmt.Fprintf(&method, "\trequest.%s(%s, params.%s)\n", setter, strconv.Quote(parameter.Name), parameter.Field)
+	}
+
+	if description.RequestBodyType != nil {
+		if description.IsRequestStream {
+			fmt.Fprintf(&method, "\trequest.SetBodyStream(%s, requestBody)\n", strconv.Quote(requestMimeType))
+		} else {
+			fmt.Fprintf(&method, "\trequest.SetBody(%s, requestBody)\n", strconv.Quote(requestMimeType))
+		}
+	}
+	if len(description.ResponseMimeTypes) != 0 {
+		fmt.Fprintf(&method, "\trequest.SetAccept(%s)\n", strconv.Quote(description.ResponseMimeTypes[0]))
+	}
+	method.WriteString("\n")
+
+	switch {
+	case description.ResponseBodyType == nil:
+		fmt.Fprintf(&method, "\treturn client.Do[%s](ctx, self.client, request, nil)\n", errorType)
+	case description.IsResponseStream:
+		fmt.Fprintf(&method, "\treturn client.DoStream[%s](ctx, self.client, request)\n", errorType)
+	case description.ResponseBodyType.Kind() == reflect.Struct:
+		fmt.Fprintf(&method, "\tresponseBody := &%s{}\n", strings.TrimPrefix(responseBodyType, "*"))
+		fmt.Fprintf(&method, "\tif err := client.Do[%s](ctx, self.client, request, responseBody); err != nil {\n", errorType)
+		method.WriteString("\t\treturn nil, err\n\t}\n\n\treturn responseBody, nil\n")
+	default:
+		fmt.Fprintf(&method, "\tvar responseBody %s\n", responseBodyType)
+		fmt.Fprintf(&method, "\tif err := client.Do[%s](ctx, self.client, request, &responseBody); err != nil {\n", errorType)
+		fmt.Fprintf(&method, "\t\tvar zero %s\n\n\t\treturn zero, err\n\t}\n\n\treturn responseBody, nil\n", responseBodyType)
+	}
+
+	method.WriteString("}\n\n")
+
+	return method.String()
+}
+
+// paramsStruct holds the parameters of a method.
+// Optional parameters are pointers so that they are only sent when set.
+func (self *generator) paramsStruct(name string, parameters []endpoint.ParameterDescription) string {
+	params := strings.Builder{}
+	fmt.Fprintf(&params, "// %sParams are the parameters of %s.\n", name, name)
+	fmt.Fprintf(&params, "type %sParams struct {\n", name)
+
+	for _, parameter := range parameters {
+		fieldType := self.types.expr(parameter.Type)
+		switch parameter.Type.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		default:
+			if !parameter.IsRequired {
+				fieldType = "*" + fieldType
+			}
+		}
+
+		comment := fmt.Sprintf("%s is the %s parameter '%s'.", parameter.Field, parameter.In, parameter.Name)
+		if parameter.IsRequired && parameter.In != endpoint.ParameterInPath {
+			comment += " Required."
+		}
+		if parameter.Default != nil {
+			comment += fmt.Sprintf(" Defaults to '%s'.", *parameter.Default)
+		}
+		if len(parameter.Enum) != 0 {
+			comment += fmt.Sprintf(" One of [%s].", strings.Join(parameter.Enum, ", "))
+		}
+
+		fmt.Fprintf(&params, "\t// %s\n\t%s %s\n", comment, parameter.Field, fieldType)
+	}
+
+	params.WriteString("}\n\n")
+
+	return params.String()
+}
+
+// bodyExpr is the argument or result type of a body. Structs are passed by pointer.
+func (self *generator) bodyExpr(bodyType reflect.Type) string {
+	if bodyType.Kind() == reflect.Struct {
+		return "*" + self.types.expr(bodyType)
+	}
+
+	return self.types.expr(bodyType)
+}
+
+func methodExpr(types *types, httpMethod string) string {
+	constant := map[string]string{
+		http.MethodGet:     "MethodGet",
+		http.MethodHead:    "MethodHead",
+		http.MethodPost:    "MethodPost",
+		http.MethodPut:     "MethodPut",
+		http.MethodPatch:   "MethodPatch",
+		http.MethodDelete:  "MethodDelete",
+		http.MethodConnect: "MethodConnect",
+		http.MethodOptions: "MethodOptions",
+		http.MethodTrace:   "MethodTrace",
+	}[httpMethod]
+	if constant == "" {
+		return strconv.Quote(httpMethod)
+	}
+
+	return types.importPackage("net/http") + "." + constant
+}
@@ -0,0 +1,158 @@
+package clientgen_test
+
+import (
+	"go/parser"
+	"go/token"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/client"
+	"github.com/wspowell/spiderweb/clientgen"
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/server/restful"
+	"github.com/wspowell/spiderweb/server/route"
+)
+
+type note struct {
+	Id     string `json:"id"`
+	Status string `json:"status"`
+	secret string
+}
+
+type listNotes struct {
+	Limit        int      `spiderweb:"query=limit,default=10"`
+	Order        string   `spiderweb:"query=order,enum=asc|desc"`
+	Tags         []string `spiderweb:"query=tag"`
+	TenantId     string   `spiderweb:"header=X-Tenant-Id,required"`
+	ResponseBody *[]note  `spiderweb:"response,mime=application/json"`
+}
+
+func (self *listNotes) Handle(ctx context.Context) (int, error) {
+	return httpstatus.OK, nil
+}
+
+type putNote struct {
+	Id           string `spiderweb:"path=id"`
+	RequestBody  *note  `spiderweb:"request,mime=application/json"`
+	ResponseBody *note  `spiderweb:"response,mime=application/json"`
+}
+
+func (self *putNote) Handle(ctx context.Context) (int, error) {
+	return httpstatus.OK, nil
+}
+
+type deleteNote struct {
+	Id string `spiderweb:"path=id"`
+}
+
+func (self *deleteNote) Handle(ctx context.Context) (int, error) {
+	return httpstatus.NoContent, nil
+}
+
+func Test_Generate(t *testing.T) {
+	t.Parallel()
+
+	ctx := log.WithContext(context.Background(), log.NewConfig().WithLevel(log.LevelError))
+
+	source, err := clientgen.Generate(ctx, clientgen.Config{
+		PackageName: "notes",
+		EndpointConfig: &endpoint.Config{
+			LogConfig: log.NewConfig().WithLevel(log.LevelError),
+		},
+	}, []route.Route{
+		route.Get("/notes", &listNotes{}),
+		route.Put("/notes/{id}", &putNote{}),
+		route.Delete("/notes/{id}", &deleteNote{}),
+	})
+	assert.Nil(t, err)
+
+	_, err = parser.ParseFile(token.NewFileSet(), "client_gen.go", source, parser.AllErrors)
+	assert.Nil(t, err)
+
+	generated := string(source)
+	assert.Contains(t, generated, "package notes\n")
+	assert.Contains(t, generated, "type Error = client.Error[DefaultErrorResponse]\n")
+
+	assert.Contains(t, generated, "func (self *Client) ListNotes(ctx context.Context, params ListNotesParams) ([]Note, error) {\n")
+	assert.Contains(t, generated, "\tLimit *int\n")
+	assert.Contains(t, generated, "\tTags []string\n")
+	assert.Contains(t, generated, "\t// TenantId is the header parameter 'X-Tenant-Id'. Required.\n\tTenantId string\n")
+	assert.Contains(t, generated, "\trequest.AddQueryParam(\"limit\", params.Limit)\n")
+	assert.Contains(t, generated, "\trequest.AddHeader(\"X-Tenant-Id\", params.TenantId)\n")
+
+	assert.Contains(t, generated, "func (self *Client) PutNote(ctx context.Context, params PutNoteParams, requestBody *Note) (*Note, error) {\n")
+	assert.Contains(t, generated, "\trequest.SetPathParam(\"id\", params.Id)\n")
+	assert.Contains(t, generated, "\trequest.SetBody(\"application/json\", requestBody)\n")
+
+	assert.Contains(t, generated, "func (self *Client) DeleteNote(ctx context.Context, params DeleteNoteParams) error {\n")
+	assert.Contains(t, generated, "\treturn client.Do[DefaultErrorResponse](ctx, self.client, request, nil)\n")
+
+	// Models only copy exported fields.
+	assert.Contains(t, generated, "type Note struct {\n\tId     string `json:\"id\"`\n\tStatus string `json:\"status\"`\n}\n")
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+type labels struct {
+	Labels []string `json:"labels"`
+}
+
+type getLabels struct {
+	Labels       []string `spiderweb:"header=X-Labels"`
+	ResponseBody *labels  `spiderweb:"response,mime=application/json"`
+}
+
+func (self *getLabels) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &labels{Labels: self.Labels}
+
+	return httpstatus.OK, nil
+}
+
+func Test_Generate_Slice_Header(t *testing.T) {
+	t.Parallel()
+
+	ctx := log.WithContext(context.Background(), log.NewConfig().WithLevel(log.LevelError))
+	endpointConfig := &endpoint.Config{
+		LogConfig: log.NewConfig().WithLevel(log.LevelError),
+	}
+	labelsRoute := route.Get("/labels", &getLabels{})
+
+	source, err := clientgen.Generate(ctx, clientgen.Config{
+		PackageName:    "labels",
+		EndpointConfig: endpointConfig,
+	}, []route.Route{labelsRoute})
+	assert.Nil(t, err)
+
+	generated := string(source)
+	assert.Contains(t, generated, "\tLabels []string\n")
+	assert.Contains(t, generated, "\trequest.AddHeader(\"X-Labels\", params.Labels)\n")
+
+	// Send the request the generated client builds to a server with the same route.
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.Nil(t, err)
+	server := restful.NewServer(&restful.ServerConfig{
+		LogConfig: log.NewConfig().WithLevel(log.LevelError),
+		Listener:  listener,
+	})
+	server.Handle(endpointConfig, labelsRoute)
+	assert.Nil(t, server.Start(context.Background()))
+	defer server.Shutdown(context.Background())
+
+	apiClient := client.New("http://" + listener.Addr().String())
+
+	request := client.NewRequest(http.MethodGet, "/labels").
+		AddHeader("X-Labels", []string{"red", "green", "blue"}).
+		SetAccept("application/json")
+
+	responseBody := &labels{}
+	assert.Nil(t, client.Do[errorResponse](context.Background(), apiClient, request, responseBody))
+	assert.Equal(t, []string{"red", "green", "blue"}, responseBody.Labels)
+}
@@ -0,0 +1,244 @@
+package clientgen
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// nolint:gochecknoglobals // reason: cached reflection types
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// types converts Go types used by handlers into type expressions of the generated package.
+// Standard library types and exported types with custom encodings are imported.
+// Every other named type is copied into the generated package as a model so that
+// the client does not depend on the packages of the server.
+type types struct {
+	// imports of package path to package name.
+	imports     map[string]string
+	importNames map[string]string
+
+	models     map[reflect.Type]string
+	modelNames map[string]reflect.Type
+	// modelOrder in which models were found, since models are generated after the methods that use them.
+	modelOrder []reflect.Type
+}
+
+func newTypes() *types {
+	return &types{
+		imports:     map[string]string{},
+		importNames: map[string]string{},
+		models:      map[reflect.Type]string{},
+		modelNames:  map[string]reflect.Type{},
+	}
+}
+
+// reserve a name so that models do not use it.
+func (self *types) reserve(name string) {
+	self.modelNames[name] = nil
+}
+
+// importPackage and return its package name.
+func (self *types) importPackage(pkgPath string) string {
+	if name, exists := self.imports[pkgPath]; exists {
+		return name
+	}
+
+	name := path.Base(pkgPath)
+	if _, exists := self.importNames[name]; exists {
+		name = identifier(strings.ReplaceAll(pkgPath, "/", "_"))
+	}
+	self.imports[pkgPath] = name
+	self.importNames[name] = pkgPath
+
+	return name
+}
+
+// expr is the type expression of the Go type in the generated package.
+func (self *types) expr(goType reflect.Type) string {
+	if goType.Name() != "" {
+		if goType.PkgPath() == "" {
+			// Predeclared types.
+			if goType.Kind() == reflect.Interface {
+				return "any"
+			}
+
+			return goType.Name()
+		}
+
+		if isImported(goType) {
+			return self.importPackage(goType.PkgPath()) + "." + goType.Name()
+		}
+
+		return self.model(goType)
+	}
+
+	return self.underlyingExpr(goType)
+}
+
+// model copies the named type into the generated package.
+func (self *types) model(goType reflect.Type) string {
+	if name, exists := self.models[goType]; exists {
+		return name
+	}
+
+	name := exportedIdentifier(goType.Name())
+	if _, exists := self.modelNames[name]; exists {
+		// Same name from a different package.
+		name = exportedIdentifier(path.Base(goType.PkgPath())) + name
+	}
+	for suffix := 2; ; suffix++ {
+		if _, exists := self.modelNames[name]; !exists {
+			break
+		}
+		name = exportedIdentifier(goType.Name()) + strconv.Itoa(suffix)
+	}
+
+	self.models[goType] = name
+	self.modelNames[name] = goType
+	self.modelOrder = append(self.modelOrder, goType)
+
+	return name
+}
+
+// modelDefinitions of every model, including models found while generating other models.
+func (self *types) modelDefinitions() string {
+	definitions := strings.Builder{}
+	for index := 0; index < len(self.modelOrder); index++ {
+		goType := self.modelOrder[index]
+		name := self.models[goType]
+
+		definitionType := self.underlyingExpr(goType)
+		fmt.Fprintf(&definitions, "// %s is a copy of %s.\ntype %s %s\n\n", name, goType.String(), name, definitionType)
+	}
+
+	return definitions.String()
+}
+
+// underlyingExpr is the type expression of the underlying type of a named type.
+func (self *types) underlyingExpr(goType reflect.Type) string {
+	switch goType.Kind() {
+	case reflect.Ptr:
+		return "*" + self.expr(goType.Elem())
+	case reflect.Slice:
+		return "[]" + self.expr(goType.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(goType.Len()) + "]" + self.expr(goType.Elem())
+	case reflect.Map:
+		return "map[" + self.expr(goType.Key()) + "]" + self.expr(goType.Elem())
+	case reflect.Struct:
+		return self.structExpr(goType)
+	case reflect.Interface:
+		return "any"
+	}
+
+	return goType.Kind().String()
+}
+
+func (self *types) structExpr(goType reflect.Type) string {
+	fields := strings.Builder{}
+	fields.WriteString("struct {\n")
+
+	for fieldNum := 0; fieldNum < goType.NumField(); fieldNum++ {
+		structField := goType.Field(fieldNum)
+
+		if structField.Anonymous {
+			embeddedType := structField.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			// Embedded structs are copied even if unexported since encoding/json promotes their fields.
+			if embeddedType.Kind() != reflect.Struct && !structField.IsExported() {
+				continue
+			}
+			fields.WriteString("\t" + self.expr(structField.Type))
+		} else {
+			if !structField.IsExported() {
+				continue
+			}
+			fields.WriteString("\t" + structField.Name + " " + self.expr(structField.Type))
+		}
+
+		if structField.Tag != "" {
+			fields.WriteString(" " + quoteTag(string(structField.Tag)))
+		}
+		fields.WriteString("\n")
+	}
+
+	fields.WriteString("}")
+
+	return fields.String()
+}
+
+// isImported is true for types referenced from their own package rather than copied.
+func isImported(goType reflect.Type) bool {
+	if goType.PkgPath() == "main" || !isExported(goType.Name()) || strings.ContainsAny(goType.Name(), "[]") {
+		return false
+	}
+
+	if !isThirdParty(goType.PkgPath()) {
+		return true
+	}
+
+	// Types with custom encodings cannot be copied.
+	return hasCustomEncoding(goType)
+}
+
+// isThirdParty is false for standard library packages, whose paths never contain a dot in the first element.
+func isThirdParty(pkgPath string) bool {
+	return strings.Contains(strings.Split(pkgPath, "/")[0], ".")
+}
+
+func hasCustomEncoding(goType reflect.Type) bool {
+	pointerType := reflect.PtrTo(goType)
+
+	return goType.Implements(jsonMarshalerType) || pointerType.Implements(jsonMarshalerType) ||
+		goType.Implements(textMarshalerType) || pointerType.Implements(textMarshalerType)
+}
+
+func quoteTag(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+
+	return "`" + tag + "`"
+}
+
+func isExported(name string) bool {
+	for _, char := range name {
+		return unicode.IsUpper(char)
+	}
+
+	return false
+}
+
+// identifier removes characters that cannot be used in an identifier.
+func identifier(name string) string {
+	return strings.Map(func(char rune) rune {
+		if unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_' {
+			return char
+		}
+
+		return -1
+	}, name)
+}
+
+func exportedIdentifier(name string) string {
+	name = identifier(name)
+	if name == "" {
+		return name
+	}
+
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
@@ -0,0 +1,220 @@
+package endpoint
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/httpheader"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+const (
+	structTagPrincipal = "principal"
+
+	tagValueOptional = "optional"
+)
+
+// Authenticator verifies the credentials of one authentication scheme.
+type Authenticator interface {
+	// Scheme of the credentials. Ex: Bearer
+	// Sent in the WWW-Authenticate header when authentication fails.
+	Scheme() string
+	// Authenticate the request and return its principal.
+	// Returns an error wrapping ErrNoCredentials if the request has no credentials for the scheme, in which case the next Authenticator is tried.
+	// Any other error fails authentication.
+	Authenticate(ctx context.Context, requester Requester) (any, error)
+}
+
+// Policy authorizes the principal of an authenticated request.
+type Policy interface {
+	// Authorize returns an error if the principal is not allowed to call the endpoint.
+	// The principal is nil if authentication is optional and the request had no credentials.
+	Authorize(ctx context.Context, principal any) error
+}
+
+// PolicyFunc adapts a function to a Policy.
+type PolicyFunc func(ctx context.Context, principal any) error
+
+func (self PolicyFunc) Authorize(ctx context.Context, principal any) error {
+	return self(ctx, principal)
+}
+
+// RolePrincipal is implemented by principals that have roles.
+type RolePrincipal interface {
+	HasRole(role string) bool
+}
+
+// ScopePrincipal is implemented by principals that have scopes.
+type ScopePrincipal interface {
+	HasScope(scope string) bool
+}
+
+// Authenticated requires that the request has a principal.
+func Authenticated() Policy {
+	return PolicyFunc(func(ctx context.Context, principal any) error {
+		if principal == nil {
+			return errors.New("authentication required")
+		}
+
+		return nil
+	})
+}
+
+// RequireRoles requires that the principal is a RolePrincipal with every role.
+func RequireRoles(roles ...string) Policy {
+	return PolicyFunc(func(ctx context.Context, principal any) error {
+		rolePrincipal, ok := principal.(RolePrincipal)
+		if !ok {
+			return errors.New("principal has no roles")
+		}
+
+		for _, role := range roles {
+			if !rolePrincipal.HasRole(role) {
+				return errors.New("missing role: %s", role)
+			}
+		}
+
+		return nil
+	})
+}
+
+// RequireScopes requires that the principal is a ScopePrincipal with every scope.
+func RequireScopes(scopes ...string) Policy {
+	return PolicyFunc(func(ctx context.Context, principal any) error {
+		scopePrincipal, ok := principal.(ScopePrincipal)
+		if !ok {
+			return errors.New("principal has no scopes")
+		}
+
+		for _, scope := range scopes {
+			if !scopePrincipal.HasScope(scope) {
+				return errors.New("missing scope: %s", scope)
+			}
+		}
+
+		return nil
+	})
+}
+
+// AnyPolicy requires that at least one of the policies authorizes the principal.
+func AnyPolicy(policies ...Policy) Policy {
+	return PolicyFunc(func(ctx context.Context, principal any) error {
+		failures := make([]string, 0, len(policies))
+		for _, policy := range policies {
+			err := policy.Authorize(ctx, principal)
+			if err == nil {
+				return nil
+			}
+			failures = append(failures, err.Error())
+		}
+
+		return errors.New("%s", strings.Join(failures, "; "))
+	})
+}
+
+// AllPolicies requires that every policy authorizes the principal.
+func AllPolicies(policies ...Policy) Policy {
+	return PolicyFunc(func(ctx context.Context, principal any) error {
+		for _, policy := range policies {
+			if err := policy.Authorize(ctx, principal); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+type principalKey struct{}
+
+// RequestPrincipal returns the principal of the request being executed.
+// Returns false if the request was not authenticated.
+func RequestPrincipal(ctx context.Context) (any, bool) {
+	principal := ctx.Value(principalKey{})
+
+	return principal, principal != nil
+}
+
+// requiresAuthentication is true when the handler binds a principal or the route has policies.
+func (self *Endpoint) requiresAuthentication() bool {
+	return self.handlerData.hasPrincipal || len(self.Config.Policies) != 0
+}
+
+// authenticate the request using each Authenticator in order.
+// The first Authenticator with credentials in the request determines the principal.
+func (self *Endpoint) authenticate(ctx context.Context, requester Requester) (any, int, error) {
+	for _, authenticator := range self.Config.Authenticators {
+		principal, err := authenticator.Authenticate(ctx, requester)
+		if err == nil {
+			log.Tag(ctx, "auth_scheme", authenticator.Scheme())
+
+			return principal, httpstatus.OK, nil
+		}
+
+		if !errors.Is(err, ErrNoCredentials) {
+			log.Debug(ctx, "authentication failed (%s): %v", authenticator.Scheme(), err)
+			self.setWwwAuthenticate(requester)
+
+			return nil, httpstatus.Unauthorized, errors.Wrap(err, ErrUnauthorized)
+		}
+	}
+
+	if self.handlerData.isPrincipalOptional {
+		return nil, httpstatus.OK, nil
+	}
+
+	log.Debug(ctx, "no credentials")
+	self.setWwwAuthenticate(requester)
+
+	return nil, httpstatus.Unauthorized, ErrUnauthorized
+}
+
+// authorize the principal using each route Policy.
+func (self *Endpoint) authorize(ctx context.Context, requester Requester, principal any) (int, error) {
+	for _, policy := range self.Config.Policies {
+		if err := policy.Authorize(ctx, principal); err != nil {
+			log.Debug(ctx, "authorization failed: %v", err)
+
+			if principal == nil {
+				// Anonymous requests have not proven who they are.
+				self.setWwwAuthenticate(requester)
+
+				return httpstatus.Unauthorized, errors.Wrap(err, ErrUnauthorized)
+			}
+
+			return httpstatus.Forbidden, errors.Wrap(err, ErrForbidden)
+		}
+	}
+
+	return httpstatus.OK, nil
+}
+
+func (self *Endpoint) setWwwAuthenticate(requester Requester) {
+	schemes := make([]string, 0, len(self.Config.Authenticators))
+	for _, authenticator := range self.Config.Authenticators {
+		schemes = append(schemes, authenticator.Scheme())
+	}
+	if len(schemes) != 0 {
+		requester.SetResponseHeader(httpheader.WwwAuthenticate, strings.Join(schemes, ", "))
+	}
+}
+
+// setPrincipal on the "principal" field.
+func (self handlerTypeData) setPrincipal(handlerValue reflect.Value, principal any) error {
+	if !self.hasPrincipal || principal == nil {
+		return nil
+	}
+
+	principalValue := reflect.ValueOf(principal)
+	if !principalValue.Type().AssignableTo(self.principalType) {
+		return errors.Wrap(errors.New("principal type %s cannot be assigned to %s", principalValue.Type(), self.principalType), ErrInternalServerError)
+	}
+
+	handlerValue.Elem().Field(self.principalFieldNum).Set(principalValue)
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package endpoint_test
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+type testPrincipal struct {
+	Subject string
+	Roles   []string
+}
+
+func (self *testPrincipal) HasRole(role string) bool {
+	for _, principalRole := range self.Roles {
+		if principalRole == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+type bearerAuthenticator struct{}
+
+func (self bearerAuthenticator) Scheme() string {
+	return "Bearer"
+}
+
+func (self bearerAuthenticator) Authenticate(ctx context.Context, requester endpoint.Requester) (any, error) {
+	authorization := string(requester.PeekHeader(httpheader.Authorization))
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return nil, endpoint.ErrNoCredentials
+	}
+
+	switch strings.TrimPrefix(authorization, "Bearer ") {
+	case "user-token":
+		return &testPrincipal{Subject: "user", Roles: []string{"reader"}}, nil
+	case "admin-token":
+		return &testPrincipal{Subject: "admin", Roles: []string{"reader", "admin"}}, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
+type apiKeyAuthenticator struct{}
+
+func (self apiKeyAuthenticator) Scheme() string {
+	return "ApiKey"
+}
+
+func (self apiKeyAuthenticator) Authenticate(ctx context.Context, requester endpoint.Requester) (any, error) {
+	if string(requester.PeekHeader("X-Api-Key")) == "service-key" {
+		return &testPrincipal{Subject: "service", Roles: []string{"admin"}}, nil
+	}
+
+	return nil, endpoint.ErrNoCredentials
+}
+
+type principalResponse struct {
+	Subject string `json:"subject"`
+}
+
+type principalEndpoint struct {
+	Principal    *testPrincipal     `spiderweb:"principal"`
+	ResponseBody *principalResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *principalEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &principalResponse{Subject: self.Principal.Subject}
+
+	return httpstatus.OK, nil
+}
+
+type optionalPrincipalEndpoint struct {
+	Principal    *testPrincipal     `spiderweb:"principal,optional"`
+	ResponseBody *principalResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *optionalPrincipalEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &principalResponse{Subject: "anonymous"}
+	if principal, ok := endpoint.RequestPrincipal(ctx); ok {
+		self.ResponseBody.Subject = principal.(*testPrincipal).Subject
+	}
+
+	return httpstatus.OK, nil
+}
+
+func Test_Endpoint_Authentication(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                    string
+		handler                 endpoint.Handler
+		policies                []endpoint.Policy
+		headers                 map[string]string
+		expectedHttpStatus      int
+		expectedBody            string
+		expectedWwwAuthenticate string
+	}{
+		{
+			name:               "bearer",
+			handler:            &principalEndpoint{},
+			headers:            map[string]string{httpheader.Authorization: "Bearer user-token"},
+			expectedHttpStatus: httpstatus.OK,
+			expectedBody:       `{"subject":"user"}`,
+		},
+		{
+			name:               "second scheme",
+			handler:            &principalEndpoint{},
+			headers:            map[string]string{"X-Api-Key": "service-key"},
+			expectedHttpStatus: httpstatus.OK,
+			expectedBody:       `{"subject":"service"}`,
+		},
+		{
+			name:                    "no credentials",
+			handler:                 &principalEndpoint{},
+			expectedHttpStatus:      httpstatus.Unauthorized,
+			expectedBody:            `{"message":"unauthorized"}`,
+			expectedWwwAuthenticate: "Bearer, ApiKey",
+		},
+		{
+			name:                    "invalid credentials",
+			handler:                 &principalEndpoint{},
+			headers:                 map[string]string{httpheader.Authorization: "Bearer bad-token", "X-Api-Key": "service-key"},
+			expectedHttpStatus:      httpstatus.Unauthorized,
+			expectedBody:            `{"message":"unauthorized"}`,
+			expectedWwwAuthenticate: "Bearer, ApiKey",
+		},
+		{
+			name:               "policy allowed",
+			handler:            &principalEndpoint{},
+			policies:           []endpoint.Policy{endpoint.RequireRoles("admin")},
+			headers:            map[string]string{httpheader.Authorization: "Bearer admin-token"},
+			expectedHttpStatus: httpstatus.OK,
+			expectedBody:       `{"subject":"admin"}`,
+		},
+		{
+			name:               "policy denied",
+			handler:            &principalEndpoint{},
+			policies:           []endpoint.Policy{endpoint.AnyPolicy(endpoint.RequireRoles("admin"), endpoint.RequireRoles("owner"))},
+			headers:            map[string]string{httpheader.Authorization: "Bearer user-token"},
+			expectedHttpStatus: httpstatus.Forbidden,
+			expectedBody:       `{"message":"forbidden"}`,
+		},
+		{
+			name:               "optional anonymous",
+			handler:            &optionalPrincipalEndpoint{},
+			expectedHttpStatus: httpstatus.OK,
+			expectedBody:       `{"subject":"anonymous"}`,
+		},
+		{
+			name:               "optional authenticated",
+			handler:            &optionalPrincipalEndpoint{},
+			headers:            map[string]string{httpheader.Authorization: "Bearer user-token"},
+			expectedHttpStatus: httpstatus.OK,
+			expectedBody:       `{"subject":"user"}`,
+		},
+		{
+			name:                    "optional with policy",
+			handler:                 &optionalPrincipalEndpoint{},
+			policies:                []endpoint.Policy{endpoint.Authenticated()},
+			expectedHttpStatus:      httpstatus.Unauthorized,
+			expectedBody:            `{"message":"unauthorized"}`,
+			expectedWwwAuthenticate: "Bearer, ApiKey",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig:      log.NewConfig().WithLevel(log.LevelError),
+				Authenticators: []endpoint.Authenticator{bearerAuthenticator{}, apiKeyAuthenticator{}},
+				Policies:       testCase.policies,
+			}, testCase.handler)
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/principal", nil)
+			assert.Nil(t, err)
+			for header, value := range testCase.headers {
+				req.Header.Add(header, value)
+			}
+
+			requester, err := endpoint.NewHttpRequester("/principal", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+			assert.Equal(t, testCase.expectedWwwAuthenticate, req.Response.Header.Get(httpheader.WwwAuthenticate))
+		})
+	}
+}
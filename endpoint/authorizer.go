@@ -3,6 +3,7 @@ package endpoint
 import "github.com/wspowell/context"
 
 // Authorizer defines request authentication.
+// The Authorizer is the "auth" field of the handler, allocated for each request.
 type Authorizer interface {
-	Authorization(ctx context.Context, PeekHeader func(key string) []byte) (int, error)
+	Authorization(ctx context.Context, requester Requester) (int, error)
 }
@@ -0,0 +1,144 @@
+package endpoint
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/wspowell/context"
+)
+
+// Description of an endpoint, as defined by the handler struct tags and the endpoint Config.
+// Used to document the API, ex: OpenAPI generation.
+type Description struct {
+	// Name of the handler struct.
+	Name       string
+	Parameters []ParameterDescription
+
+	// RequestBodyType is nil if the endpoint has no request body.
+	RequestBodyType   reflect.Type
+	RequestMimeTypes  []string
+	IsRequestStream   bool
+	Files             []string
+	RequiredFiles     []string
+	ResponseBodyType  reflect.Type
+	ResponseMimeTypes []string
+	IsResponseStream  bool
+	// ResponseHeaders set by "response-header=<name>" fields.
+	ResponseHeaders []string
+	SetsCookies     bool
+
+	// ErrorBodyType is the type of response body created by the ErrorHandler.
+	// Nil if the ErrorHandler has no response body.
+	ErrorBodyType reflect.Type
+	// ErrorMimeTypes are the Content-Types of error responses.
+	ErrorMimeTypes []string
+
+	HasAuth bool
+	// HasPolicies is true if the principal must be authorized by a Policy.
+	HasPolicies   bool
+	ETagEnabled   bool
+	MaxAgeSeconds int
+}
+
+// ParameterDescription of a path, query, header, or cookie parameter.
+type ParameterDescription struct {
+	// In is one of ParameterInPath, ParameterInQuery, ParameterInHeader, or ParameterInCookie.
+	In   string
+	Name string
+	// Field is the name of the handler field.
+	Field string
+	// Type of the handler field.
+	Type       reflect.Type
+	IsRequired bool
+	// Default is nil if there is no default.
+	Default *string
+	Enum    []string
+	// Pattern is the anchored regular expression the value must match.
+	Pattern string
+	// Min and Max are the limits of numbers and the lengths of strings.
+	// Durations are limited in nanoseconds.
+	Min        *float64
+	Max        *float64
+	IsDuration bool
+}
+
+// Describe the endpoint.
+// The ErrorHandler is called once to find the type of error response body.
+func (self *Endpoint) Describe(ctx context.Context) Description {
+	parameters := make([]ParameterDescription, len(self.handlerData.parameters))
+	for index, parameter := range self.handlerData.parameters {
+		structField := self.handlerData.structValue.Type().Field(parameter.fieldNum)
+		fieldType := structField.Type
+		parameters[index] = ParameterDescription{
+			In:         parameter.in,
+			Name:       parameter.name,
+			Field:      structField.Name,
+			Type:       fieldType,
+			IsRequired: parameter.isRequired,
+			Enum:       parameter.enum,
+			Min:        parameter.min,
+			Max:        parameter.max,
+			IsDuration: baseType(fieldType) == durationType,
+		}
+		if parameter.hasDefault {
+			defaultValue := parameter.defaultValue
+			parameters[index].Default = &defaultValue
+		}
+		if parameter.pattern != nil {
+			parameters[index].Pattern = parameter.pattern.String()
+		}
+	}
+
+	description := Description{
+		Name:              self.handlerData.structName,
+		Parameters:        parameters,
+		RequestBodyType:   self.RequestBodyType(),
+		RequestMimeTypes:  self.handlerData.requestMimeTypes,
+		IsRequestStream:   self.handlerData.isRequestStream,
+		ResponseBodyType:  self.ResponseBodyType(),
+		ResponseMimeTypes: self.handlerData.responseMimeTypes,
+		IsResponseStream:  self.handlerData.isResponseStream,
+		SetsCookies:       len(self.handlerData.responseCookies) != 0,
+		HasAuth:           self.handlerData.hasAuth || self.requiresAuthentication(),
+		HasPolicies:       len(self.Config.Policies) != 0,
+		ETagEnabled:       self.handlerData.eTagEnabled,
+		MaxAgeSeconds:     self.handlerData.maxAgeSeconds,
+	}
+
+	for name := range self.handlerData.files {
+		description.Files = append(description.Files, name)
+		if _, isRequired := self.handlerData.requiredFiles[name]; isRequired {
+			description.RequiredFiles = append(description.RequiredFiles, name)
+		}
+	}
+	sort.Strings(description.Files)
+	sort.Strings(description.RequiredFiles)
+
+	for name := range self.handlerData.responseHeaders {
+		description.ResponseHeaders = append(description.ResponseHeaders, name)
+	}
+	sort.Strings(description.ResponseHeaders)
+
+	if _, errorBody := self.Config.ErrorHandler.HandleError(ctx, http.StatusInternalServerError, ErrInternalServerError); errorBody != nil {
+		description.ErrorBodyType = reflect.TypeOf(errorBody)
+		for description.ErrorBodyType.Kind() == reflect.Ptr {
+			description.ErrorBodyType = description.ErrorBodyType.Elem()
+		}
+
+		errorMimeTypes := description.ResponseMimeTypes
+		if len(errorMimeTypes) == 0 || description.IsResponseStream {
+			errorMimeTypes = []string{mimeTypeJson}
+		}
+		for _, mimeType := range errorMimeTypes {
+			description.ErrorMimeTypes = append(description.ErrorMimeTypes, mimeType)
+			if contentTyper, ok := self.Config.ErrorHandler.(ErrorContentTyper); ok {
+				if contentType := contentTyper.ErrorContentType([]byte(mimeTypeAny), mimeType); contentType != mimeType {
+					description.ErrorMimeTypes = append(description.ErrorMimeTypes, contentType)
+				}
+			}
+		}
+	}
+
+	return description
+}
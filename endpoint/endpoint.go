@@ -3,7 +3,10 @@ package endpoint
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"strings"
 	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
@@ -11,7 +14,9 @@ import (
 	"github.com/wspowell/errors"
 	"github.com/wspowell/log"
 
+	"github.com/wspowell/spiderweb/httpheader"
 	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/i18n"
 )
 
 const (
@@ -37,14 +42,27 @@ func nullBytes() []byte {
 // Endpoint behavior is interface driven and can be completely modified by an application.
 // The values in the config must never be modified by an endpoint.
 type Config struct {
-	LogConfig         log.LoggerConfig
-	ErrorHandler      ErrorHandler
-	RequestValidator  RequestValidator
-	ResponseValidator ResponseValidator
-	MimeTypeHandlers  MimeTypeHandlers
-	Resources         map[string]any
-	Timeout           time.Duration
-	Tracer            opentracing.Tracer
+	LogConfig    log.LoggerConfig
+	ErrorHandler ErrorHandler
+	// Middleware wraps endpoint execution, in order. The first Middleware is the outermost.
+	Middleware []Middleware
+	// Authenticators of each supported authentication scheme, tried in order.
+	// Authentication is required by endpoints with a "principal" field or with Policies.
+	Authenticators []Authenticator
+	// Policies that authorize the principal. Usually set per route with route.Route.WithPolicies().
+	Policies         []Policy
+	RequestValidator RequestValidator
+	// RequestBodyValidator validates the unmarshaled request body. Defaults to TagValidator.
+	RequestBodyValidator RequestBodyValidator
+	ResponseValidator    ResponseValidator
+	MimeTypeHandlers     MimeTypeHandlers
+	// PanicReporter receives panics recovered while executing the endpoint. Optional.
+	PanicReporter PanicReporter
+	// Catalog localizes i18n.Localizable errors in the Accept-Language of the request.
+	Catalog   *i18n.Catalog
+	Resources map[string]any
+	Timeout   time.Duration
+	Tracer    opentracing.Tracer
 }
 
 // Endpoint defines the behavior of a given handler.
@@ -73,6 +91,20 @@ func NewEndpoint(ctx context.Context, config *Config, handler Handler) *Endpoint
 		configClone.ErrorHandler = config.ErrorHandler
 	}
 
+	configClone.Middleware = config.Middleware
+	configClone.Authenticators = config.Authenticators
+	configClone.Policies = config.Policies
+	configClone.RequestValidator = config.RequestValidator
+	configClone.ResponseValidator = config.ResponseValidator
+	configClone.PanicReporter = config.PanicReporter
+	configClone.Catalog = config.Catalog
+
+	if config.RequestBodyValidator == nil {
+		configClone.RequestBodyValidator = NewTagValidator()
+	} else {
+		configClone.RequestBodyValidator = config.RequestBodyValidator
+	}
+
 	if config.MimeTypeHandlers == nil {
 		configClone.MimeTypeHandlers = NewMimeTypeHandlers()
 	} else {
@@ -104,10 +136,33 @@ func NewEndpoint(ctx context.Context, config *Config, handler Handler) *Endpoint
 		configClone.Tracer = config.Tracer
 	}
 
+	handlerData := newHandlerTypeData(ctx, handler)
+
+	// Every MIME type allowed by the endpoint must be registered, otherwise the endpoint could never be called.
+	// Streams are not marshaled so they may use any MIME type.
+	if missing := configClone.MimeTypeHandlers.Missing(handlerData.requestMimeTypes); len(missing) != 0 && !handlerData.isRequestStream {
+		log.Fatal(ctx, "%s: no MimeTypeHandler registered for request MIME types: %v", handlerData.structName, missing)
+	}
+	if missing := configClone.MimeTypeHandlers.Missing(handlerData.responseMimeTypes); len(missing) != 0 && !handlerData.isResponseStream {
+		log.Fatal(ctx, "%s: no MimeTypeHandler registered for response MIME types: %v", handlerData.structName, missing)
+	}
+
+	if handlerData.hasRequestBody && !handlerData.isRequestStream && handlerData.shouldValidateRequest {
+		if typeValidator, ok := configClone.RequestBodyValidator.(RequestBodyTypeValidator); ok {
+			if err := typeValidator.ValidateRequestBodyType(handlerData.requestBodyType); err != nil {
+				log.Fatal(ctx, "%s: invalid request body: %v", handlerData.structName, err)
+			}
+		}
+	}
+
+	if (handlerData.hasPrincipal || len(configClone.Policies) != 0) && len(configClone.Authenticators) == 0 {
+		log.Fatal(ctx, "%s: authentication is required but no Authenticators are configured", handlerData.structName)
+	}
+
 	return &Endpoint{
 		Config: configClone,
 
-		handlerData: newHandlerTypeData(ctx, handler),
+		handlerData: handlerData,
 	}
 }
 
@@ -115,6 +170,30 @@ func (self *Endpoint) Name() string {
 	return self.handlerData.structName
 }
 
+// RequestBodyType is the Go type of the request body, without the pointer.
+// Returns nil if the endpoint has no request body.
+func (self *Endpoint) RequestBodyType() reflect.Type {
+	return bodyType(self.handlerData.hasRequestBody, self.handlerData.requestBodyType)
+}
+
+// ResponseBodyType is the Go type of the response body, without the pointer.
+// Returns nil if the endpoint has no response body.
+func (self *Endpoint) ResponseBodyType() reflect.Type {
+	return bodyType(self.handlerData.hasResponseBody, self.handlerData.responseBodyType)
+}
+
+func bodyType(hasBody bool, fieldType reflect.Type) reflect.Type {
+	if !hasBody || fieldType == nil {
+		return nil
+	}
+
+	if fieldType.Kind() == reflect.Ptr {
+		return fieldType.Elem()
+	}
+
+	return fieldType
+}
+
 // Execute the endpoint and run the endpoint handler.
 func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpStatus int, responseBody []byte) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "Execute()")
@@ -125,19 +204,19 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 	// Every invocation of an endpoint creates its own logger instance.
 	ctx = log.WithContext(ctx, self.Config.LogConfig)
 
-	var responseMimeType *MimeTypeHandler
-
 	// Defer recover at this point so that logging and context has been initialized.
 	defer func() {
-		if err := errors.Recover(recover()); err != nil {
+		if recovered := recover(); recovered != nil {
+			err := errors.Recover(recovered)
 			log.Error(ctx, "panic: %+v", err)
+			self.reportPanic(ctx, requester, recovered)
 			// Convert the panic error to an internal server error. Never expose panics directly.
 			err = errors.Wrap(err, ErrInternalServerError)
-			httpStatus, responseBody = self.processErrorResponse(ctx, requester, responseMimeType, http.StatusInternalServerError, err)
+			httpStatus, responseBody = self.processErrorResponse(ctx, requester, self.errorMimeType(requester), http.StatusInternalServerError, err)
 		}
 	}()
 
-	requester.SetResponseHeader("X-Request-Id", requester.RequestId())
+	ctx = self.requestContext(ctx, requester)
 
 	// Setup log.
 	{
@@ -151,27 +230,64 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 
 		// Each path parameter is added as a log tag.
 		// Note: It helps if the path parameter name is descriptive.
-		for param := range self.handlerData.pathParameters {
-			if value, ok := requester.PathParam(param); ok {
-				log.Tag(ctx, param, value)
+		for _, parameter := range self.handlerData.parameters {
+			if parameter.in != ParameterInPath {
+				continue
+			}
+			if value, ok := requester.PathParam(parameter.name); ok {
+				log.Tag(ctx, parameter.name, value)
 			}
 		}
 
 		logSpan.Finish()
 	}
 
+	return self.executeMiddleware(ctx, requester, 0)
+}
+
+// ErrorResponse returns the error response of the endpoint without executing it.
+// The error is processed by the ErrorHandler using the MIME type negotiated from the Accept header.
+// Servers use this for errors that occur outside of Execute, such as a request timing out.
+func (self *Endpoint) ErrorResponse(ctx context.Context, requester Requester, httpStatus int, err error) (int, []byte) {
+	ctx = context.Localize(ctx)
+	ctx = log.WithContext(ctx, self.Config.LogConfig)
+	ctx = self.requestContext(ctx, requester)
+
+	return self.processErrorResponse(ctx, requester, self.errorMimeType(requester), httpStatus, err)
+}
+
+// requestContext sets the request ID response header and adds the request values to the context.
+func (self *Endpoint) requestContext(ctx context.Context, requester Requester) context.Context {
+	requester.SetResponseHeader("X-Request-Id", requester.RequestId())
+
+	ctx = context.WithValue(ctx, routeInfoKey{}, RouteInfo{
+		Method:    string(requester.Method()),
+		Path:      requester.MatchedPath(),
+		RequestId: requester.RequestId(),
+		Endpoint:  self,
+	})
+	ctx = context.WithValue(ctx, acceptLanguageKey{}, string(requester.PeekHeader(httpheader.AcceptLanguage)))
+
+	return ctx
+}
+
+// execute the endpoint after all middleware.
+func (self *Endpoint) execute(ctx context.Context, requester Requester) (httpStatus int, responseBody []byte) {
 	log.Trace(ctx, "executing endpoint")
 
 	var err error
+	var responseMimeType *MimeTypeHandler
 
 	// Content-Type and Accept
 	var requestMimeType *MimeTypeHandler
+	var requestMediaType MediaType
+	var responseMediaType MediaType
 	{
 		mimeTypeSpan, ctx := opentracing.StartSpanFromContext(ctx, "setup mime types")
 
 		var ok bool
 
-		if self.handlerData.hasRequestBody {
+		if self.handlerData.hasRequestBody || self.handlerData.hasFiles() {
 			log.Trace(ctx, "processing request body mime type")
 
 			contentType := requester.ContentType()
@@ -179,17 +295,38 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 				log.Debug(ctx, "header Content-Type not found")
 				mimeTypeSpan.Finish()
 
-				return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusUnsupportedMediaType, errors.Wrap(ErrInvalidMimeType, errors.New("Content-Type MIME type not provided")))
+				return self.processErrorResponse(ctx, requester, self.errorMimeType(requester), http.StatusUnsupportedMediaType, errors.Wrap(errors.New("Content-Type MIME type not provided"), ErrInvalidMimeType))
 			}
 
-			requestMimeType, ok = self.Config.MimeTypeHandlers.Get(contentType, self.handlerData.requestMimeTypes)
+			requestMediaType, err = ParseMediaType(string(contentType))
+			if err != nil {
+				log.Debug(ctx, "invalid Content-Type: %s", contentType)
+				mimeTypeSpan.Finish()
+
+				return self.processErrorResponse(ctx, requester, self.errorMimeType(requester), http.StatusUnsupportedMediaType, errors.Wrap(err, ErrInvalidMimeType))
+			}
+
+			if self.handlerData.isRequestStream {
+				// Streams are passed to the handler as-is, so the MIME type only needs to be allowed.
+				ok = supportsMimeType(requestMediaType.MimeType(), self.handlerData.requestMimeTypes)
+			} else {
+				requestMimeType, ok = self.Config.MimeTypeHandlers.Get([]byte(requestMediaType.MimeType()), self.handlerData.requestMimeTypes)
+			}
 			if !ok {
 				log.Debug(ctx, "mime type handler not available: %s", contentType)
 				mimeTypeSpan.Finish()
 
-				return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusUnsupportedMediaType, errors.Wrap(ErrInvalidMimeType, errors.New("Content-Type MIME type not supported: %s", contentType)))
+				return self.processErrorResponse(ctx, requester, self.errorMimeType(requester), http.StatusUnsupportedMediaType, errors.Wrap(errors.New("Content-Type MIME type not supported: %s", contentType), ErrInvalidMimeType))
 			}
 
+			if self.handlerData.hasFiles() && requestMediaType.MimeType() != mimeTypeMultipartFormData {
+				log.Debug(ctx, "file upload requires multipart: %s", contentType)
+				mimeTypeSpan.Finish()
+
+				return self.processErrorResponse(ctx, requester, self.errorMimeType(requester), http.StatusUnsupportedMediaType, errors.Wrap(errors.New("Content-Type MIME type must be %s: %s", mimeTypeMultipartFormData, contentType), ErrInvalidMimeType))
+			}
+
+			log.Tag(ctx, "request_mime_type", requestMediaType.MimeType())
 			log.Trace(ctx, "found request mime type handler: %s", contentType)
 		}
 
@@ -197,28 +334,40 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 		log.Trace(ctx, "processing response body mime type")
 
 		accept := requester.Accept()
-		if len(accept) == 0 {
-			log.Debug(ctx, "header Accept not found")
-			mimeTypeSpan.Finish()
-
-			return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusUnsupportedMediaType, errors.Wrap(ErrInvalidMimeType, errors.New("Accept MIME type not provided")))
+		var responseContentType string
+		if self.handlerData.isResponseStream {
+			responseMediaType, ok = NegotiateMediaType(accept, self.handlerData.responseMimeTypes)
+			// Streams are written as-is, but errors are still marshaled if there is a registered handler.
+			responseMimeType = self.Config.MimeTypeHandlers[responseMediaType.MimeType()]
+			responseContentType = responseMediaType.MimeType()
+		} else {
+			responseMimeType, responseMediaType, ok = self.Config.MimeTypeHandlers.Negotiate(accept, self.handlerData.responseMimeTypes)
+			if ok {
+				responseContentType = responseMimeType.MimeType
+			}
 		}
-
-		responseMimeType, ok = self.Config.MimeTypeHandlers.Get(accept, self.handlerData.responseMimeTypes)
 		if !ok {
 			log.Debug(ctx, "mime type handler not available: %s", accept)
 			mimeTypeSpan.Finish()
 
-			return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusUnsupportedMediaType, errors.Wrap(ErrInvalidMimeType, errors.New("Accept MIME type not supported: %s", accept)))
+			return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusNotAcceptable, errors.Wrap(errors.New("Accept MIME type not supported: %s", accept), ErrNotAcceptable))
 		}
 		// All responses after this must be marshalable to the mime type.
-		requester.SetResponseContentType(responseMimeType.MimeType)
+		requester.SetResponseContentType(responseContentType)
+
+		log.Tag(ctx, "response_mime_type", responseContentType)
 
-		log.Trace(ctx, "found response mime type handler: %s", accept)
+		log.Trace(ctx, "negotiated response mime type: %s", responseContentType)
 
 		mimeTypeSpan.Finish()
 	}
 
+	// Expose the media types (and their parameters) to the handler.
+	if self.handlerData.hasRequestBody || self.handlerData.hasFiles() {
+		ctx = context.WithValue(ctx, requestMediaTypeKey{}, requestMediaType)
+	}
+	ctx = context.WithValue(ctx, responseMediaTypeKey{}, responseMediaType)
+
 	log.Trace(ctx, "allocating handler")
 
 	allocSpan, ctx := opentracing.StartSpanFromContext(ctx, "handler allocation")
@@ -228,24 +377,43 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 		log.Debug(ctx, "failed to set resources")
 		allocSpan.Finish()
 
-		return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusInternalServerError, errors.Wrap(err, ErrInternalServerError))
+		return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, http.StatusInternalServerError, errors.Wrap(err, ErrInternalServerError))
 	}
-	if err = self.handlerData.setPathParameters(handlerAlloc.handlerValue, requester); err != nil {
-		log.Debug(ctx, "failed to set path parameters")
+	if err = self.handlerData.setParameters(handlerAlloc.handlerValue, requester); err != nil {
+		log.Debug(ctx, "failed to set parameters")
 		allocSpan.Finish()
 
-		return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusBadRequest, errors.Wrap(err, ErrBadRequest))
-	}
-	if err = self.handlerData.setQueryParameters(handlerAlloc.handlerValue, requester); err != nil {
-		log.Debug(ctx, "failed to set query parameters")
-		allocSpan.Finish()
-
-		return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusBadRequest, errors.Wrap(err, ErrBadRequest))
+		// ParameterErrors is already an ErrBadRequest and lists every failing parameter.
+		return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, http.StatusBadRequest, err)
 	}
 
 	allocSpan.Finish()
 
 	// Authentication
+	if self.requiresAuthentication() {
+		authenticationSpan, spanCtx := opentracing.StartSpanFromContext(ctx, "authentication")
+
+		var principal any
+		principal, httpStatus, err = self.authenticate(spanCtx, requester)
+		if err == nil {
+			httpStatus, err = self.authorize(spanCtx, requester, principal)
+		}
+		if err == nil {
+			httpStatus = http.StatusInternalServerError
+			err = self.handlerData.setPrincipal(handlerAlloc.handlerValue, principal)
+		}
+		authenticationSpan.Finish()
+
+		if err != nil {
+			return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpStatus, err)
+		}
+
+		if principal != nil {
+			ctx = context.WithValue(ctx, principalKey{}, principal)
+		}
+	}
+
+	// Authorization
 	{
 		authSpan, ctx := opentracing.StartSpanFromContext(ctx, "authorization")
 
@@ -253,18 +421,18 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 			log.Trace(ctx, "processing auth handler")
 
 			if asAuthorizer, ok := handlerAlloc.auth.(Authorizer); ok {
-				httpStatus, err = asAuthorizer.Authorization(ctx, requester.PeekHeader)
+				httpStatus, err = asAuthorizer.Authorization(ctx, requester)
 				if err != nil {
 					log.Debug(ctx, "authorization failed")
 					authSpan.Finish()
 
-					return self.processErrorResponse(ctx, requester, responseMimeType, httpStatus, err)
+					return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpStatus, err)
 				}
 			} else {
 				log.Debug(ctx, "authorization object does not implement Authorizer")
 				authSpan.Finish()
 
-				return self.processErrorResponse(ctx, requester, responseMimeType, httpstatus.InternalServerError, errors.Wrap(err, ErrInternalServerError))
+				return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpstatus.InternalServerError, errors.Wrap(err, ErrInternalServerError))
 			}
 		}
 
@@ -275,20 +443,51 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 	{
 		requestBodySpan, ctx := opentracing.StartSpanFromContext(ctx, "process request body")
 
-		if self.handlerData.hasRequestBody {
-			log.Trace(ctx, "processing request body")
+		if self.handlerData.hasRequestBody && self.handlerData.isRequestStream {
+			log.Trace(ctx, "streaming request body")
+
+			// The handler reads the stream, so the request body cannot be validated beforehand.
+			self.handlerData.setRequestStream(handlerAlloc.handlerValue, newContextReader(ctx, requester.RequestBodyStream()))
+		} else if requestMimeType != nil && requestMimeType.MimeType == mimeTypeMultipartFormData {
+			log.Trace(ctx, "processing multipart request body")
 
-			requestBodyBytes := requester.RequestBody()
+			form := &MultipartForm{}
+			// Temporary files must remain until the handler completes.
+			defer form.RemoveAll()
 
-			err = self.setHandlerRequestBody(ctx, requestMimeType, handlerAlloc.requestBody, requestBodyBytes)
+			var openedFiles []io.Closer
+			openedFiles, err = self.setHandlerMultipart(ctx, requester, requestMimeType, requestMediaType, handlerAlloc, form)
+			defer closeAll(ctx, openedFiles)
 			if err != nil {
-				log.Debug(ctx, "failed processing request body")
+				log.Debug(ctx, "failed processing multipart request body")
 				requestBodySpan.Finish()
 
-				return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusBadRequest, err)
+				httpStatus := http.StatusBadRequest
+				if errors.Is(err, ErrMultipartPartTooLarge) {
+					httpStatus = http.StatusRequestEntityTooLarge
+				}
+
+				return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpStatus, err)
+			}
+		} else if self.handlerData.hasRequestBody {
+			log.Trace(ctx, "processing request body")
+
+			shouldValidateBytes := self.Config.RequestValidator != nil && self.handlerData.shouldValidateRequest
+
+			// The raw request body is validated before it is unmarshaled so that validation failures describe the body as it was sent.
+			var requestBodyBytes []byte
+			if requestMimeType.UnmarshalStream == nil || shouldValidateBytes {
+				requestBodyBytes = requester.RequestBody()
+
+				if httpStatus, err = requestBodyError(requester); err != nil {
+					log.Debug(ctx, "failed reading request body")
+					requestBodySpan.Finish()
+
+					return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpStatus, err)
+				}
 			}
 
-			if self.Config.RequestValidator != nil && self.handlerData.shouldValidateRequest {
+			if shouldValidateBytes {
 				log.Trace(ctx, "processing validation handler")
 
 				var validationFailure error
@@ -300,9 +499,39 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 					// The failure is passed through since it is assumed this error contains information to be returned in the response.
 					requestBodySpan.Finish()
 
-					return self.processErrorResponse(ctx, requester, responseMimeType, httpStatus, validationFailure)
+					return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpStatus, validationFailure)
 				}
 			}
+
+			if requestMimeType.UnmarshalStream != nil {
+				requestBodyStream := requester.RequestBodyStream()
+				if shouldValidateBytes {
+					// The stream was already read for validation.
+					requestBodyStream = bytes.NewReader(requestBodyBytes)
+				}
+				err = self.setHandlerRequestBodyStream(ctx, requestMimeType, requestMediaType, handlerAlloc.requestBody, requestBodyStream)
+			} else {
+				err = self.setHandlerRequestBody(ctx, requestMimeType, handlerAlloc.requestBody, requestBodyBytes)
+			}
+			if err != nil {
+				log.Debug(ctx, "failed processing request body")
+				requestBodySpan.Finish()
+
+				return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, http.StatusBadRequest, err)
+			}
+		}
+
+		if self.handlerData.hasRequestBody && !self.handlerData.isRequestStream && self.handlerData.shouldValidateRequest {
+			log.Trace(ctx, "processing request body validation")
+
+			var validationFailure error
+			httpStatus, validationFailure = self.Config.RequestBodyValidator.ValidateRequestBody(ctx, handlerAlloc.requestBody)
+			if validationFailure != nil {
+				log.Debug(ctx, "failed request body validation")
+				requestBodySpan.Finish()
+
+				return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpStatus, validationFailure)
+			}
 		}
 
 		requestBodySpan.Finish()
@@ -311,12 +540,24 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 	if !ShouldContinue(ctx) {
 		log.Debug(ctx, "request canceled or timed out")
 
-		return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusRequestTimeout, ErrRequestTimeout)
+		return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, http.StatusRequestTimeout, ErrRequestTimeout)
 	}
 
 	// Run the endpoint handler.
 	log.Trace(ctx, "running endpoint handler")
 
+	if self.handlerData.isBeforeHandler {
+		log.Trace(ctx, "running before handle hook")
+
+		// nolint:forcetypeassert // reason: checked in newHandlerTypeData
+		httpStatus, err = handlerAlloc.handler.(BeforeHandler).BeforeHandle(ctx)
+		if err != nil {
+			log.Debug(ctx, "before handle hook error")
+
+			return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpStatus, err)
+		}
+	}
+
 	handlerSpan, ctx := opentracing.StartSpanFromContext(ctx, "Handle()")
 	httpStatus, err = handlerAlloc.handler.Handle(ctx)
 	handlerSpan.Finish()
@@ -325,13 +566,45 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 	if err != nil {
 		log.Debug(ctx, "handler error")
 
-		return self.processErrorResponse(ctx, requester, responseMimeType, httpStatus, err)
+		return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpStatus, err)
+	}
+
+	if self.handlerData.isAfterHandler {
+		log.Trace(ctx, "running after handle hook")
+
+		// nolint:forcetypeassert // reason: checked in newHandlerTypeData
+		httpStatus, err = handlerAlloc.handler.(AfterHandler).AfterHandle(ctx, httpStatus)
+		if err != nil {
+			log.Debug(ctx, "after handle hook error")
+
+			return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpStatus, err)
+		}
 	}
 
 	if !ShouldContinue(ctx) {
 		log.Debug(ctx, "request canceled or timed out")
 
-		return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusRequestTimeout, ErrRequestTimeout)
+		return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, http.StatusRequestTimeout, ErrRequestTimeout)
+	}
+
+	if err = self.handlerData.setResponseHeaders(handlerAlloc.handlerValue, requester); err != nil {
+		log.Debug(ctx, "failed to set response headers")
+
+		return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, http.StatusInternalServerError, err)
+	}
+
+	if self.handlerData.isResponseStream {
+		log.Trace(ctx, "streaming response body")
+
+		// The stream is written by the server after Execute returns.
+		// Since the status has already been sent by then, stream failures can only be logged.
+		if stream := self.handlerData.getResponseStream(handlerAlloc.handlerValue); stream != nil {
+			requester.SetResponseBodyStream(newContextReader(ctx, stream))
+		}
+
+		log.Debug(ctx, "success response: %d (streamed)", httpStatus)
+
+		return httpStatus, nil
 	}
 
 	// Handle Response Body
@@ -343,7 +616,7 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 			log.Debug(ctx, "failed processing response")
 			responseBodySpan.Finish()
 
-			return self.processErrorResponse(ctx, requester, responseMimeType, http.StatusInternalServerError, err)
+			return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, http.StatusInternalServerError, err)
 		}
 
 		if self.Config.ResponseValidator != nil && self.handlerData.shouldValidateResponse {
@@ -357,7 +630,7 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 				// The failure is passed through since it is assumed this error contains information to be returned in the response.
 				responseBodySpan.Finish()
 
-				return self.processErrorResponse(ctx, requester, responseMimeType, httpStatus, validationFailure)
+				return self.handlerErrorResponse(ctx, requester, responseMimeType, handlerAlloc, httpStatus, validationFailure)
 			}
 		}
 
@@ -375,6 +648,18 @@ func (self *Endpoint) Execute(ctx context.Context, requester Requester) (httpSta
 	return httpStatus, responseBody
 }
 
+// handlerErrorResponse passes the error to the OnError hook of the handler, if implemented, before processing the error response.
+func (self *Endpoint) handlerErrorResponse(ctx context.Context, requester Requester, responseMimeType *MimeTypeHandler, handlerAlloc *handlerAllocation, httpStatus int, err error) (int, []byte) {
+	if self.handlerData.isOnErrorHandler {
+		log.Trace(ctx, "running on error hook")
+
+		// nolint:forcetypeassert // reason: checked in newHandlerTypeData
+		httpStatus, err = handlerAlloc.handler.(OnErrorHandler).OnError(ctx, httpStatus, err)
+	}
+
+	return self.processErrorResponse(ctx, requester, responseMimeType, httpStatus, err)
+}
+
 func (self *Endpoint) processErrorResponse(ctx context.Context, requester Requester, responseMimeType *MimeTypeHandler, httpStatus int, err error) (int, []byte) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "processErrorResponse()")
 	defer span.Finish()
@@ -382,6 +667,8 @@ func (self *Endpoint) processErrorResponse(ctx context.Context, requester Reques
 	var responseBody []byte
 	var errStruct any
 
+	httpStatus = httpErrorStatus(err, httpStatus)
+
 	defer func() {
 		// Print the actual error response returned to the caller.
 		log.Debug(ctx, "error response: %d %s", httpStatus, responseBody)
@@ -397,6 +684,9 @@ func (self *Endpoint) processErrorResponse(ctx context.Context, requester Reques
 		log.Debug(ctx, "error (%d): %#v", httpStatus, err)
 	}
 
+	// The cause of an HttpError and the message of an internal error have been logged and must never reach the client.
+	err = publicError(err, httpStatus)
+
 	if responseMimeType == nil {
 		requester.SetResponseContentType(mimeTypeTextPlain)
 		responseBody = []byte(fmt.Sprintf("%v", err))
@@ -405,6 +695,9 @@ func (self *Endpoint) processErrorResponse(ctx context.Context, requester Reques
 	}
 
 	httpStatus, errStruct = self.Config.ErrorHandler.HandleError(ctx, httpStatus, err)
+	if contentTyper, ok := self.Config.ErrorHandler.(ErrorContentTyper); ok {
+		requester.SetResponseContentType(contentTyper.ErrorContentType(requester.Accept(), responseMimeType.MimeType))
+	}
 	responseBody, err = responseMimeType.Marshal(errStruct)
 	if err != nil {
 		requester.SetResponseContentType(mimeTypeTextPlain)
@@ -432,6 +725,88 @@ func (self *Endpoint) setHandlerRequestBody(ctx context.Context, mimeHandler *Mi
 	return nil
 }
 
+func (self *Endpoint) setHandlerRequestBodyStream(ctx context.Context, mimeHandler *MimeTypeHandler, mediaType MediaType, requestBody any, requestBodyStream io.Reader) error {
+	if requestBody != nil {
+		log.Trace(ctx, "non-empty request body stream")
+
+		if err := mimeHandler.UnmarshalStream(newContextReader(ctx, requestBodyStream), mediaType, requestBody); err != nil {
+			log.Error(ctx, "failed to unmarshal request body stream: %v", err)
+
+			return errors.Wrap(err, ErrBadRequest)
+		}
+	}
+
+	return nil
+}
+
+// setHandlerMultipart reads the multipart form and binds values to the request body and file parts to "file=<name>" fields.
+// Returns the files opened for the handler so they can be closed once the handler completes.
+func (self *Endpoint) setHandlerMultipart(ctx context.Context, requester Requester, mimeHandler *MimeTypeHandler, mediaType MediaType, handlerAlloc *handlerAllocation, form *MultipartForm) ([]io.Closer, error) {
+	if mimeHandler.UnmarshalStream == nil {
+		return nil, errors.Wrap(ErrInvalidMimeType, ErrBadRequest)
+	}
+
+	if err := mimeHandler.UnmarshalStream(newContextReader(ctx, requester.RequestBodyStream()), mediaType, form); err != nil {
+		log.Error(ctx, "failed to read multipart form: %v", err)
+
+		if errors.Is(err, ErrMultipartPartTooLarge) {
+			return nil, err
+		}
+
+		return nil, errors.Wrap(err, ErrBadRequest)
+	}
+
+	if handlerAlloc.requestBody != nil {
+		if err := form.Bind(handlerAlloc.requestBody); err != nil {
+			log.Error(ctx, "failed to bind multipart form: %v", err)
+
+			return nil, errors.Wrap(err, ErrBadRequest)
+		}
+	}
+
+	openedFiles, err := self.handlerData.setFiles(handlerAlloc.handlerValue, form)
+	if err != nil {
+		log.Error(ctx, "failed to set files: %v", err)
+
+		return openedFiles, errors.Wrap(err, ErrBadRequest)
+	}
+
+	return openedFiles, nil
+}
+
+// requestBodyErrorer is implemented by requesters that read the request body when it is needed, which can fail.
+type requestBodyErrorer interface {
+	RequestBodyError() error
+}
+
+// requestBodyError returns the error from reading the request body and the status of its error response.
+func requestBodyError(requester Requester) (int, error) {
+	bodyErrorer, ok := requester.(requestBodyErrorer)
+	if !ok {
+		return 0, nil
+	}
+
+	err := bodyErrorer.RequestBodyError()
+	if err == nil {
+		return 0, nil
+	}
+
+	// http.MaxBytesReader errors have no exported type before Go 1.19, but always have this message.
+	if strings.Contains(err.Error(), "request body too large") {
+		return http.StatusRequestEntityTooLarge, errors.Wrap(err, ErrRequestBodyTooLarge)
+	}
+
+	return http.StatusBadRequest, errors.Wrap(err, ErrInvalidBody)
+}
+
+func closeAll(ctx context.Context, closers []io.Closer) {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			log.Warn(ctx, "failed to close: %v", err)
+		}
+	}
+}
+
 func (self *Endpoint) getHandlerResponseBody(ctx context.Context, requester Requester, mimeHandler *MimeTypeHandler, responseBody any) ([]byte, error) {
 	if responseBody != nil {
 		log.Trace(ctx, "non-empty response body")
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"strings"
 	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wspowell/context"
@@ -35,10 +39,10 @@ type user struct {
 	userData string
 }
 
-func (self *user) Authorization(ctx context.Context, peekHeader func(key string) []byte) (int, error) {
+func (self *user) Authorization(ctx context.Context, requester endpoint.Requester) (int, error) {
 	var statusCode int
 
-	if !bytes.EqualFold([]byte("valid-token"), peekHeader(httpheader.Authorization)) {
+	if !bytes.EqualFold([]byte("valid-token"), requester.PeekHeader(httpheader.Authorization)) {
 		return httpstatus.Unauthorized, errors.New("invalid auth token")
 	}
 
@@ -370,3 +374,918 @@ func Test_Endpoint_Auth_Error(t *testing.T) {
 		t.Errorf("expected 'message' to be '%v', but got '%v'", "invalid auth token", string(responseBodyBytes))
 	}
 }
+
+func Test_Endpoint_Content_Negotiation(t *testing.T) {
+	t.Parallel()
+
+	testEndpoint := createDefaultTestEndpoint()
+
+	ctx := context.Background()
+	ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/resources/myid/5/true?id=me&num=13&flag=true", strings.NewReader(`{"myString": "hello", "myInt": 5}`))
+	assert.Nil(t, err)
+
+	req.Header.Add(httpheader.ContentType, "application/json; charset=utf-8")
+	req.Header.Add(httpheader.Accept, "text/html, application/*;q=0.9, */*;q=0.8")
+	req.Header.Add(httpheader.Authorization, "valid-token")
+
+	requester, err := endpoint.NewHttpRequester("/resources/{id}/{num}/{flag}", req)
+	assert.Nil(t, err)
+
+	var httpStatus int
+	var responseBodyBytes []byte
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+	}()
+	wg.Wait()
+
+	assert.Equal(t, httpstatus.OK, httpStatus)
+	assert.Equal(t, "application/json", requester.ResponseContentType())
+	assert.Equal(t, `{"outputString":"hello","outputInt":5}`, string(responseBodyBytes))
+}
+
+type untaggedBody struct {
+	Value string `json:"value"`
+}
+
+type untaggedMimeEndpoint struct {
+	RequestBody  *untaggedBody `spiderweb:"request"`
+	ResponseBody *untaggedBody `spiderweb:"response"`
+}
+
+func (self *untaggedMimeEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = self.RequestBody
+
+	return httpstatus.OK, nil
+}
+
+func Test_Endpoint_Mime_Type_Default_Json_Only(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		contentType        string
+		accept             string
+		expectedHttpStatus int
+	}{
+		{name: "json", contentType: "application/json", accept: "application/json", expectedHttpStatus: httpstatus.OK},
+		{name: "xml request", contentType: "application/xml", accept: "application/json", expectedHttpStatus: httpstatus.UnsupportedMediaType},
+		{name: "msgpack request", contentType: "application/msgpack", accept: "application/json", expectedHttpStatus: httpstatus.UnsupportedMediaType},
+		{name: "xml response", contentType: "application/json", accept: "application/xml", expectedHttpStatus: httpstatus.NotAcceptable},
+		{name: "protobuf response", contentType: "application/json", accept: "application/x-protobuf", expectedHttpStatus: httpstatus.NotAcceptable},
+		{name: "multipart response", contentType: "application/json", accept: "multipart/form-data", expectedHttpStatus: httpstatus.NotAcceptable},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelFatal))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelFatal),
+			}, &untaggedMimeEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/values", strings.NewReader(`{"value":"hello"}`))
+			assert.Nil(t, err)
+			req.Header.Add(httpheader.ContentType, testCase.contentType)
+			req.Header.Add(httpheader.Accept, testCase.accept)
+
+			requester, err := endpoint.NewHttpRequester("/values", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, _ = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+		})
+	}
+}
+
+func Test_Endpoint_Request_Body_Read_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		body               io.Reader
+		expectedHttpStatus int
+		expectedType       string
+	}{
+		{name: "over limit", body: http.MaxBytesReader(nil, io.NopCloser(strings.NewReader(`{"value":"hello"}`)), 4), expectedHttpStatus: httpstatus.RequestEntityTooLarge, expectedType: "urn:spiderweb:problem:request-body-too-large"},
+		{name: "truncated", body: io.MultiReader(strings.NewReader(`{"value":`), iotest.ErrReader(io.ErrUnexpectedEOF)), expectedHttpStatus: httpstatus.BadRequest, expectedType: "urn:spiderweb:problem:invalid-body"},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelFatal))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig:    log.NewConfig().WithLevel(log.LevelFatal),
+				ErrorHandler: endpoint.ProblemErrorHandler{},
+			}, &untaggedMimeEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/values", testCase.body)
+			assert.Nil(t, err)
+			req.Header.Add(httpheader.ContentType, "application/json")
+			req.Header.Add(httpheader.Accept, "application/json")
+
+			requester, err := endpoint.NewHttpRequester("/values", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBody []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBody = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			// The handler is never given an empty body.
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.NotNil(t, requester.RequestBodyError())
+
+			problem := endpoint.Problem{}
+			assert.Nil(t, json.Unmarshal(responseBody, &problem))
+			assert.Equal(t, testCase.expectedType, problem.Type)
+		})
+	}
+}
+
+func Test_Endpoint_Mime_Type_Not_Allowed(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		contentType        string
+		accept             string
+		expectedHttpStatus int
+	}{
+		{name: "unsupported Content-Type", contentType: "application/xml", accept: "application/json", expectedHttpStatus: httpstatus.UnsupportedMediaType},
+		{name: "unsupported Accept", contentType: "application/json", accept: "application/xml", expectedHttpStatus: httpstatus.NotAcceptable},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			config := &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelError),
+				MimeTypeHandlers: map[string]*endpoint.MimeTypeHandler{
+					// Registered, but not allowed by the endpoint.
+					"application/xml": {
+						MimeType:  "application/xml",
+						Marshal:   json.Marshal,
+						Unmarshal: json.Unmarshal,
+					},
+				},
+				Resources: map[string]any{
+					"db": &myDbClient{conn: "myconnection"},
+				},
+			}
+			testEndpoint := endpoint.NewEndpoint(ctx, config, &myEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/resources/myid/5/true?id=me&num=13&flag=true", strings.NewReader(`{"myString": "hello", "myInt": 5}`))
+			assert.Nil(t, err)
+
+			req.Header.Add(httpheader.ContentType, testCase.contentType)
+			req.Header.Add(httpheader.Accept, testCase.accept)
+			req.Header.Add(httpheader.Authorization, "valid-token")
+
+			requester, err := endpoint.NewHttpRequester("/resources/{id}/{num}/{flag}", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, _ = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+		})
+	}
+}
+
+type streamEndpoint struct {
+	RequestBody  io.Reader `spiderweb:"request,stream,mime=application/octet-stream"`
+	ResponseBody io.Reader `spiderweb:"response,stream,mime=application/octet-stream;application/json"`
+}
+
+func (self *streamEndpoint) Handle(ctx context.Context) (int, error) {
+	requestBytes, err := io.ReadAll(self.RequestBody)
+	if err != nil {
+		return httpstatus.BadRequest, err
+	}
+
+	if len(requestBytes) == 0 {
+		return httpstatus.BadRequest, errors.New("empty stream")
+	}
+
+	self.ResponseBody = bytes.NewReader(bytes.ToUpper(requestBytes))
+
+	return httpstatus.OK, nil
+}
+
+func Test_Endpoint_Stream(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                string
+		requestBody         string
+		accept              string
+		expectedHttpStatus  int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{name: "success", requestBody: "hello", accept: "*/*", expectedHttpStatus: httpstatus.OK, expectedContentType: "application/octet-stream", expectedBody: "HELLO"},
+		{name: "error marshaled with registered handler", requestBody: "", accept: "application/json", expectedHttpStatus: httpstatus.BadRequest, expectedContentType: "application/json", expectedBody: `{"message":"empty stream"}`},
+		{name: "not acceptable", requestBody: "hello", accept: "text/html", expectedHttpStatus: httpstatus.NotAcceptable, expectedContentType: "text/plain"},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelError),
+			}, &streamEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/stream", strings.NewReader(testCase.requestBody))
+			assert.Nil(t, err)
+
+			req.Header.Add(httpheader.ContentType, "application/octet-stream")
+			req.Header.Add(httpheader.Accept, testCase.accept)
+
+			requester, err := endpoint.NewHttpRequester("/stream", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedContentType, requester.ResponseContentType())
+
+			if stream := requester.ResponseBodyStream(); stream != nil {
+				assert.Nil(t, responseBodyBytes)
+				responseBodyBytes, err = io.ReadAll(stream)
+				assert.Nil(t, err)
+			}
+
+			if testCase.expectedBody != "" {
+				assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+			}
+		})
+	}
+}
+
+type uploadRequest struct {
+	Description string `form:"description"`
+}
+
+type uploadResponse struct {
+	Description string `json:"description"`
+	FileName    string `json:"fileName"`
+	Contents    string `json:"contents"`
+}
+
+type uploadEndpoint struct {
+	Avatar       *multipart.FileHeader `spiderweb:"file=avatar,required"`
+	Notes        io.Reader             `spiderweb:"file=notes"`
+	RequestBody  *uploadRequest        `spiderweb:"request,mime=multipart/form-data"`
+	ResponseBody *uploadResponse       `spiderweb:"response,mime=application/json"`
+}
+
+func (self *uploadEndpoint) Handle(ctx context.Context) (int, error) {
+	contents, err := io.ReadAll(self.Notes)
+	if err != nil {
+		return httpstatus.InternalServerError, err
+	}
+
+	self.ResponseBody = &uploadResponse{
+		Description: self.RequestBody.Description,
+		FileName:    self.Avatar.Filename,
+		Contents:    string(contents),
+	}
+
+	return httpstatus.OK, nil
+}
+
+func Test_Endpoint_Multipart(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		fileName           string
+		maxPartSize        int64
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{name: "success", fileName: "avatar", expectedHttpStatus: httpstatus.OK, expectedBody: `{"description":"my avatar","fileName":"me.png","contents":"n"}`},
+		{name: "missing required file", fileName: "other", expectedHttpStatus: httpstatus.BadRequest},
+		{name: "part too large", fileName: "avatar", maxPartSize: 2, expectedHttpStatus: httpstatus.RequestEntityTooLarge},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelError),
+				MimeTypeHandlers: endpoint.MimeTypeHandlers{
+					"multipart/form-data": endpoint.MultipartHandler(endpoint.MultipartConfig{
+						MaxPartSize: testCase.maxPartSize,
+					}),
+				},
+			}, &uploadEndpoint{})
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			assert.Nil(t, writer.WriteField("description", "my avatar"))
+			part, err := writer.CreateFormFile(testCase.fileName, "me.png")
+			assert.Nil(t, err)
+			_, err = part.Write([]byte("image bytes"))
+			assert.Nil(t, err)
+			part, err = writer.CreateFormFile("notes", "notes.txt")
+			assert.Nil(t, err)
+			_, err = part.Write([]byte("n"))
+			assert.Nil(t, err)
+			assert.Nil(t, writer.Close())
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/upload", body)
+			assert.Nil(t, err)
+
+			req.Header.Add(httpheader.ContentType, writer.FormDataContentType())
+			req.Header.Add(httpheader.Accept, "application/json")
+
+			requester, err := endpoint.NewHttpRequester("/upload", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			if testCase.expectedBody != "" {
+				assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+			}
+		})
+	}
+}
+
+// countingReader counts the bytes read from the request body.
+type countingReader struct {
+	reader io.Reader
+	read   int
+}
+
+func (self *countingReader) Read(p []byte) (int, error) {
+	n, err := self.reader.Read(p)
+	self.read += n
+
+	return n, err
+}
+
+func Test_MultipartHandler_MaxPartSize(t *testing.T) {
+	t.Parallel()
+
+	const partSize = 10 << 20
+
+	header := &bytes.Buffer{}
+	writer := multipart.NewWriter(header)
+	_, err := writer.CreateFormFile("avatar", "me.png")
+	assert.Nil(t, err)
+
+	trailer := "\r\n--" + writer.Boundary() + "--\r\n"
+	body := &countingReader{
+		reader: io.MultiReader(header, io.LimitReader(zeroReader{}, partSize), strings.NewReader(trailer)),
+	}
+
+	mediaType, err := endpoint.ParseMediaType(writer.FormDataContentType())
+	assert.Nil(t, err)
+
+	handler := endpoint.MultipartHandler(endpoint.MultipartConfig{
+		MaxPartSize: 1024,
+	})
+	form := &endpoint.MultipartForm{}
+	err = handler.UnmarshalStream(body, mediaType, form)
+	assert.ErrorIs(t, err, endpoint.ErrMultipartPartTooLarge)
+
+	// The part is rejected while it is read, not after the whole body is read.
+	assert.Less(t, body.read, partSize)
+}
+
+func Test_MultipartForm_Bind_invalid_field(t *testing.T) {
+	t.Parallel()
+
+	form := &endpoint.MultipartForm{
+		Form: &multipart.Form{
+			Value: map[string][]string{"count": {"three"}},
+		},
+	}
+
+	var value struct {
+		Count int `form:"count"`
+	}
+	err := form.Bind(&value)
+	assert.Equal(t, "invalid form field: count", err.Error())
+	// The parse failure is kept as the cause.
+	assert.Contains(t, fmt.Sprintf("%+v", err), "could not set value (int) from string (three)")
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for index := range p {
+		p[index] = 0
+	}
+
+	return len(p), nil
+}
+
+type bindingResponse struct {
+	TenantId int    `json:"tenantId"`
+	Session  string `json:"session"`
+}
+
+type bindingEndpoint struct {
+	TenantId     int              `spiderweb:"header=X-Tenant-Id,required"`
+	Session      string           `spiderweb:"cookie=session"`
+	ResponseBody *bindingResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *bindingEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &bindingResponse{
+		TenantId: self.TenantId,
+		Session:  self.Session,
+	}
+
+	return httpstatus.OK, nil
+}
+
+func Test_Endpoint_Header_Cookie_Binding(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		headers            map[string]string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{name: "success", headers: map[string]string{"X-Tenant-Id": "12", "Cookie": "theme=dark; session=abc123"}, expectedHttpStatus: httpstatus.OK, expectedBody: `{"tenantId":12,"session":"abc123"}`},
+		{name: "optional cookie missing", headers: map[string]string{"X-Tenant-Id": "12"}, expectedHttpStatus: httpstatus.OK, expectedBody: `{"tenantId":12,"session":""}`},
+		{name: "required header missing", headers: map[string]string{"Cookie": "session=abc123"}, expectedHttpStatus: httpstatus.BadRequest},
+		{name: "invalid header value", headers: map[string]string{"X-Tenant-Id": "twelve"}, expectedHttpStatus: httpstatus.BadRequest},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelError),
+			}, &bindingEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/binding", nil)
+			assert.Nil(t, err)
+
+			req.Header.Add(httpheader.Accept, "application/json")
+			for header, value := range testCase.headers {
+				req.Header.Add(header, value)
+			}
+
+			requester, err := endpoint.NewHttpRequester("/binding", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			if testCase.expectedBody != "" {
+				assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+			}
+		})
+	}
+}
+
+type createdEndpoint struct {
+	Location     string           `spiderweb:"response-header=Location"`
+	RetryAfter   int              `spiderweb:"response-header=retry-after"`
+	Empty        string           `spiderweb:"response-header=X-Empty"`
+	TotalCount   int              `spiderweb:"response-header=X-Total-Count"`
+	Cached       bool             `spiderweb:"response-header=X-Cached"`
+	Optional     *int             `spiderweb:"response-header=X-Optional"`
+	Session      *http.Cookie     `spiderweb:"set-cookie"`
+	Others       []*http.Cookie   `spiderweb:"set-cookie"`
+	ResponseBody *bindingResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *createdEndpoint) Handle(ctx context.Context) (int, error) {
+	self.Location = "/resources/12"
+	self.RetryAfter = 30
+	self.Session = &http.Cookie{Name: "session", Value: "abc123", HttpOnly: true}
+	self.Others = []*http.Cookie{{Name: "theme", Value: "dark"}}
+	self.ResponseBody = &bindingResponse{TenantId: 12}
+
+	return httpstatus.Created, nil
+}
+
+func Test_Endpoint_Response_Headers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+	testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+		LogConfig: log.NewConfig().WithLevel(log.LevelError),
+	}, &createdEndpoint{})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/resources", nil)
+	assert.Nil(t, err)
+
+	requester, err := endpoint.NewHttpRequester("/resources", req)
+	assert.Nil(t, err)
+
+	var httpStatus int
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		httpStatus, _ = testEndpoint.Execute(ctx, requester)
+	}()
+	wg.Wait()
+
+	assert.Equal(t, httpstatus.Created, httpStatus)
+	assert.Equal(t, "/resources/12", req.Response.Header.Get("Location"))
+	assert.Equal(t, "30", req.Response.Header.Get("Retry-After"))
+	assert.NotContains(t, req.Response.Header, "X-Empty")
+	assert.Equal(t, "0", req.Response.Header.Get("X-Total-Count"))
+	assert.Equal(t, "false", req.Response.Header.Get("X-Cached"))
+	assert.NotContains(t, req.Response.Header, "X-Optional")
+	assert.Equal(t, []string{"session=abc123; HttpOnly", "theme=dark"}, req.Response.Header.Values(httpheader.SetCookie))
+}
+
+type queryResponse struct {
+	Ids   []int         `json:"ids"`
+	Limit *int          `json:"limit"`
+	Wait  time.Duration `json:"wait"`
+}
+
+type queryEndpoint struct {
+	Ids          []int          `spiderweb:"query=id"`
+	Limit        *int           `spiderweb:"query=limit"`
+	Wait         time.Duration  `spiderweb:"query=wait"`
+	ResponseBody *queryResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *queryEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &queryResponse{
+		Ids:   self.Ids,
+		Limit: self.Limit,
+		Wait:  self.Wait,
+	}
+
+	return httpstatus.OK, nil
+}
+
+func Test_Endpoint_Query_Binding(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		query              string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{name: "repeated", query: "id=1&id=2", expectedHttpStatus: httpstatus.OK, expectedBody: `{"ids":[1,2],"limit":null,"wait":0}`},
+		{name: "comma separated", query: "id=1,2,3&limit=0&wait=1s", expectedHttpStatus: httpstatus.OK, expectedBody: `{"ids":[1,2,3],"limit":0,"wait":1000000000}`},
+		{name: "invalid", query: "id=1,two", expectedHttpStatus: httpstatus.BadRequest},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelError),
+			}, &queryEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/query?"+testCase.query, nil)
+			assert.Nil(t, err)
+
+			requester, err := endpoint.NewHttpRequester("/query", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			if testCase.expectedBody != "" {
+				assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+			}
+		})
+	}
+}
+
+type listResponse struct {
+	Limit  int    `json:"limit"`
+	Order  string `json:"order"`
+	Cursor string `json:"cursor"`
+}
+
+type listEndpoint struct {
+	Limit        int           `spiderweb:"query=limit,default=25,min=1,max=100"`
+	Order        string        `spiderweb:"query=order,default=asc,enum=asc|desc"`
+	Cursor       string        `spiderweb:"query=cursor,pattern=[a-z0-9]+"`
+	TenantId     string        `spiderweb:"header=X-Tenant-Id,required,min=3"`
+	Locale       string        `spiderweb:"query=locale,pattern=[a-z]{2,3}(-[A-Z]{2})?"`
+	ResponseBody *listResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *listEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &listResponse{
+		Limit:  self.Limit,
+		Order:  self.Order,
+		Cursor: self.Cursor,
+	}
+
+	return httpstatus.OK, nil
+}
+
+func Test_Endpoint_Parameter_Constraints(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		query              string
+		tenantId           string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{name: "defaults", tenantId: "acme", expectedHttpStatus: httpstatus.OK, expectedBody: `{"limit":25,"order":"asc","cursor":""}`},
+		{name: "valid", query: "limit=100&order=desc&cursor=abc123", tenantId: "acme", expectedHttpStatus: httpstatus.OK, expectedBody: `{"limit":100,"order":"desc","cursor":"abc123"}`},
+		{name: "pattern with comma", query: "locale=en-US", tenantId: "acme", expectedHttpStatus: httpstatus.OK, expectedBody: `{"limit":25,"order":"asc","cursor":""}`},
+		{
+			name:               "pattern with comma mismatch",
+			query:              "locale=english",
+			tenantId:           "acme",
+			expectedHttpStatus: httpstatus.BadRequest,
+			expectedBody: `{"message":"bad request: query parameter 'locale' must match pattern ^(?:[a-z]{2,3}(-[A-Z]{2})?)$",` +
+				`"parameters":[{"in":"query","name":"locale","message":"must match pattern ^(?:[a-z]{2,3}(-[A-Z]{2})?)$"}]}`,
+		},
+		{
+			name:               "every failure listed",
+			query:              "limit=0&order=up&cursor=ABC",
+			expectedHttpStatus: httpstatus.BadRequest,
+			expectedBody: `{"message":"bad request: query parameter 'limit' must be at least 1, got 0; query parameter 'order' must be one of [asc, desc]; query parameter 'cursor' must match pattern ^(?:[a-z0-9]+)$; header parameter 'X-Tenant-Id' is required",` +
+				`"parameters":[` +
+				`{"in":"query","name":"limit","message":"must be at least 1, got 0"},` +
+				`{"in":"query","name":"order","message":"must be one of [asc, desc]"},` +
+				`{"in":"query","name":"cursor","message":"must match pattern ^(?:[a-z0-9]+)$"},` +
+				`{"in":"header","name":"X-Tenant-Id","message":"is required"}]}`,
+		},
+		{
+			name:               "max and string length",
+			query:              "limit=101",
+			tenantId:           "ab",
+			expectedHttpStatus: httpstatus.BadRequest,
+			expectedBody: `{"message":"bad request: query parameter 'limit' must be at most 100, got 101; header parameter 'X-Tenant-Id' length must be at least 3, got 2",` +
+				`"parameters":[` +
+				`{"in":"query","name":"limit","message":"must be at most 100, got 101"},` +
+				`{"in":"header","name":"X-Tenant-Id","message":"length must be at least 3, got 2"}]}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelError),
+			}, &listEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/list?"+testCase.query, nil)
+			assert.Nil(t, err)
+
+			if testCase.tenantId != "" {
+				req.Header.Add("X-Tenant-Id", testCase.tenantId)
+			}
+
+			requester, err := endpoint.NewHttpRequester("/list", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+		})
+	}
+}
+
+type signupRequest struct {
+	Name  string `json:"name" validate:"required,min=3"`
+	Email string `json:"email" validate:"required,email"`
+}
+
+type signupEndpoint struct {
+	RequestBody  *signupRequest `spiderweb:"request,mime=application/json,validate"`
+	ResponseBody *signupRequest `spiderweb:"response,mime=application/json"`
+}
+
+func (self *signupEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = self.RequestBody
+
+	return httpstatus.Created, nil
+}
+
+func Test_Endpoint_Request_Body_Validation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		requestBody        string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{name: "valid", requestBody: `{"name":"bob","email":"bob@example.com"}`, expectedHttpStatus: httpstatus.Created, expectedBody: `{"name":"bob","email":"bob@example.com"}`},
+		{
+			name:               "invalid",
+			requestBody:        `{"name":"bo"}`,
+			expectedHttpStatus: httpstatus.BadRequest,
+			expectedBody:       `{"message":"bad request: name length must be at least 3; email is required","fields":{"email":"is required","name":"length must be at least 3"},"pointers":{"/email":"is required","/name":"length must be at least 3"}}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelError),
+			}, &signupEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/signup", strings.NewReader(testCase.requestBody))
+			assert.Nil(t, err)
+
+			req.Header.Add(httpheader.ContentType, "application/json")
+
+			requester, err := endpoint.NewHttpRequester("/signup", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+		})
+	}
+}
+
+type xmlSignupEndpoint struct {
+	RequestBody  *signupRequest `spiderweb:"request,mime=application/xml,validate"`
+	ResponseBody *signupRequest `spiderweb:"response,mime=application/xml"`
+}
+
+func (self *xmlSignupEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = self.RequestBody
+
+	return httpstatus.Created, nil
+}
+
+func Test_Endpoint_Request_Body_Validation_XML(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+	testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+		LogConfig: log.NewConfig().WithLevel(log.LevelError),
+	}, &xmlSignupEndpoint{})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/signup", strings.NewReader(`<signupRequest><Name>bo</Name></signupRequest>`))
+	assert.Nil(t, err)
+
+	req.Header.Add(httpheader.ContentType, "application/xml")
+	req.Header.Add(httpheader.Accept, "application/xml")
+
+	requester, err := endpoint.NewHttpRequester("/signup", req)
+	assert.Nil(t, err)
+
+	var httpStatus int
+	var responseBodyBytes []byte
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+	}()
+	wg.Wait()
+
+	assert.Equal(t, httpstatus.BadRequest, httpStatus)
+	assert.Equal(t, `<defaultErrorResponse>`+
+		`<Message>bad request: name length must be at least 3; email is required</Message><Code></Code>`+
+		`<Fields><field name="email">is required</field><field name="name">length must be at least 3</field></Fields>`+
+		`<Pointers><pointer name="/email">is required</pointer><pointer name="/name">length must be at least 3</pointer></Pointers>`+
+		`</defaultErrorResponse>`, string(responseBodyBytes))
+}
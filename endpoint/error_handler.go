@@ -1,9 +1,11 @@
 package endpoint
 
 import (
-	"fmt"
+	"encoding/xml"
+	"sort"
 
 	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
 )
 
 type ErrorHandler interface {
@@ -11,13 +13,110 @@ type ErrorHandler interface {
 }
 
 type defaultErrorResponse struct {
-	Message string `json:"message"`
+	Message    string            `json:"message"`
+	Code       string            `json:"code,omitempty"`
+	Details    map[string]any    `json:"details,omitempty" xml:"-"`
+	Parameters []ParameterError  `json:"parameters,omitempty"`
+	Fields     map[string]string `json:"fields,omitempty" xml:"-"`
+	Pointers   map[string]string `json:"pointers,omitempty" xml:"-"`
+}
+
+// MarshalXML encodes the maps, which encoding/xml cannot marshal, as elements with a "name" attribute sorted by name.
+// Ex: <Fields><field name="email">is required</field></Fields>
+func (self defaultErrorResponse) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if err := encoder.EncodeElement(self.Message, xml.StartElement{Name: xml.Name{Local: "Message"}}); err != nil {
+		return err
+	}
+	if err := encoder.EncodeElement(self.Code, xml.StartElement{Name: xml.Name{Local: "Code"}}); err != nil {
+		return err
+	}
+	if err := encodeXmlNamedMembers(encoder, "Details", "detail", self.Details); err != nil {
+		return err
+	}
+	for _, parameter := range self.Parameters {
+		if err := encoder.EncodeElement(parameter, xml.StartElement{Name: xml.Name{Local: "Parameters"}}); err != nil {
+			return err
+		}
+	}
+	if err := encodeXmlNamedMembers(encoder, "Fields", "field", self.Fields); err != nil {
+		return err
+	}
+	if err := encodeXmlNamedMembers(encoder, "Pointers", "pointer", self.Pointers); err != nil {
+		return err
+	}
+
+	if err := encoder.EncodeToken(start.End()); err != nil {
+		return err
+	}
+
+	return encoder.Flush()
+}
+
+// encodeXmlNamedMembers of a map as member elements with a "name" attribute, sorted by name.
+// Nothing is encoded for an empty map.
+func encodeXmlNamedMembers(encoder *xml.Encoder, name string, memberName string, members any) error {
+	value, err := jsonValue(members)
+	if err != nil {
+		return err
+	}
+
+	memberValues, _ := value.(map[string]any)
+	if len(memberValues) == 0 {
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(memberValues))
+	for memberKey := range memberValues {
+		names = append(names, memberKey)
+	}
+	sort.Strings(names)
+
+	for _, memberKey := range names {
+		memberStart := xml.StartElement{
+			Name: xml.Name{Local: memberName},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: memberKey}},
+		}
+		if err := encodeXmlValue(encoder, memberStart, memberValues[memberKey]); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
 }
 
 type defaultErrorHandler struct{}
 
 func (self defaultErrorHandler) HandleError(ctx context.Context, httpStatus int, err error) (int, any) {
-	return httpStatus, defaultErrorResponse{
-		Message: fmt.Sprintf("%v", err),
+	errorResponse := defaultErrorResponse{
+		Message: LocalizeError(ctx, err),
+	}
+
+	var httpError *HttpError
+	if errors.As(err, &httpError) {
+		httpStatus = httpErrorStatus(httpError, httpStatus)
+		errorResponse.Code = httpError.Code
+		errorResponse.Details = httpError.Details
+	}
+
+	var parameterErrors ParameterErrors
+	if errors.As(err, &parameterErrors) {
+		errorResponse.Parameters = parameterErrors
+	}
+
+	var validationErrors ValidationErrors
+	if errors.As(err, &validationErrors) {
+		errorResponse.Fields = validationErrors.Fields()
+		errorResponse.Pointers = validationErrors.Pointers()
 	}
+
+	return httpStatus, errorResponse
 }
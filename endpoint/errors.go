@@ -6,6 +6,12 @@ var (
 	ErrInternalServerError = errors.New("internal server error")
 	ErrBadRequest          = errors.New("bad request")
 	ErrInvalidBody         = errors.New("invalid body")
+	ErrRequestBodyTooLarge = errors.New("request body too large")
 	ErrRequestTimeout      = errors.New("request timeout")
 	ErrInvalidMimeType     = errors.New("invalid MIME type")
+	ErrNotAcceptable       = errors.New("not acceptable")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	// ErrNoCredentials is returned by an Authenticator when the request has no credentials for its scheme.
+	ErrNoCredentials = errors.New("no credentials")
 )
@@ -12,3 +12,25 @@ type Handler interface {
 	//   any request data.
 	Handle(ctx context.Context) (int, error)
 }
+
+// BeforeHandler may be implemented by a handler struct to run after the request has been bound and validated, but before Handle.
+// Useful for normalizing inputs.
+type BeforeHandler interface {
+	// BeforeHandle is called before Handle. Returning an error skips Handle and the error is passed to the ErrorHandler.
+	BeforeHandle(ctx context.Context) (int, error)
+}
+
+// AfterHandler may be implemented by a handler struct to run after a successful Handle, but before the response is processed.
+type AfterHandler interface {
+	// AfterHandle is called with the status returned by Handle and returns the status of the response.
+	// Returning an error fails the request and the error is passed to the ErrorHandler.
+	AfterHandle(ctx context.Context, httpStatus int) (int, error)
+}
+
+// OnErrorHandler may be implemented by a handler struct to observe or replace any error that occurs once the handler has been allocated.
+// Panics are not passed to OnError.
+type OnErrorHandler interface {
+	// OnError is called with the failing status and error, before the ErrorHandler.
+	// Returns the status and error that are passed to the ErrorHandler.
+	OnError(ctx context.Context, httpStatus int, err error) (int, error)
+}
@@ -0,0 +1,123 @@
+package endpoint_test
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+type hookRequest struct {
+	Name string `json:"name"`
+}
+
+type hookResponse struct {
+	Name  string   `json:"name"`
+	Hooks []string `json:"hooks"`
+}
+
+type hookEndpoint struct {
+	Mode         string        `spiderweb:"query=mode"`
+	RequestBody  *hookRequest  `spiderweb:"request,mime=application/json"`
+	ResponseBody *hookResponse `spiderweb:"response,mime=application/json"`
+
+	hooks []string
+}
+
+func (self *hookEndpoint) BeforeHandle(ctx context.Context) (int, error) {
+	self.hooks = append(self.hooks, "before")
+	if self.Mode == "reject" {
+		return httpstatus.UnprocessableEntity, errors.New("rejected")
+	}
+
+	// Normalize input.
+	self.RequestBody.Name = strings.ToLower(self.RequestBody.Name)
+
+	return httpstatus.OK, nil
+}
+
+func (self *hookEndpoint) Handle(ctx context.Context) (int, error) {
+	self.hooks = append(self.hooks, "handle")
+	if self.Mode == "fail" {
+		return httpstatus.InternalServerError, errors.New("failed")
+	}
+
+	self.ResponseBody = &hookResponse{Name: self.RequestBody.Name}
+
+	return httpstatus.OK, nil
+}
+
+func (self *hookEndpoint) AfterHandle(ctx context.Context, httpStatus int) (int, error) {
+	self.hooks = append(self.hooks, "after")
+	self.ResponseBody.Hooks = self.hooks
+
+	return httpstatus.Created, nil
+}
+
+func (self *hookEndpoint) OnError(ctx context.Context, httpStatus int, err error) (int, error) {
+	self.hooks = append(self.hooks, "error")
+
+	return httpStatus, errors.New("%s: %s", strings.Join(self.hooks, ","), err)
+}
+
+func Test_Endpoint_Lifecycle_Hooks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		mode               string
+		requestBody        string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{name: "success", requestBody: `{"name":"BOB"}`, expectedHttpStatus: httpstatus.Created, expectedBody: `{"name":"bob","hooks":["before","handle","after"]}`},
+		{name: "before error", mode: "reject", requestBody: `{"name":"BOB"}`, expectedHttpStatus: httpstatus.UnprocessableEntity, expectedBody: `{"message":"before,error: rejected"}`},
+		{name: "handle error", mode: "fail", requestBody: `{"name":"BOB"}`, expectedHttpStatus: httpstatus.InternalServerError, expectedBody: `{"message":"internal server error"}`},
+		{name: "binding error", requestBody: `{"name":`, expectedHttpStatus: httpstatus.BadRequest, expectedBody: `{"message":"error: bad request"}`},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelError),
+			}, &hookEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/hooks?mode="+testCase.mode, strings.NewReader(testCase.requestBody))
+			assert.Nil(t, err)
+
+			req.Header.Add(httpheader.ContentType, "application/json")
+
+			requester, err := endpoint.NewHttpRequester("/hooks", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+		})
+	}
+}
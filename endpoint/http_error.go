@@ -0,0 +1,192 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/i18n"
+)
+
+// HttpError is an error that carries its own HTTP status.
+// Any code may return an HttpError and the status overrides the status returned alongside it.
+//
+// Error() is the public message, which is safe to send to the client.
+// The cause is private and only formatted with "%+v" or "%#v" for logging.
+type HttpError struct {
+	// Status is the HTTP status of the response.
+	Status int
+	// Code is a machine readable code. Ex: "user_not_found"
+	Code string
+	// Message is safe to send to the client. Defaults to the text of the status.
+	Message string
+	// Cause is the internal error. It is logged but never sent to the client.
+	Cause error
+	// Details are extra public information about the error.
+	Details map[string]any
+	// Localized public message. Nil if the message is not localized.
+	Localized *i18n.Message
+}
+
+var _ i18n.Localizable = (*HttpError)(nil)
+
+// NewHttpError with a status, code, and public message.
+func NewHttpError(httpStatus int, code string, message string) *HttpError {
+	return &HttpError{
+		Status:  httpStatus,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// WithCause returns a copy of the error with the internal cause.
+func (self *HttpError) WithCause(cause error) *HttpError {
+	clone := *self
+	clone.Cause = cause
+
+	return &clone
+}
+
+// WithDetail returns a copy of the error with the detail added.
+func (self *HttpError) WithDetail(name string, value any) *HttpError {
+	clone := *self
+	clone.Details = make(map[string]any, len(self.Details)+1)
+	for detailName, detailValue := range self.Details {
+		clone.Details[detailName] = detailValue
+	}
+	clone.Details[name] = value
+
+	return &clone
+}
+
+// WithLocalizedMessage returns a copy of the error with a public message that is localized with the Catalog of the endpoint.
+func (self *HttpError) WithLocalizedMessage(message *i18n.Message) *HttpError {
+	clone := *self
+	clone.Message = message.Error()
+	clone.Localized = message
+
+	return &clone
+}
+
+func (self *HttpError) MessageKey() string {
+	if self.Localized == nil {
+		return ""
+	}
+
+	return self.Localized.Key
+}
+
+func (self *HttpError) MessageArgs() i18n.Args {
+	if self.Localized == nil {
+		return nil
+	}
+
+	return self.Localized.Args
+}
+
+func (self *HttpError) Error() string {
+	if self.Message == "" {
+		return http.StatusText(self.Status)
+	}
+
+	return self.Message
+}
+
+// Is matches any HttpError with the same status and code.
+// This allows HttpError sentinels to be compared after WithCause or WithDetail.
+func (self *HttpError) Is(target error) bool {
+	// nolint:errorlint // reason: Is is called for each error in the chain.
+	targetHttpError, ok := target.(*HttpError)
+	if !ok {
+		return false
+	}
+
+	return self.Status == targetHttpError.Status && self.Code == targetHttpError.Code
+}
+
+func (self *HttpError) Unwrap() error {
+	return self.Cause
+}
+
+func (self *HttpError) Format(state fmt.State, verb rune) {
+	fmt.Fprintf(state, "%s", self.Error())
+
+	if verb == 'v' && (state.Flag('+') || state.Flag('#')) && self.Cause != nil {
+		fmt.Fprintf(state, " -> ")
+		if formatter, ok := self.Cause.(fmt.Formatter); ok {
+			formatter.Format(state, verb)
+		} else {
+			fmt.Fprintf(state, "%s", self.Cause)
+		}
+	}
+}
+
+// ProblemExtensions are the code and details of the error.
+func (self *HttpError) ProblemExtensions() map[string]any {
+	extensions := make(map[string]any, len(self.Details)+1)
+	for name, value := range self.Details {
+		extensions[name] = value
+	}
+	if self.Code != "" {
+		extensions["code"] = self.Code
+	}
+
+	return extensions
+}
+
+// publicError returns a copy of the HttpError in the error chain without its cause, so that the cause can never be sent to the client.
+// Only an HttpError or an i18n.Message has a public message, so the message of any other error with a 5xx status is replaced by
+// the text of the status, in lowercase like the built in errors (ex: "internal server error").
+// Other errors are returned as is.
+func publicError(err error, httpStatus int) error {
+	var httpError *HttpError
+	if !errors.As(err, &httpError) {
+		if httpStatus >= http.StatusInternalServerError {
+			message := strings.ToLower(http.StatusText(httpStatus))
+
+			var localized *i18n.Message
+			if errors.As(err, &localized) {
+				message = localized.Error()
+			}
+
+			return internalError{
+				message: message,
+				err:     err,
+			}
+		}
+
+		return err
+	}
+
+	public := *httpError
+	public.Cause = nil
+
+	return &public
+}
+
+// internalError replaces the message of an error that is not safe to send to the client.
+// The error can still be matched with errors.Is and errors.As.
+type internalError struct {
+	message string
+	err     error
+}
+
+func (self internalError) Error() string {
+	return self.message
+}
+
+func (self internalError) Unwrap() error {
+	return self.err
+}
+
+// httpErrorStatus returns the status of the HttpError in the error chain, if there is one.
+func httpErrorStatus(err error, httpStatus int) int {
+	var httpError *HttpError
+	if errors.As(err, &httpError) && httpError.Status != 0 {
+		return httpError.Status
+	}
+
+	return httpStatus
+}
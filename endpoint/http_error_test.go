@@ -0,0 +1,183 @@
+package endpoint_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+var (
+	errUserNotFound = endpoint.NewHttpError(httpstatus.NotFound, "user_not_found", "user not found")
+	errDatabase     = errors.New("connection refused: postgres://admin:secret@db")
+)
+
+// findUser is library code that fails without knowing about the handler status.
+func findUser(id string) error {
+	switch id {
+	case "missing":
+		return errUserNotFound.WithCause(errors.New("no rows")).WithDetail("id", id)
+	case "broken":
+		return endpoint.NewHttpError(httpstatus.ServiceUnavailable, "database_unavailable", "").WithCause(errDatabase)
+	case "unexpected":
+		return errors.New("query failed: %v", errDatabase)
+	}
+
+	return nil
+}
+
+type httpErrorEndpoint struct {
+	Id string `spiderweb:"path=id"`
+}
+
+func (self *httpErrorEndpoint) Handle(ctx context.Context) (int, error) {
+	if err := findUser(self.Id); err != nil {
+		return httpstatus.InternalServerError, err
+	}
+
+	return httpstatus.NoContent, nil
+}
+
+func Test_HttpError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		errorHandler       endpoint.ErrorHandler
+		id                 string
+		expectedHttpStatus int
+		expectedBody       map[string]any
+	}{
+		{
+			name:               "status, code, and details",
+			id:                 "missing",
+			expectedHttpStatus: httpstatus.NotFound,
+			expectedBody: map[string]any{
+				"message": "user not found",
+				"code":    "user_not_found",
+				"details": map[string]any{"id": "missing"},
+			},
+		},
+		{
+			name:               "cause is not exposed",
+			id:                 "broken",
+			expectedHttpStatus: httpstatus.ServiceUnavailable,
+			expectedBody: map[string]any{
+				"message": "Service Unavailable",
+				"code":    "database_unavailable",
+			},
+		},
+		{
+			name:               "problem",
+			errorHandler:       endpoint.ProblemErrorHandler{},
+			id:                 "missing",
+			expectedHttpStatus: httpstatus.NotFound,
+			expectedBody: map[string]any{
+				"type":   "about:blank",
+				"title":  "Not Found",
+				"status": float64(404),
+				"detail": "user not found",
+				"code":   "user_not_found",
+				"id":     "missing",
+			},
+		},
+		{
+			name:               "problem cause is not exposed",
+			errorHandler:       endpoint.ProblemErrorHandler{},
+			id:                 "broken",
+			expectedHttpStatus: httpstatus.ServiceUnavailable,
+			expectedBody: map[string]any{
+				"type":   "about:blank",
+				"title":  "Service Unavailable",
+				"status": float64(503),
+				"detail": "Service Unavailable",
+				"code":   "database_unavailable",
+			},
+		},
+		{
+			name:               "internal error message is not exposed",
+			id:                 "unexpected",
+			expectedHttpStatus: httpstatus.InternalServerError,
+			expectedBody: map[string]any{
+				"message": "internal server error",
+			},
+		},
+		{
+			name:               "problem internal error message is not exposed",
+			errorHandler:       endpoint.ProblemErrorHandler{},
+			id:                 "unexpected",
+			expectedHttpStatus: httpstatus.InternalServerError,
+			expectedBody: map[string]any{
+				"type":   "about:blank",
+				"title":  "Internal Server Error",
+				"status": float64(500),
+				"detail": "internal server error",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelFatal))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig:    log.NewConfig().WithLevel(log.LevelFatal),
+				ErrorHandler: testCase.errorHandler,
+			}, &httpErrorEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/users/"+testCase.id, nil)
+			assert.Nil(t, err)
+
+			requester, err := endpoint.NewHttpRequester("/users/{id}", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.NotContains(t, string(responseBodyBytes), "no rows")
+			assert.NotContains(t, string(responseBodyBytes), "postgres")
+
+			responseBody := map[string]any{}
+			assert.Nil(t, json.Unmarshal(responseBodyBytes, &responseBody), string(responseBodyBytes))
+			delete(responseBody, "instance")
+			assert.Equal(t, testCase.expectedBody, responseBody)
+		})
+	}
+}
+
+func Test_HttpError_Chain(t *testing.T) {
+	t.Parallel()
+
+	err := findUser("missing")
+	assert.True(t, errors.Is(err, errUserNotFound))
+	assert.False(t, errors.Is(err, endpoint.NewHttpError(httpstatus.NotFound, "account_not_found", "")))
+
+	err = findUser("broken")
+	assert.True(t, errors.Is(err, errDatabase))
+	assert.Equal(t, "Service Unavailable", err.Error())
+	assert.Equal(t, "Service Unavailable", fmt.Sprintf("%v", err))
+	assert.True(t, strings.HasPrefix(fmt.Sprintf("%+v", err), "Service Unavailable -> connection refused: postgres://admin:secret@db"))
+}
@@ -1,11 +1,12 @@
 package endpoint
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 	"strings"
 
-	"github.com/wspowell/errors"
+	"github.com/wspowell/spiderweb/httpheader"
 )
 
 type Requester interface {
@@ -30,13 +31,29 @@ type Requester interface {
 	// Returns false if parameter not found.
 	PathParam(param string) (string, bool)
 	QueryParam(param string) ([]byte, bool)
+	// QueryParamValues returns every value of a repeated query parameter (ex: ?id=1&id=2).
+	// Returns false if parameter not found.
+	QueryParamValues(param string) ([][]byte, bool)
+	// VisitQueryParams calls f for every query parameter value.
+	// The order of different parameters is unspecified. The values of a repeated parameter are in the order they appear in the request.
+	VisitQueryParams(f func(key []byte, value []byte))
 
 	RequestBody() []byte
+	// RequestBodyStream returns the request body as a stream.
+	// This should not be mixed with RequestBody().
+	RequestBodyStream() io.Reader
 
 	SetResponseHeader(header string, value string)
+	// SetResponseCookie adds a Set-Cookie header to the response.
+	SetResponseCookie(cookie *http.Cookie)
 	SetResponseContentType(contentType string)
 	ResponseContentType() string
 	ResponseHeaders() map[string]string
+
+	// SetResponseBodyStream sets a stream to write as the response body instead of the response body bytes.
+	// If the stream is an io.Closer, it is closed once written.
+	SetResponseBodyStream(stream io.Reader)
+	ResponseBodyStream() io.Reader
 }
 
 var _ Requester = (*HttpRequester)(nil)
@@ -44,19 +61,17 @@ var _ Requester = (*HttpRequester)(nil)
 type HttpRequester struct {
 	matchedPath string
 	request     *http.Request
-	bodyBytes   []byte
+
+	bodyRead  bool
+	bodyBytes []byte
+	bodyErr   error
+
+	responseBodyStream io.Reader
 }
 
+// NewHttpRequester for the given request.
+// The request body is not read until it is needed, so streamed request bodies are never buffered.
 func NewHttpRequester(matchedPath string, request *http.Request) (*HttpRequester, error) {
-	var bodyBytes []byte
-	var err error
-	if request.Body != nil {
-		bodyBytes, err = io.ReadAll(request.Body)
-		if err != nil {
-			return nil, errors.Wrap(err, ErrInvalidBody)
-		}
-	}
-
 	request.Response = &http.Response{
 		Header: http.Header{},
 	}
@@ -64,7 +79,6 @@ func NewHttpRequester(matchedPath string, request *http.Request) (*HttpRequester
 	return &HttpRequester{
 		matchedPath: matchedPath,
 		request:     request,
-		bodyBytes:   bodyBytes,
 	}, nil
 }
 
@@ -125,14 +139,66 @@ func (self *HttpRequester) QueryParam(param string) ([]byte, bool) {
 	return []byte(value), value != ""
 }
 
+func (self *HttpRequester) QueryParamValues(param string) ([][]byte, bool) {
+	values := self.request.URL.Query()[param]
+
+	return stringsToBytes(values), len(values) != 0
+}
+
+func (self *HttpRequester) VisitQueryParams(f func(key []byte, value []byte)) {
+	for param, values := range self.request.URL.Query() {
+		for _, value := range values {
+			f([]byte(param), []byte(value))
+		}
+	}
+}
+
+// RequestBody reads the entire request body.
+// Returns nil if the body failed to be read. The failure is returned by RequestBodyError().
 func (self *HttpRequester) RequestBody() []byte {
+	if !self.bodyRead {
+		self.bodyRead = true
+
+		if self.request.Body != nil {
+			bodyBytes, err := io.ReadAll(self.request.Body)
+			if err != nil {
+				self.bodyErr = err
+
+				return nil
+			}
+			self.bodyBytes = bodyBytes
+		}
+	}
+
 	return self.bodyBytes
 }
 
+// RequestBodyError returns the error from reading the request body, if it failed.
+// Ex: the body exceeded the limit of an http.MaxBytesReader.
+func (self *HttpRequester) RequestBodyError() error {
+	return self.bodyErr
+}
+
+func (self *HttpRequester) RequestBodyStream() io.Reader {
+	if self.bodyRead {
+		return bytes.NewReader(self.bodyBytes)
+	}
+
+	if self.request.Body == nil {
+		return bytes.NewReader(nil)
+	}
+
+	return self.request.Body
+}
+
 func (self *HttpRequester) SetResponseHeader(header string, value string) {
 	self.request.Response.Header.Set(header, value)
 }
 
+func (self *HttpRequester) SetResponseCookie(cookie *http.Cookie) {
+	self.request.Response.Header.Add(httpheader.SetCookie, cookie.String())
+}
+
 func (self *HttpRequester) SetResponseContentType(contentType string) {
 	self.request.Response.Header.Set("Content-Type", contentType)
 }
@@ -149,3 +215,20 @@ func (self *HttpRequester) ResponseHeaders() map[string]string {
 
 	return headers
 }
+
+func (self *HttpRequester) SetResponseBodyStream(stream io.Reader) {
+	self.responseBodyStream = stream
+}
+
+func (self *HttpRequester) ResponseBodyStream() io.Reader {
+	return self.responseBodyStream
+}
+
+func stringsToBytes(values []string) [][]byte {
+	byteValues := make([][]byte, len(values))
+	for index, value := range values {
+		byteValues[index] = []byte(value)
+	}
+
+	return byteValues
+}
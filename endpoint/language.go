@@ -0,0 +1,30 @@
+package endpoint
+
+import (
+	"fmt"
+
+	"github.com/wspowell/context"
+
+	"github.com/wspowell/spiderweb/i18n"
+)
+
+type acceptLanguageKey struct{}
+
+// RequestLanguages returns the languages of the request Accept-Language header, in order of preference.
+func RequestLanguages(ctx context.Context) []string {
+	acceptLanguage, _ := ctx.Value(acceptLanguageKey{}).(string)
+
+	return i18n.ParseAcceptLanguage(acceptLanguage)
+}
+
+// LocalizeError returns the message of the error in the request languages using the Catalog of the endpoint.
+// Errors that are not i18n.Localizable, or that have no message in the Catalog, use the error message.
+func LocalizeError(ctx context.Context, err error) string {
+	if routeInfo, ok := RequestRoute(ctx); ok && routeInfo.Endpoint != nil && routeInfo.Endpoint.Config.Catalog != nil {
+		if message, _, ok := routeInfo.Endpoint.Config.Catalog.LocalizeError(RequestLanguages(ctx), err); ok {
+			return message
+		}
+	}
+
+	return fmt.Sprintf("%v", err)
+}
@@ -0,0 +1,98 @@
+package endpoint_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/i18n"
+)
+
+type localizedEndpoint struct {
+	Name string `spiderweb:"query=name"`
+}
+
+func (self *localizedEndpoint) Handle(ctx context.Context) (int, error) {
+	switch self.Name {
+	case "":
+		return httpstatus.BadRequest, i18n.NewMessage("name.required", "name is required", nil)
+	case "taken":
+		return httpstatus.InternalServerError, endpoint.NewHttpError(httpstatus.Conflict, "name_taken", "").
+			WithLocalizedMessage(i18n.NewMessage("name.taken", "{name} is taken", i18n.Args{"name": self.Name}))
+	}
+
+	return httpstatus.InternalServerError, i18n.NewMessage("name.unknown", "unknown error", nil)
+}
+
+func Test_Endpoint_LocalizedErrors(t *testing.T) {
+	t.Parallel()
+
+	catalog := i18n.NewCatalog("en")
+	catalog.Add("en", map[string]string{
+		"name.required": "Name is required.",
+		"name.taken":    "The name {name} is taken.",
+	})
+	catalog.Add("fr", map[string]string{
+		"name.required": "Le nom est obligatoire.",
+		"name.taken":    "Le nom {name} est déjà pris.",
+	})
+
+	testCases := []struct {
+		name               string
+		catalog            *i18n.Catalog
+		query              string
+		acceptLanguage     string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{name: "default language", catalog: catalog, query: "", expectedHttpStatus: httpstatus.BadRequest, expectedBody: `{"message":"Name is required."}`},
+		{name: "accept language", catalog: catalog, query: "", acceptLanguage: "fr-CA, en;q=0.8", expectedHttpStatus: httpstatus.BadRequest, expectedBody: `{"message":"Le nom est obligatoire."}`},
+		{name: "http error", catalog: catalog, query: "?name=taken", acceptLanguage: "fr", expectedHttpStatus: httpstatus.Conflict, expectedBody: `{"message":"Le nom taken est déjà pris.","code":"name_taken"}`},
+		{name: "not in catalog", catalog: catalog, query: "?name=other", acceptLanguage: "fr", expectedHttpStatus: httpstatus.InternalServerError, expectedBody: `{"message":"unknown error"}`},
+		{name: "no catalog", catalog: nil, query: "?name=taken", acceptLanguage: "fr", expectedHttpStatus: httpstatus.Conflict, expectedBody: `{"message":"taken is taken","code":"name_taken"}`},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelFatal))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelFatal),
+				Catalog:   testCase.catalog,
+			}, &localizedEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/names"+testCase.query, nil)
+			assert.Nil(t, err)
+			if testCase.acceptLanguage != "" {
+				req.Header.Add("Accept-Language", testCase.acceptLanguage)
+			}
+
+			requester, err := endpoint.NewHttpRequester("/names", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package endpoint
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+)
+
+const (
+	mediaTypeWildcard      = "*"
+	mediaTypeQualityParam  = "q"
+	mediaTypeListSeparator = ","
+	mediaTypeParamSep      = ";"
+)
+
+type requestMediaTypeKey struct{}
+type responseMediaTypeKey struct{}
+
+// MediaType is a parsed MIME type (RFC 7231, section 3.1.1.1).
+// The type and subtype are always lowercase. Parameter names are lowercase
+// while parameter values retain their original case.
+type MediaType struct {
+	Type    string
+	Subtype string
+	Params  map[string]string
+}
+
+// ParseMediaType parses a single media type such as "application/json; charset=utf-8".
+func ParseMediaType(value string) (MediaType, error) {
+	parts := strings.Split(value, mediaTypeParamSep)
+
+	fullType := strings.ToLower(strings.TrimSpace(parts[0]))
+	typeParts := strings.SplitN(fullType, "/", 2)
+	if len(typeParts) != 2 || typeParts[0] == "" || typeParts[1] == "" {
+		return MediaType{}, errors.New("invalid media type: %s", value)
+	}
+
+	mediaType := MediaType{
+		Type:    typeParts[0],
+		Subtype: typeParts[1],
+		Params:  map[string]string{},
+	}
+
+	for _, param := range parts[1:] {
+		param = strings.TrimSpace(param)
+		if param == "" {
+			continue
+		}
+
+		keyValue := strings.SplitN(param, "=", 2)
+		if len(keyValue) != 2 {
+			return MediaType{}, errors.New("invalid media type parameter: %s", param)
+		}
+
+		key := strings.ToLower(strings.TrimSpace(keyValue[0]))
+		paramValue := strings.Trim(strings.TrimSpace(keyValue[1]), `"`)
+		mediaType.Params[key] = paramValue
+	}
+
+	return mediaType, nil
+}
+
+// MimeType returns the "type/subtype" without any parameters.
+// This is the value used to look up a MimeTypeHandler.
+func (self MediaType) MimeType() string {
+	return self.Type + "/" + self.Subtype
+}
+
+// Param returns the value of the given parameter, if it exists.
+func (self MediaType) Param(name string) (string, bool) {
+	value, exists := self.Params[strings.ToLower(name)]
+
+	return value, exists
+}
+
+// RequestMediaType returns the parsed Content-Type of the request body, including parameters (ex: charset).
+// Returns false if the endpoint has no request body.
+func RequestMediaType(ctx context.Context) (MediaType, bool) {
+	mediaType, ok := ctx.Value(requestMediaTypeKey{}).(MediaType)
+
+	return mediaType, ok
+}
+
+// ResponseMediaType returns the media type negotiated from the Accept header, including any
+// parameters that were given on the matching media range.
+func ResponseMediaType(ctx context.Context) (MediaType, bool) {
+	mediaType, ok := ctx.Value(responseMediaTypeKey{}).(MediaType)
+
+	return mediaType, ok
+}
+
+// mediaRange is a single entry of an Accept header.
+type mediaRange struct {
+	MediaType
+	quality float64
+	order   int
+}
+
+// matches returns true if the media range includes the given MIME type.
+func (self mediaRange) matches(mimeType MediaType) bool {
+	if self.Type == mediaTypeWildcard {
+		return true
+	}
+
+	if self.Type != mimeType.Type {
+		return false
+	}
+
+	return self.Subtype == mediaTypeWildcard || self.Subtype == mimeType.Subtype
+}
+
+// specificity ranks media ranges so that more specific ranges override less specific ones.
+// "*/*" < "type/*" < "type/subtype" < "type/subtype;param=value"
+func (self mediaRange) specificity() int {
+	switch {
+	case self.Type == mediaTypeWildcard:
+		return 0
+	case self.Subtype == mediaTypeWildcard:
+		return 1
+	case len(self.Params) == 0:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// parseAccept parses an Accept header into media ranges.
+// Invalid media ranges are skipped rather than failing the entire header.
+// The returned ranges are sorted by quality then specificity, preserving header order for ties.
+func parseAccept(accept string) []mediaRange {
+	ranges := []mediaRange{}
+
+	for index, value := range strings.Split(accept, mediaTypeListSeparator) {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		mediaType, err := ParseMediaType(value)
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if qValue, exists := mediaType.Params[mediaTypeQualityParam]; exists {
+			// Parameters after "q" are accept-ext and not part of the media range.
+			delete(mediaType.Params, mediaTypeQualityParam)
+
+			parsedQuality, err := strconv.ParseFloat(qValue, 64)
+			if err != nil || parsedQuality < 0 || parsedQuality > 1 {
+				continue
+			}
+			quality = parsedQuality
+		}
+
+		ranges = append(ranges, mediaRange{
+			MediaType: mediaType,
+			quality:   quality,
+			order:     index,
+		})
+	}
+
+	sort.SliceStable(ranges, func(i int, j int) bool {
+		if ranges[i].quality != ranges[j].quality {
+			return ranges[i].quality > ranges[j].quality
+		}
+
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	return ranges
+}
+
+// negotiate the best MIME type from the candidates for the given media ranges.
+// Candidates are given in server preference order, which is used to break ties.
+// Returns the chosen candidate along with the media range it matched.
+func negotiate(ranges []mediaRange, candidates []string) (string, mediaRange, bool) {
+	var bestCandidate string
+	var bestRange mediaRange
+	bestQuality := 0.0
+
+	for _, candidate := range candidates {
+		candidateType, err := ParseMediaType(candidate)
+		if err != nil {
+			continue
+		}
+
+		// The most specific matching range determines the quality of the candidate.
+		var matchedRange mediaRange
+		matched := false
+		for _, accepted := range ranges {
+			if !accepted.matches(candidateType) {
+				continue
+			}
+
+			if !matched || accepted.specificity() > matchedRange.specificity() {
+				matchedRange = accepted
+				matched = true
+			}
+		}
+
+		if !matched || matchedRange.quality <= 0 {
+			continue
+		}
+
+		if matchedRange.quality > bestQuality ||
+			(matchedRange.quality == bestQuality && matchedRange.specificity() > bestRange.specificity()) {
+			bestCandidate = candidateType.MimeType()
+			bestRange = matchedRange
+			bestQuality = matchedRange.quality
+		}
+	}
+
+	return bestCandidate, bestRange, bestQuality > 0
+}
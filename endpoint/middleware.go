@@ -0,0 +1,62 @@
+package endpoint
+
+import (
+	"github.com/wspowell/context"
+)
+
+// Next runs the remaining middleware and the endpoint.
+// Returns the final response status and body.
+type Next func(ctx context.Context) (int, []byte)
+
+// Middleware wraps the execution of an endpoint.
+// Middleware may act on the request before calling next, act on the response after calling next,
+// or short-circuit the request by not calling next at all.
+type Middleware interface {
+	// HandleRequest and return the response status and body. The result of next is usually returned as-is.
+	// Errors returned are passed straight through to the ErrorHandler with the returned status code.
+	HandleRequest(ctx context.Context, requester Requester, next Next) (int, []byte, error)
+}
+
+// MiddlewareFunc adapts a function to a Middleware.
+type MiddlewareFunc func(ctx context.Context, requester Requester, next Next) (int, []byte, error)
+
+func (self MiddlewareFunc) HandleRequest(ctx context.Context, requester Requester, next Next) (int, []byte, error) {
+	return self(ctx, requester, next)
+}
+
+// executeMiddleware runs the middleware at index, which runs the rest of the chain when it calls next.
+func (self *Endpoint) executeMiddleware(ctx context.Context, requester Requester, index int) (int, []byte) {
+	if index == len(self.Config.Middleware) {
+		return self.execute(ctx, requester)
+	}
+
+	httpStatus, responseBody, err := self.Config.Middleware[index].HandleRequest(ctx, requester, func(ctx context.Context) (int, []byte) {
+		return self.executeMiddleware(ctx, requester, index+1)
+	})
+	if err != nil {
+		return self.processErrorResponse(ctx, requester, self.errorMimeType(requester), httpStatus, err)
+	}
+
+	return httpStatus, responseBody
+}
+
+// errorMimeType negotiates the MIME type of error responses that occur before the response MIME type is negotiated.
+// Returns nil if the Accept header is not supported, in which case errors are plain text.
+func (self *Endpoint) errorMimeType(requester Requester) *MimeTypeHandler {
+	var responseMimeType *MimeTypeHandler
+	var ok bool
+	if self.handlerData.isResponseStream {
+		var responseMediaType MediaType
+		responseMediaType, ok = NegotiateMediaType(requester.Accept(), self.handlerData.responseMimeTypes)
+		responseMimeType = self.Config.MimeTypeHandlers[responseMediaType.MimeType()]
+	} else {
+		responseMimeType, _, ok = self.Config.MimeTypeHandlers.Negotiate(requester.Accept(), self.handlerData.responseMimeTypes)
+	}
+	if !ok || responseMimeType == nil {
+		return nil
+	}
+
+	requester.SetResponseContentType(responseMimeType.MimeType)
+
+	return responseMimeType
+}
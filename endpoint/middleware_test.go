@@ -0,0 +1,120 @@
+package endpoint_test
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+type middlewareKey struct{}
+
+type middlewareResponse struct {
+	Value string `json:"value"`
+}
+
+type middlewareEndpoint struct {
+	ResponseBody *middlewareResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *middlewareEndpoint) Handle(ctx context.Context) (int, error) {
+	value, _ := ctx.Value(middlewareKey{}).(string)
+	self.ResponseBody = &middlewareResponse{Value: value}
+
+	return httpstatus.OK, nil
+}
+
+func Test_Endpoint_Middleware(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		limited            bool
+		expectedHttpStatus int
+		expectedBody       string
+		expectedCalls      []string
+	}{
+		{
+			name:               "success",
+			expectedHttpStatus: httpstatus.OK,
+			expectedBody:       `{"value":"from middleware"}`,
+			expectedCalls:      []string{"outer", "limiter", "outer 200"},
+		},
+		{
+			name:               "short-circuit",
+			limited:            true,
+			expectedHttpStatus: httpstatus.TooManyRequests,
+			expectedBody:       `{"message":"rate limited"}`,
+			expectedCalls:      []string{"outer", "limiter", "outer 429"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			var calls []string
+
+			outer := endpoint.MiddlewareFunc(func(ctx context.Context, requester endpoint.Requester, next endpoint.Next) (int, []byte, error) {
+				calls = append(calls, "outer")
+				httpStatus, responseBody := next(ctx)
+				calls = append(calls, fmt.Sprintf("outer %d", httpStatus))
+				requester.SetResponseHeader("X-Middleware", "outer")
+
+				return httpStatus, responseBody, nil
+			})
+			limiter := endpoint.MiddlewareFunc(func(ctx context.Context, requester endpoint.Requester, next endpoint.Next) (int, []byte, error) {
+				calls = append(calls, "limiter")
+				if len(requester.PeekHeader("X-Limited")) != 0 {
+					return httpstatus.TooManyRequests, nil, errors.New("rate limited")
+				}
+
+				httpStatus, responseBody := next(context.WithValue(ctx, middlewareKey{}, "from middleware"))
+
+				return httpStatus, responseBody, nil
+			})
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig:  log.NewConfig().WithLevel(log.LevelError),
+				Middleware: []endpoint.Middleware{outer, limiter},
+			}, &middlewareEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/middleware", nil)
+			assert.Nil(t, err)
+			if testCase.limited {
+				req.Header.Add("X-Limited", "true")
+			}
+
+			requester, err := endpoint.NewHttpRequester("/middleware", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+			assert.Equal(t, testCase.expectedCalls, calls)
+			assert.Equal(t, "outer", req.Response.Header.Get("X-Middleware"))
+		})
+	}
+}
@@ -2,6 +2,8 @@ package endpoint
 
 import (
 	"encoding/json"
+	"io"
+	"strings"
 )
 
 const (
@@ -9,6 +11,7 @@ const (
 
 	mimeTypeJson      = "application/json"
 	mimeTypeTextPlain = "text/plain"
+	mimeTypeAny       = "*/*"
 
 	mimeTypeSeparator = ";"
 )
@@ -16,34 +19,120 @@ const (
 type Marshaler func(v any) ([]byte, error)
 type Unmarshaler func(data []byte, v any) error
 
+// StreamUnmarshaler reads the request body as a stream and is given the request media type.
+// This is useful for MIME types that require parameters (ex: multipart boundary) or are too large to buffer.
+type StreamUnmarshaler func(body io.Reader, mediaType MediaType, v any) error
+
 type MimeTypeHandlers map[string]*MimeTypeHandler
 
+// defaultMimeTypes of endpoints without a "mime" struct tag option.
+var defaultMimeTypes = []string{mimeTypeJson}
+
+// NewMimeTypeHandlers with all built in handlers registered.
+// Endpoints without a "mime" struct tag option only use JSON. Other MIME types are opt in using the "mime" option.
 func NewMimeTypeHandlers() MimeTypeHandlers {
 	// Set default handlers.
 	return MimeTypeHandlers{
-		mimeTypeJson: JsonHandler(),
+		mimeTypeJson:              JsonHandler(),
+		mimeTypeXml:               XmlHandler(),
+		mimeTypeFormUrlEncoded:    FormHandler(),
+		mimeTypeMsgpack:           MsgpackHandler(),
+		mimeTypeCbor:              CborHandler(),
+		mimeTypeProtobuf:          ProtobufHandler(),
+		mimeTypeMultipartFormData: MultipartHandler(MultipartConfig{}),
 	}
 }
 
 // Get the MIME type handler for the request content type as well as checking that it is supported by the endpoint.
+// Any parameters on the content type (ex: "; charset=utf-8") are ignored for the lookup.
+// If there are no supported MIME types, then only JSON is allowed.
 func (m MimeTypeHandlers) Get(contentType []byte, supportedMimeTypes []string) (*MimeTypeHandler, bool) {
+	mimeType := string(contentType)
+	if index := strings.Index(mimeType, mimeTypeSeparator); index != -1 {
+		mimeType = mimeType[:index]
+	}
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+
 	// Check if the MIME type handler exists at all.
-	if handler, exists := m[string(contentType)]; exists {
-		if len(supportedMimeTypes) == 0 {
-			// If there are no supported mime types, then check against all registered handlers.
-			// This is useful when there is no response body.
+	handler, exists := m[mimeType]
+	if !exists {
+		return nil, false
+	}
+
+	if len(supportedMimeTypes) == 0 {
+		supportedMimeTypes = defaultMimeTypes
+	}
+
+	// Now check if the MIME type is in the given list of supported types.
+	for _, supportedMimeType := range supportedMimeTypes {
+		if supportedMimeType == mimeType {
 			return handler, true
 		}
+	}
+
+	return nil, false
+}
 
-		// Now check if the MIME type is in the given list of supported types.
-		for _, supportedMimeType := range supportedMimeTypes {
-			if _, exists := m[supportedMimeType]; exists {
-				return handler, true
-			}
+// Missing returns the supported MIME types that do not have a registered handler.
+func (m MimeTypeHandlers) Missing(supportedMimeTypes []string) []string {
+	missing := []string{}
+	for _, supportedMimeType := range supportedMimeTypes {
+		if _, exists := m[supportedMimeType]; !exists {
+			missing = append(missing, supportedMimeType)
 		}
 	}
 
-	return nil, false
+	return missing
+}
+
+// Negotiate the MIME type handler for the response using the request Accept header (RFC 7231, section 5.3.2).
+// Media ranges are matched using q-values, wildcards, and specificity. Only MIME types supported by the endpoint
+// are considered, in the order given, which also acts as the server preference when the client has none.
+// An empty Accept header is treated as "*/*".
+// The returned MediaType includes any parameters from the Accept media range that selected the handler.
+func (m MimeTypeHandlers) Negotiate(accept []byte, supportedMimeTypes []string) (*MimeTypeHandler, MediaType, bool) {
+	acceptValue := strings.TrimSpace(string(accept))
+	if acceptValue == "" {
+		acceptValue = mimeTypeAny
+	}
+
+	candidates := m.candidates(supportedMimeTypes)
+
+	mimeType, matchedRange, ok := negotiate(parseAccept(acceptValue), candidates)
+	if !ok {
+		return nil, MediaType{}, false
+	}
+
+	mediaType, err := ParseMediaType(mimeType)
+	if err != nil {
+		return nil, MediaType{}, false
+	}
+
+	// Only an exact match of the media range carries parameters intended for the chosen type.
+	if matchedRange.specificity() >= 2 {
+		for key, value := range matchedRange.Params {
+			mediaType.Params[key] = value
+		}
+	}
+
+	return m[mimeType], mediaType, true
+}
+
+// candidates returns the registered MIME types that may be negotiated, in preference order.
+// If there are no supported MIME types, only JSON is a candidate.
+func (m MimeTypeHandlers) candidates(supportedMimeTypes []string) []string {
+	if len(supportedMimeTypes) == 0 {
+		supportedMimeTypes = defaultMimeTypes
+	}
+
+	candidates := []string{}
+	for _, supportedMimeType := range supportedMimeTypes {
+		if _, exists := m[supportedMimeType]; exists {
+			candidates = append(candidates, supportedMimeType)
+		}
+	}
+
+	return candidates
 }
 
 // MimeTypeHandler defines how a mime type is used.
@@ -52,6 +141,8 @@ type MimeTypeHandler struct {
 	MimeType  string
 	Marshal   Marshaler
 	Unmarshal Unmarshaler
+	// UnmarshalStream is optional. If set, it is used instead of Unmarshal for request bodies.
+	UnmarshalStream StreamUnmarshaler
 }
 
 func JsonHandler() *MimeTypeHandler {
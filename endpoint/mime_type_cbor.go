@@ -0,0 +1,27 @@
+package endpoint
+
+import (
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	mimeTypeCbor = "application/cbor"
+)
+
+// CborHandler for "application/cbor" (RFC 8949).
+// Struct fields may be named using the "cbor" struct tag, falling back to the "json" struct tag.
+func CborHandler() *MimeTypeHandler {
+	return &MimeTypeHandler{
+		MimeType:  mimeTypeCbor,
+		Marshal:   cborMarshal,
+		Unmarshal: cborUnmarshal,
+	}
+}
+
+func cborMarshal(value any) ([]byte, error) {
+	return cbor.Marshal(value)
+}
+
+func cborUnmarshal(data []byte, value any) error {
+	return cbor.Unmarshal(data, value)
+}
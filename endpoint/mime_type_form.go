@@ -0,0 +1,139 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/wspowell/errors"
+)
+
+const (
+	mimeTypeFormUrlEncoded = "application/x-www-form-urlencoded"
+
+	structTagForm = "form"
+)
+
+// FormHandler for "application/x-www-form-urlencoded".
+// Form fields are bound to the request body struct using the "form" struct tag. Fields without
+// a "form" struct tag use the field name and fields tagged `form:"-"` are ignored.
+// Values are converted to the field type the same way as path and query parameters.
+func FormHandler() *MimeTypeHandler {
+	return &MimeTypeHandler{
+		MimeType:  mimeTypeFormUrlEncoded,
+		Marshal:   formMarshal,
+		Unmarshal: formUnmarshal,
+	}
+}
+
+func formMarshal(value any) ([]byte, error) {
+	if formValues, ok := value.(url.Values); ok {
+		return []byte(formValues.Encode()), nil
+	}
+
+	structValue, ok := formStruct(reflect.ValueOf(value))
+	if !ok {
+		return nil, errors.New("form value must be a struct: %T", value)
+	}
+
+	formValues := url.Values{}
+	for i := 0; i < structValue.NumField(); i++ {
+		structField := structValue.Type().Field(i)
+		name, ok := formFieldName(structField)
+		if !ok {
+			continue
+		}
+
+		fieldValue := structValue.Field(i)
+		if fieldValue.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		formValues.Set(name, fmt.Sprintf("%v", fieldValue.Interface()))
+	}
+
+	return []byte(formValues.Encode()), nil
+}
+
+func formUnmarshal(data []byte, value any) error {
+	formValues, err := url.ParseQuery(string(data))
+	if err != nil {
+		return errors.Wrap(err, ErrInvalidBody)
+	}
+
+	if valuesPtr, ok := value.(*url.Values); ok {
+		*valuesPtr = formValues
+
+		return nil
+	}
+
+	structValue, ok := formStruct(reflect.ValueOf(value))
+	if !ok {
+		return errors.New("form value must be a struct: %T", value)
+	}
+
+	for i := 0; i < structValue.NumField(); i++ {
+		structField := structValue.Type().Field(i)
+		name, ok := formFieldName(structField)
+		if !ok {
+			continue
+		}
+
+		if _, exists := formValues[name]; !exists {
+			continue
+		}
+
+		fieldValue := structValue.Field(i)
+		if fieldValue.Kind() == reflect.Ptr {
+			fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+			fieldValue = fieldValue.Elem()
+		}
+
+		if err := setValueFromString(fieldValue, formValues.Get(name)); err != nil {
+			return errors.Wrap(err, errors.New("invalid form field: %s", name))
+		}
+	}
+
+	return nil
+}
+
+// formStruct finds the settable struct through any levels of pointer indirection.
+// Nil pointers are allocated along the way.
+func formStruct(value reflect.Value) (reflect.Value, bool) {
+	for value.IsValid() && value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if !value.CanSet() {
+				return reflect.Value{}, false
+			}
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+
+	return value, value.IsValid() && value.Kind() == reflect.Struct
+}
+
+func formFieldName(structField reflect.StructField) (string, bool) {
+	if !structField.IsExported() {
+		return "", false
+	}
+
+	tagValue, exists := structField.Tag.Lookup(structTagForm)
+	if !exists {
+		return structField.Name, true
+	}
+
+	name := strings.Split(tagValue, ",")[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		return structField.Name, true
+	}
+
+	return name, true
+}
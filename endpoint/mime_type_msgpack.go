@@ -0,0 +1,27 @@
+package endpoint
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	mimeTypeMsgpack = "application/msgpack"
+)
+
+// MsgpackHandler for "application/msgpack".
+// Struct fields may be named using the "msgpack" struct tag.
+func MsgpackHandler() *MimeTypeHandler {
+	return &MimeTypeHandler{
+		MimeType:  mimeTypeMsgpack,
+		Marshal:   msgpackMarshal,
+		Unmarshal: msgpackUnmarshal,
+	}
+}
+
+func msgpackMarshal(value any) ([]byte, error) {
+	return msgpack.Marshal(value)
+}
+
+func msgpackUnmarshal(data []byte, value any) error {
+	return msgpack.Unmarshal(data, value)
+}
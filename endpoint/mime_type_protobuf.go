@@ -0,0 +1,62 @@
+package endpoint
+
+import (
+	"reflect"
+
+	"github.com/wspowell/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	mimeTypeProtobuf = "application/x-protobuf"
+)
+
+// ProtobufHandler for "application/x-protobuf".
+// Request and response bodies must be generated protobuf messages (implement proto.Message).
+func ProtobufHandler() *MimeTypeHandler {
+	return &MimeTypeHandler{
+		MimeType:  mimeTypeProtobuf,
+		Marshal:   protobufMarshal,
+		Unmarshal: protobufUnmarshal,
+	}
+}
+
+func protobufMarshal(value any) ([]byte, error) {
+	message, ok := protoMessage(reflect.ValueOf(value))
+	if !ok {
+		return nil, errors.New("value does not implement proto.Message: %T", value)
+	}
+
+	return proto.Marshal(message)
+}
+
+func protobufUnmarshal(data []byte, value any) error {
+	message, ok := protoMessage(reflect.ValueOf(value))
+	if !ok {
+		return errors.New("value does not implement proto.Message: %T", value)
+	}
+
+	return proto.Unmarshal(data, message)
+}
+
+// protoMessage finds the proto.Message through any levels of pointer indirection.
+// Body fields are given as a pointer to the struct field, so a "*Message" field arrives as "**Message".
+func protoMessage(value reflect.Value) (proto.Message, bool) {
+	for value.IsValid() {
+		if value.Kind() == reflect.Ptr && value.IsNil() {
+			return nil, false
+		}
+
+		if message, ok := value.Interface().(proto.Message); ok {
+			return message, true
+		}
+
+		if value.Kind() != reflect.Ptr {
+			return nil, false
+		}
+
+		value = value.Elem()
+	}
+
+	return nil, false
+}
@@ -0,0 +1,208 @@
+package endpoint_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/wspowell/spiderweb/endpoint"
+)
+
+func testMimeTypeHandlers() endpoint.MimeTypeHandlers {
+	mimeTypeHandlers := endpoint.NewMimeTypeHandlers()
+	mimeTypeHandlers["application/xml"] = &endpoint.MimeTypeHandler{
+		MimeType: "application/xml",
+	}
+	mimeTypeHandlers["text/html"] = &endpoint.MimeTypeHandler{
+		MimeType: "text/html",
+	}
+
+	return mimeTypeHandlers
+}
+
+func Test_MimeTypeHandlers_Get_ignores_parameters(t *testing.T) {
+	t.Parallel()
+
+	handler, ok := testMimeTypeHandlers().Get([]byte("application/json; charset=utf-8"), []string{"application/json"})
+	assert.True(t, ok)
+	assert.Equal(t, "application/json", handler.MimeType)
+}
+
+func Test_MimeTypeHandlers_Get_enforces_supported(t *testing.T) {
+	t.Parallel()
+
+	mimeTypeHandlers := testMimeTypeHandlers()
+
+	_, ok := mimeTypeHandlers.Get([]byte("application/xml"), []string{"application/json"})
+	assert.False(t, ok)
+
+	handler, ok := mimeTypeHandlers.Get([]byte("application/xml"), []string{"application/json", "application/xml"})
+	assert.True(t, ok)
+	assert.Equal(t, "application/xml", handler.MimeType)
+
+	// Only JSON is supported by default.
+	handler, ok = mimeTypeHandlers.Get([]byte("application/json"), []string{})
+	assert.True(t, ok)
+	assert.Equal(t, "application/json", handler.MimeType)
+
+	_, ok = mimeTypeHandlers.Get([]byte("text/html"), []string{})
+	assert.False(t, ok)
+
+	_, ok = mimeTypeHandlers.Get([]byte("image/png"), []string{})
+	assert.False(t, ok)
+}
+
+func Test_MimeTypeHandlers_Missing(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"image/png"}, testMimeTypeHandlers().Missing([]string{"application/json", "image/png"}))
+}
+
+func Test_MimeTypeHandlers_Negotiate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		accept    string
+		supported []string
+		expected  string
+		ok        bool
+	}{
+		{name: "exact", accept: "application/json", supported: []string{"application/json"}, expected: "application/json", ok: true},
+		{name: "empty accept", accept: "", supported: []string{"application/xml"}, expected: "application/xml", ok: true},
+		{name: "any", accept: "*/*", supported: []string{}, expected: "application/json", ok: true},
+		{name: "subtype wildcard", accept: "text/*", supported: []string{"application/json", "text/html"}, expected: "text/html", ok: true},
+		{name: "default only json", accept: "text/html, application/xml", supported: []string{}, ok: false},
+		{name: "parameters", accept: "application/json; charset=utf-8", supported: []string{"application/json"}, expected: "application/json", ok: true},
+		{name: "q-values", accept: "text/html, application/json;q=0.9", supported: []string{"application/json", "text/html"}, expected: "text/html", ok: true},
+		{name: "q-values only supported", accept: "text/html, application/json;q=0.9", supported: []string{"application/json"}, expected: "application/json", ok: true},
+		{name: "server preference on tie", accept: "application/xml, application/json", supported: []string{"application/json", "application/xml"}, expected: "application/json", ok: true},
+		{name: "specific overrides wildcard", accept: "application/*;q=0.5, application/xml;q=0", supported: []string{"application/xml", "application/json"}, expected: "application/json", ok: true},
+		{name: "q zero excludes", accept: "application/json;q=0", supported: []string{"application/json"}, ok: false},
+		{name: "unsupported", accept: "image/png", supported: []string{"application/json"}, ok: false},
+		{name: "invalid range skipped", accept: "garbage, application/json", supported: []string{"application/json"}, expected: "application/json", ok: true},
+	}
+
+	mimeTypeHandlers := testMimeTypeHandlers()
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, _, ok := mimeTypeHandlers.Negotiate([]byte(testCase.accept), testCase.supported)
+			assert.Equal(t, testCase.ok, ok)
+			if testCase.ok {
+				assert.Equal(t, testCase.expected, handler.MimeType)
+			}
+		})
+	}
+}
+
+func Test_MimeTypeHandlers_Negotiate_exposes_parameters(t *testing.T) {
+	t.Parallel()
+
+	_, mediaType, ok := testMimeTypeHandlers().Negotiate([]byte("application/json; version=2; q=0.8, */*;q=0.1"), []string{"application/json"})
+	assert.True(t, ok)
+	assert.Equal(t, "application/json", mediaType.MimeType())
+
+	version, exists := mediaType.Param("version")
+	assert.True(t, exists)
+	assert.Equal(t, "2", version)
+
+	_, exists = mediaType.Param("q")
+	assert.False(t, exists)
+}
+
+func Test_ParseMediaType(t *testing.T) {
+	t.Parallel()
+
+	mediaType, err := endpoint.ParseMediaType(`Application/JSON; Charset="UTF-8"`)
+	assert.Nil(t, err)
+	assert.Equal(t, "application", mediaType.Type)
+	assert.Equal(t, "json", mediaType.Subtype)
+
+	charset, exists := mediaType.Param("charset")
+	assert.True(t, exists)
+	assert.Equal(t, "UTF-8", charset)
+
+	_, err = endpoint.ParseMediaType("json")
+	assert.NotNil(t, err)
+}
+
+type mimeTestModel struct {
+	Name    string  `json:"name" xml:"name" msgpack:"name" form:"name"`
+	Count   int     `json:"count" xml:"count" msgpack:"count" form:"count"`
+	Enabled bool    `json:"enabled" xml:"enabled" msgpack:"enabled" form:"enabled"`
+	Ratio   float64 `json:"ratio" xml:"ratio" msgpack:"ratio" form:"ratio"`
+	Ignored string  `json:"-" xml:"-" msgpack:"-" form:"-"`
+}
+
+func Test_MimeTypeHandler_round_trip(t *testing.T) {
+	t.Parallel()
+
+	handlers := []*endpoint.MimeTypeHandler{
+		endpoint.JsonHandler(),
+		endpoint.XmlHandler(),
+		endpoint.FormHandler(),
+		endpoint.MsgpackHandler(),
+		endpoint.CborHandler(),
+	}
+
+	for _, handler := range handlers {
+		handler := handler
+		t.Run(handler.MimeType, func(t *testing.T) {
+			t.Parallel()
+
+			expected := &mimeTestModel{
+				Name:    "hello world",
+				Count:   5,
+				Enabled: true,
+				Ratio:   0.5,
+				Ignored: "ignored",
+			}
+
+			data, err := handler.Marshal(expected)
+			assert.Nil(t, err)
+
+			// Unmarshal into a pointer field, the same as an endpoint request body.
+			var actual *mimeTestModel
+			assert.Nil(t, handler.Unmarshal(data, &actual))
+
+			expected.Ignored = ""
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func Test_FormHandler_Unmarshal(t *testing.T) {
+	t.Parallel()
+
+	var actual mimeTestModel
+	err := endpoint.FormHandler().Unmarshal([]byte("name=a+b&count=3&enabled=true&Ignored=x"), &actual)
+	assert.Nil(t, err)
+	assert.Equal(t, mimeTestModel{Name: "a b", Count: 3, Enabled: true}, actual)
+
+	err = endpoint.FormHandler().Unmarshal([]byte("count=three"), &actual)
+	assert.Equal(t, "invalid form field: count", err.Error())
+	// The parse failure is kept as the cause.
+	assert.Contains(t, fmt.Sprintf("%+v", err), "could not set value (int) from string (three)")
+}
+
+func Test_ProtobufHandler_round_trip(t *testing.T) {
+	t.Parallel()
+
+	handler := endpoint.ProtobufHandler()
+
+	data, err := handler.Marshal(wrapperspb.String("hello"))
+	assert.Nil(t, err)
+
+	actual := &wrapperspb.StringValue{}
+	assert.Nil(t, handler.Unmarshal(data, &actual))
+	assert.Equal(t, "hello", actual.GetValue())
+
+	_, err = handler.Marshal(&mimeTestModel{})
+	assert.NotNil(t, err)
+}
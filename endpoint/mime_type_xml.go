@@ -0,0 +1,26 @@
+package endpoint
+
+import (
+	"encoding/xml"
+)
+
+const (
+	mimeTypeXml = "application/xml"
+)
+
+// XmlHandler for "application/xml" using encoding/xml.
+func XmlHandler() *MimeTypeHandler {
+	return &MimeTypeHandler{
+		MimeType:  mimeTypeXml,
+		Marshal:   xmlMarshal,
+		Unmarshal: xmlUnmarshal,
+	}
+}
+
+func xmlMarshal(value any) ([]byte, error) {
+	return xml.Marshal(value)
+}
+
+func xmlUnmarshal(data []byte, value any) error {
+	return xml.Unmarshal(data, value)
+}
@@ -0,0 +1,227 @@
+package endpoint
+
+import (
+	"io"
+	"mime/multipart"
+	"reflect"
+
+	"github.com/wspowell/errors"
+)
+
+const (
+	mimeTypeMultipartFormData = "multipart/form-data"
+
+	structTagFile = "file"
+
+	multipartBoundaryParam = "boundary"
+
+	defaultMultipartMaxMemory = 32 << 20 // 32 MB
+)
+
+var (
+	ErrMultipartPartTooLarge = errors.New("multipart part too large")
+)
+
+// nolint:gochecknoglobals // reason: cached reflection types
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader{})
+	multipartFileType   = reflect.TypeOf((*multipart.File)(nil)).Elem()
+)
+
+// MultipartConfig limits how multipart/form-data request bodies are read.
+type MultipartConfig struct {
+	// MaxMemory is the total number of bytes of file parts kept in memory.
+	// File parts beyond this threshold are stored in temporary files on disk.
+	// Defaults to 32MB.
+	MaxMemory int64
+	// MaxPartSize is the maximum number of bytes allowed for any one file part.
+	// A larger part is rejected with 413 Request Entity Too Large as soon as the limit is read.
+	// Defaults to no limit. The request body size is still limited by the server.
+	MaxPartSize int64
+}
+
+// MultipartForm is a parsed multipart/form-data request body.
+type MultipartForm struct {
+	*multipart.Form
+}
+
+// Bind the form values and files into the given struct using the "form" struct tag.
+// Fields of type *multipart.FileHeader or []*multipart.FileHeader are bound to file parts.
+// All other fields are bound to value parts the same way as FormHandler.
+func (self *MultipartForm) Bind(value any) error {
+	structValue, ok := formStruct(reflect.ValueOf(value))
+	if !ok {
+		return errors.New("form value must be a struct: %T", value)
+	}
+
+	for i := 0; i < structValue.NumField(); i++ {
+		structField := structValue.Type().Field(i)
+		name, ok := formFieldName(structField)
+		if !ok {
+			continue
+		}
+
+		fieldValue := structValue.Field(i)
+
+		switch structField.Type {
+		case fileHeaderType:
+			if files := self.File[name]; len(files) != 0 {
+				fieldValue.Set(reflect.ValueOf(files[0]))
+			}
+
+			continue
+		case fileHeaderSliceType:
+			if files := self.File[name]; len(files) != 0 {
+				fieldValue.Set(reflect.ValueOf(files))
+			}
+
+			continue
+		}
+
+		values, exists := self.Value[name]
+		if !exists || len(values) == 0 {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Ptr {
+			fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+			fieldValue = fieldValue.Elem()
+		}
+
+		if err := setValueFromString(fieldValue, values[0]); err != nil {
+			return errors.Wrap(err, errors.New("invalid form field: %s", name))
+		}
+	}
+
+	return nil
+}
+
+// RemoveAll removes any temporary files associated with the form.
+func (self *MultipartForm) RemoveAll() {
+	if self.Form != nil {
+		// nolint:errcheck // reason: best effort cleanup of temporary files
+		self.Form.RemoveAll()
+	}
+}
+
+// MultipartHandler for "multipart/form-data".
+// Multipart bodies are always read as a stream so that large file parts can be stored on disk rather than in memory.
+// Form values are bound to the request body using the "form" struct tag and file parts are bound to
+// handler fields using the "file=<name>" struct tag option.
+func MultipartHandler(config MultipartConfig) *MimeTypeHandler {
+	if config.MaxMemory == 0 {
+		config.MaxMemory = defaultMultipartMaxMemory
+	}
+
+	return &MimeTypeHandler{
+		MimeType:  mimeTypeMultipartFormData,
+		Marshal:   multipartMarshal,
+		Unmarshal: multipartUnmarshal,
+		UnmarshalStream: func(body io.Reader, mediaType MediaType, value any) error {
+			return multipartUnmarshalStream(config, body, mediaType, value)
+		},
+	}
+}
+
+func multipartMarshal(value any) ([]byte, error) {
+	return nil, errors.New("multipart/form-data response bodies are not supported")
+}
+
+func multipartUnmarshal(data []byte, value any) error {
+	return errors.New("multipart/form-data requires a boundary")
+}
+
+func multipartUnmarshalStream(config MultipartConfig, body io.Reader, mediaType MediaType, value any) error {
+	form, ok := value.(*MultipartForm)
+	if !ok {
+		return errors.New("multipart/form-data must be read into a *MultipartForm: %T", value)
+	}
+
+	boundary, exists := mediaType.Param(multipartBoundaryParam)
+	if !exists || boundary == "" {
+		return errors.New("multipart/form-data boundary not provided")
+	}
+
+	reader := multipart.NewReader(body, boundary)
+	if config.MaxPartSize > 0 {
+		limitedParts := limitFileParts(reader, boundary, config.MaxPartSize)
+		// Stops limitFileParts if ReadForm fails first.
+		defer limitedParts.Close()
+
+		reader = multipart.NewReader(limitedParts, boundary)
+	}
+
+	multipartForm, err := reader.ReadForm(config.MaxMemory)
+	if err != nil {
+		if errors.Is(err, ErrMultipartPartTooLarge) {
+			return err
+		}
+
+		return errors.Wrap(err, ErrInvalidBody)
+	}
+	form.Form = multipartForm
+
+	return nil
+}
+
+// limitFileParts copies the parts of the multipart body and fails as soon as a file part is larger than maxPartSize.
+// Parts are checked while they are read, so an oversized part is never read into memory or stored on disk in full.
+func limitFileParts(reader *multipart.Reader, boundary string, maxPartSize int64) *io.PipeReader {
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		pipeWriter.CloseWithError(copyLimitedParts(reader, boundary, maxPartSize, pipeWriter))
+	}()
+
+	return pipeReader
+}
+
+func copyLimitedParts(reader *multipart.Reader, boundary string, maxPartSize int64, writer io.Writer) error {
+	multipartWriter := multipart.NewWriter(writer)
+	if err := multipartWriter.SetBoundary(boundary); err != nil {
+		return err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return multipartWriter.Close()
+		}
+		if err != nil {
+			return err
+		}
+
+		partWriter, err := multipartWriter.CreatePart(part.Header)
+		if err != nil {
+			return err
+		}
+
+		if part.FileName() == "" {
+			// Value parts are limited by ReadForm.
+			if _, err := io.Copy(partWriter, part); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		written, err := io.Copy(partWriter, io.LimitReader(part, maxPartSize+1))
+		if err != nil {
+			return err
+		}
+		if written > maxPartSize {
+			return errors.Wrap(errors.New("multipart part too large: %s", part.FormName()), ErrMultipartPartTooLarge)
+		}
+	}
+}
+
+// validateFileField checks that a "file=<name>" field has a type that can be bound to a file part.
+func validateFileField(structField reflect.StructField) error {
+	switch structField.Type {
+	case fileHeaderType, fileHeaderSliceType, multipartFileType, readerType:
+		return nil
+	}
+
+	return errors.New("file field %s must be one of *multipart.FileHeader, []*multipart.FileHeader, multipart.File, io.Reader", structField.Name)
+}
@@ -0,0 +1,239 @@
+package endpoint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/httpheader"
+)
+
+const redactedHeaderValue = "[REDACTED]"
+
+// sensitiveHeaders are never included in a PanicReport.
+var sensitiveHeaders = map[string]bool{
+	strings.ToLower(httpheader.Authorization):      true,
+	strings.ToLower(httpheader.ProxyAuthorization): true,
+	strings.ToLower(httpheader.Cookie):             true,
+	"x-api-key":                                    true,
+}
+
+// sensitiveHeaderWords redact any header whose name contains one of them.
+var sensitiveHeaderWords = []string{"token", "secret", "password", "signature"}
+
+// PanicReport of a panic recovered while executing an endpoint.
+type PanicReport struct {
+	Time time.Time
+	// Value passed to panic().
+	Value any
+	// Stack of the goroutine that panicked.
+	Stack     []byte
+	RequestId string
+	Method    string
+	// Route is the matched route path. Ex: /some/path/{id}
+	Route string
+	Path  string
+	// Headers of the request. Credentials are redacted.
+	Headers map[string]string
+	// Handler is the name of the handler struct.
+	Handler string
+	// Dropped is the number of panics that were not reported before this one, such as by a RateLimitedPanicReporter.
+	Dropped int
+}
+
+// PanicReporter receives panics recovered while executing an endpoint, after they are logged.
+// Ex: forward panics to a crash reporting service.
+// The request still receives a 500 response.
+type PanicReporter interface {
+	ReportPanic(ctx context.Context, report *PanicReport)
+}
+
+func (self *Endpoint) reportPanic(ctx context.Context, requester Requester, value any) {
+	if self.Config.PanicReporter == nil {
+		return
+	}
+
+	report := &PanicReport{
+		Time:      time.Now(),
+		Value:     value,
+		Stack:     debug.Stack(),
+		RequestId: requester.RequestId(),
+		Method:    string(requester.Method()),
+		Route:     requester.MatchedPath(),
+		Path:      string(requester.Path()),
+		Headers:   sanitizedHeaders(requester),
+		Handler:   self.Name(),
+	}
+
+	// A failing reporter must not prevent the error response.
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Error(ctx, "panic reporter panicked: %v", recovered)
+		}
+	}()
+
+	self.Config.PanicReporter.ReportPanic(ctx, report)
+}
+
+func sanitizedHeaders(requester Requester) map[string]string {
+	headers := map[string]string{}
+	requester.VisitHeaders(func(key []byte, value []byte) {
+		name := string(key)
+		if isSensitiveHeader(name) {
+			headers[name] = redactedHeaderValue
+		} else {
+			headers[name] = string(value)
+		}
+	})
+
+	return headers
+}
+
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	if sensitiveHeaders[name] {
+		return true
+	}
+	for _, word := range sensitiveHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RateLimitedPanicReporter reports at most a number of panics per interval so that a panic storm does not flood the reporter.
+// Panics over the limit are dropped and counted in the next report.
+type RateLimitedPanicReporter struct {
+	reporter PanicReporter
+	limit    int
+	interval time.Duration
+	now      func() time.Time
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	reported    int
+	dropped     int
+}
+
+var _ PanicReporter = (*RateLimitedPanicReporter)(nil)
+
+// NewRateLimitedPanicReporter that reports at most limit panics per interval.
+// A limit or interval that is not positive means there is no limit, so every panic is reported.
+func NewRateLimitedPanicReporter(reporter PanicReporter, limit int, interval time.Duration) *RateLimitedPanicReporter {
+	return &RateLimitedPanicReporter{
+		reporter: reporter,
+		limit:    limit,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+func (self *RateLimitedPanicReporter) ReportPanic(ctx context.Context, report *PanicReport) {
+	if self.limit <= 0 || self.interval <= 0 {
+		self.reporter.ReportPanic(ctx, report)
+
+		return
+	}
+
+	self.mutex.Lock()
+	now := self.now()
+	if now.Sub(self.windowStart) >= self.interval {
+		self.windowStart = now
+		self.reported = 0
+	}
+	if self.reported >= self.limit {
+		self.dropped++
+		self.mutex.Unlock()
+
+		return
+	}
+	self.reported++
+	report.Dropped += self.dropped
+	self.dropped = 0
+	self.mutex.Unlock()
+
+	self.reporter.ReportPanic(ctx, report)
+}
+
+// CrashDumpReporter writes each panic to a file in a directory.
+type CrashDumpReporter struct {
+	dir string
+}
+
+var _ PanicReporter = (*CrashDumpReporter)(nil)
+
+// NewCrashDumpReporter that writes crash dumps to the directory, which is created if it does not exist.
+func NewCrashDumpReporter(dir string) *CrashDumpReporter {
+	return &CrashDumpReporter{
+		dir: dir,
+	}
+}
+
+func (self *CrashDumpReporter) ReportPanic(ctx context.Context, report *PanicReport) {
+	if err := os.MkdirAll(self.dir, 0o755); err != nil {
+		log.Error(ctx, "failed to create crash dump directory: %v", err)
+
+		return
+	}
+
+	fileName := fmt.Sprintf("panic-%s-%s.txt", report.Time.UTC().Format("20060102T150405.000000000Z"), safeFileName(report.RequestId))
+	filePath := filepath.Join(self.dir, fileName)
+	if err := os.WriteFile(filePath, formatCrashDump(report), 0o600); err != nil {
+		log.Error(ctx, "failed to write crash dump: %v", err)
+
+		return
+	}
+
+	log.Error(ctx, "crash dump written: %s", filePath)
+}
+
+func formatCrashDump(report *PanicReport) []byte {
+	dump := strings.Builder{}
+	fmt.Fprintf(&dump, "time: %s\n", report.Time.UTC().Format(time.RFC3339Nano))
+	fmt.Fprintf(&dump, "request_id: %s\n", report.RequestId)
+	fmt.Fprintf(&dump, "handler: %s\n", report.Handler)
+	fmt.Fprintf(&dump, "method: %s\n", report.Method)
+	fmt.Fprintf(&dump, "route: %s\n", report.Route)
+	fmt.Fprintf(&dump, "path: %s\n", report.Path)
+	if report.Dropped != 0 {
+		fmt.Fprintf(&dump, "dropped: %d\n", report.Dropped)
+	}
+	fmt.Fprintf(&dump, "panic: %v\n", report.Value)
+
+	names := make([]string, 0, len(report.Headers))
+	for name := range report.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dump.WriteString("\nheaders:\n")
+	for _, name := range names {
+		fmt.Fprintf(&dump, "  %s: %s\n", name, report.Headers[name])
+	}
+
+	dump.WriteString("\nstack:\n")
+	dump.Write(report.Stack)
+
+	return []byte(dump.String())
+}
+
+// safeFileName replaces characters that are not safe in file names.
+func safeFileName(value string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, value)
+}
@@ -0,0 +1,167 @@
+package endpoint_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+type recordingPanicReporter struct {
+	mutex   sync.Mutex
+	reports []*endpoint.PanicReport
+}
+
+func (self *recordingPanicReporter) ReportPanic(ctx context.Context, report *endpoint.PanicReport) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.reports = append(self.reports, report)
+}
+
+type panicEndpoint struct{}
+
+func (self *panicEndpoint) Handle(ctx context.Context) (int, error) {
+	panic("nil map")
+}
+
+func executePanicEndpoint(t *testing.T, reporter endpoint.PanicReporter) (int, string, *endpoint.HttpRequester) {
+	t.Helper()
+
+	ctx := context.Background()
+	ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelFatal))
+
+	testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+		LogConfig:     log.NewConfig().WithLevel(log.LevelFatal),
+		PanicReporter: reporter,
+	}, &panicEndpoint{})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/orders/42", nil)
+	assert.Nil(t, err)
+	req.Header.Add("Authorization", "Bearer secret")
+	req.Header.Add("X-Auth-Token", "secret")
+	req.Header.Add("Cookie", "session=secret")
+	req.Header.Add("Accept", "application/json")
+
+	requester, err := endpoint.NewHttpRequester("/orders/{id}", req)
+	assert.Nil(t, err)
+
+	var httpStatus int
+	var responseBodyBytes []byte
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+	}()
+	wg.Wait()
+
+	return httpStatus, string(responseBodyBytes), requester
+}
+
+func Test_Endpoint_PanicReporter(t *testing.T) {
+	t.Parallel()
+
+	reporter := &recordingPanicReporter{}
+
+	httpStatus, responseBody, requester := executePanicEndpoint(t, reporter)
+	assert.Equal(t, httpstatus.InternalServerError, httpStatus)
+	assert.Equal(t, `{"message":"internal server error"}`, responseBody)
+
+	assert.Len(t, reporter.reports, 1)
+	report := reporter.reports[0]
+	assert.Equal(t, "nil map", report.Value)
+	assert.Equal(t, requester.RequestId(), report.RequestId)
+	assert.Equal(t, http.MethodPost, report.Method)
+	assert.Equal(t, "/orders/{id}", report.Route)
+	assert.Equal(t, "/orders/42", report.Path)
+	assert.Equal(t, "panicEndpoint", report.Handler)
+	assert.Contains(t, string(report.Stack), "panicEndpoint).Handle")
+	assert.Equal(t, map[string]string{
+		"Authorization": "[REDACTED]",
+		"X-Auth-Token":  "[REDACTED]",
+		"Cookie":        "[REDACTED]",
+		"Accept":        "application/json",
+	}, report.Headers)
+}
+
+func Test_RateLimitedPanicReporter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	reporter := &recordingPanicReporter{}
+	rateLimited := endpoint.NewRateLimitedPanicReporter(reporter, 2, 50*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		rateLimited.ReportPanic(ctx, &endpoint.PanicReport{})
+	}
+	assert.Len(t, reporter.reports, 2)
+
+	time.Sleep(60 * time.Millisecond)
+
+	rateLimited.ReportPanic(ctx, &endpoint.PanicReport{})
+	assert.Len(t, reporter.reports, 3)
+	assert.Equal(t, 0, reporter.reports[0].Dropped)
+	assert.Equal(t, 3, reporter.reports[2].Dropped)
+}
+
+func Test_RateLimitedPanicReporter_No_Limit(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		limit    int
+		interval time.Duration
+	}{
+		{name: "zero limit", limit: 0, interval: time.Minute},
+		{name: "negative limit", limit: -1, interval: time.Minute},
+		{name: "zero interval", limit: 1, interval: 0},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := &recordingPanicReporter{}
+			rateLimited := endpoint.NewRateLimitedPanicReporter(reporter, testCase.limit, testCase.interval)
+
+			for i := 0; i < 3; i++ {
+				rateLimited.ReportPanic(context.Background(), &endpoint.PanicReport{})
+			}
+			assert.Len(t, reporter.reports, 3)
+		})
+	}
+}
+
+func Test_CrashDumpReporter(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "crashes")
+
+	httpStatus, _, requester := executePanicEndpoint(t, endpoint.NewCrashDumpReporter(dir))
+	assert.Equal(t, httpstatus.InternalServerError, httpStatus)
+
+	dumps, err := filepath.Glob(filepath.Join(dir, "panic-*.txt"))
+	assert.Nil(t, err)
+	assert.Len(t, dumps, 1)
+
+	dump, err := os.ReadFile(dumps[0])
+	assert.Nil(t, err)
+	assert.Contains(t, string(dump), "request_id: "+requester.RequestId()+"\n")
+	assert.Contains(t, string(dump), "route: /orders/{id}\n")
+	assert.Contains(t, string(dump), "panic: nil map\n")
+	assert.Contains(t, string(dump), "  Authorization: [REDACTED]\n")
+	assert.Contains(t, string(dump), "panicEndpoint).Handle")
+	assert.NotContains(t, string(dump), "secret")
+}
@@ -0,0 +1,368 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/httpheader"
+)
+
+const (
+	structTagDefault = "default"
+	structTagMin     = "min"
+	structTagMax     = "max"
+	structTagEnum    = "enum"
+	structTagPattern = "pattern"
+
+	enumValueSeparator = "|"
+
+	ParameterInPath   = "path"
+	ParameterInQuery  = "query"
+	ParameterInHeader = "header"
+	ParameterInCookie = "cookie"
+)
+
+// ParameterError describes a path, query, header, or cookie value that could not be bound to the handler.
+type ParameterError struct {
+	In      string `json:"in" xml:"in"`
+	Name    string `json:"name" xml:"name"`
+	Message string `json:"message" xml:"message"`
+}
+
+// ParameterErrors lists every parameter that failed to bind.
+// ParameterErrors is an ErrBadRequest.
+type ParameterErrors []ParameterError
+
+func (self ParameterErrors) Error() string {
+	messages := make([]string, len(self))
+	for index, parameterError := range self {
+		messages[index] = fmt.Sprintf("%s parameter '%s' %s", parameterError.In, parameterError.Name, parameterError.Message)
+	}
+
+	return ErrBadRequest.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (self ParameterErrors) Is(target error) bool {
+	return target == ErrBadRequest
+}
+
+// parameterTypeData is a path, query, header, or cookie parameter bound to a handler field.
+type parameterTypeData struct {
+	in       string
+	name     string
+	fieldNum int
+
+	isRequired   bool
+	hasDefault   bool
+	defaultValue string
+	enum         []string
+	pattern      *regexp.Regexp
+	min          *float64
+	max          *float64
+	isDuration   bool
+}
+
+// newParameterTypeData parses the parameter options from the struct tag parts.
+// Options may be given in any order: "required", "default=<value>", "min=<n>", "max=<n>", "enum=<a|b>", "pattern=<regexp>".
+// Invalid options are fatal since the endpoint can never work as intended.
+func newParameterTypeData(ctx context.Context, in string, name string, fieldNum int, structField reflect.StructField, tagValueParts []string) parameterTypeData {
+	parameter := parameterTypeData{
+		in:       in,
+		name:     name,
+		fieldNum: fieldNum,
+		// Path parameters are always part of the matched route.
+		isRequired: in == ParameterInPath,
+	}
+
+	for _, tagValuePart := range tagValueParts {
+		optionParts := strings.SplitN(tagValuePart, "=", 2)
+		option := optionParts[0]
+		var optionValue string
+		if len(optionParts) == 2 {
+			optionValue = optionParts[1]
+		}
+
+		switch option {
+		case tagValueRequired:
+			parameter.isRequired = true
+		case structTagDefault:
+			parameter.hasDefault = true
+			parameter.defaultValue = optionValue
+		case structTagEnum:
+			parameter.enum = strings.Split(optionValue, enumValueSeparator)
+		case structTagPattern:
+			pattern, err := regexp.Compile("^(?:" + optionValue + ")$")
+			if err != nil {
+				log.Fatal(ctx, "invalid struct tag value for '%s' on %s: %v", structTagPattern, structField.Name, err)
+			}
+			parameter.pattern = pattern
+		case structTagMin, structTagMax:
+			parameter.isDuration = baseType(structField.Type) == durationType
+			limit, err := parseLimit(structField.Type, optionValue)
+			if err != nil {
+				log.Fatal(ctx, "invalid struct tag value for '%s' on %s: %v", option, structField.Name, err)
+			}
+			if option == structTagMin {
+				parameter.min = &limit
+			} else {
+				parameter.max = &limit
+			}
+		}
+	}
+
+	if parameter.isRequired && parameter.hasDefault && in != ParameterInPath {
+		log.Fatal(ctx, "%s parameter '%s' cannot be both required and have a default", in, name)
+	}
+
+	if parameter.hasDefault {
+		// The default must be valid, otherwise every request without the parameter fails.
+		defaultValue := reflect.New(structField.Type).Elem()
+		if message, ok := parameter.set(defaultValue, []string{parameter.defaultValue}, true); !ok {
+			log.Fatal(ctx, "invalid default for %s parameter '%s': %s", in, name, message)
+		}
+	}
+
+	return parameter
+}
+
+// parseLimit for a min or max option.
+// Numbers are limited by value, durations by duration (ex: "1s"), and strings by length.
+func parseLimit(fieldType reflect.Type, value string) (float64, error) {
+	fieldType = baseType(fieldType)
+
+	if fieldType == durationType {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, errors.New("invalid duration: %s", value)
+		}
+
+		return float64(duration), nil
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		limit, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, errors.New("invalid number: %s", value)
+		}
+
+		return limit, nil
+	}
+
+	return 0, errors.New("min and max are only supported on numbers, durations, and strings, got %s", fieldType)
+}
+
+// set the parameter values into the field and validate them.
+// Returns a message describing the failure if the values could not be set.
+func (self parameterTypeData) set(fieldValue reflect.Value, values []string, exists bool) (string, bool) {
+	if !fieldValue.CanSet() {
+		return "cannot be set", false
+	}
+
+	if !exists {
+		if self.isRequired {
+			return "is required", false
+		}
+		if !self.hasDefault {
+			// Parameter not required. Leave the value at the zero value.
+			return "", true
+		}
+		values = []string{self.defaultValue}
+	}
+
+	for _, value := range values {
+		for _, item := range parameterItems(fieldValue.Type(), value) {
+			if len(self.enum) != 0 && !containsString(self.enum, item) {
+				return fmt.Sprintf("must be one of [%s]", strings.Join(self.enum, ", ")), false
+			}
+			if self.pattern != nil && !self.pattern.MatchString(item) {
+				return fmt.Sprintf("must match pattern %s", self.pattern), false
+			}
+		}
+	}
+
+	if err := setValuesFromStrings(fieldValue, values); err != nil {
+		return fmt.Sprintf("has invalid value '%s'", strings.Join(values, ",")), false
+	}
+
+	return self.checkLimits(fieldValue)
+}
+
+// checkLimits validates min and max against the bound value.
+func (self parameterTypeData) checkLimits(value reflect.Value) (string, bool) {
+	if self.min == nil && self.max == nil {
+		return "", true
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return "", true
+		}
+
+		return self.checkLimits(value.Elem())
+	case reflect.Slice:
+		for index := 0; index < value.Len(); index++ {
+			if message, ok := self.checkLimits(value.Index(index)); !ok {
+				return message, false
+			}
+		}
+
+		return "", true
+	case reflect.String:
+		length := utf8.RuneCountInString(value.String())
+
+		return self.checkLimit("length", float64(length), strconv.Itoa(length))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() == durationType {
+			return self.checkLimit("", float64(value.Int()), time.Duration(value.Int()).String())
+		}
+
+		return self.checkLimit("", float64(value.Int()), strconv.FormatInt(value.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return self.checkLimit("", float64(value.Uint()), strconv.FormatUint(value.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		return self.checkLimit("", value.Float(), strconv.FormatFloat(value.Float(), 'f', -1, 64))
+	}
+
+	return "", true
+}
+
+func (self parameterTypeData) checkLimit(measure string, value float64, display string) (string, bool) {
+	prefix := "must be"
+	if measure != "" {
+		prefix = measure + " must be"
+	}
+
+	if self.min != nil && value < *self.min {
+		return fmt.Sprintf("%s at least %s, got %s", prefix, self.formatLimit(*self.min, measure), display), false
+	}
+	if self.max != nil && value > *self.max {
+		return fmt.Sprintf("%s at most %s, got %s", prefix, self.formatLimit(*self.max, measure), display), false
+	}
+
+	return "", true
+}
+
+func (self parameterTypeData) formatLimit(limit float64, measure string) string {
+	if measure == "" && self.isDuration {
+		return time.Duration(limit).String()
+	}
+
+	return strconv.FormatFloat(limit, 'f', -1, 64)
+}
+
+// baseType of a parameter, dereferencing pointers and slices of values.
+func baseType(fieldType reflect.Type) reflect.Type {
+	for fieldType.Kind() == reflect.Ptr || (fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() != reflect.Uint8) {
+		fieldType = fieldType.Elem()
+	}
+
+	return fieldType
+}
+
+// parameterItems splits a raw value into the items checked by enum and pattern.
+// Slice fields are checked per comma separated item, the same way they are bound.
+func parameterItems(fieldType reflect.Type, value string) []string {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	if fieldType.Kind() != reflect.Slice || fieldType.Elem().Kind() == reflect.Uint8 || reflect.PtrTo(fieldType).Implements(textUnmarshalerType) {
+		return []string{value}
+	}
+
+	items := strings.Split(value, sliceValueSeparator)
+	for index := range items {
+		items[index] = strings.TrimSpace(items[index])
+	}
+
+	return items
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lookup the parameter values from the request.
+func (self parameterTypeData) lookup(requester Requester, cookies *http.Request) ([]string, bool) {
+	switch self.in {
+	case ParameterInPath:
+		value, ok := requester.PathParam(self.name)
+
+		return []string{value}, ok
+	case ParameterInQuery:
+		queryValues, ok := requester.QueryParamValues(self.name)
+		values := make([]string, len(queryValues))
+		for index, queryBytes := range queryValues {
+			values[index] = string(queryBytes)
+		}
+
+		return values, ok
+	case ParameterInHeader:
+		headerBytes := requester.PeekHeader(self.name)
+
+		return []string{string(headerBytes)}, len(headerBytes) != 0
+	case ParameterInCookie:
+		cookie, err := cookies.Cookie(self.name)
+		if err != nil {
+			return nil, false
+		}
+
+		return []string{cookie.Value}, true
+	}
+
+	return nil, false
+}
+
+// setParameters binds every path, query, header, and cookie parameter.
+// All parameters are bound so that every failure is reported at once.
+func (self handlerTypeData) setParameters(handlerValue reflect.Value, requester Requester) error {
+	if len(self.parameters) == 0 {
+		return nil
+	}
+
+	// Parse the Cookie header using net/http so that cookie values are handled the same as the standard library.
+	cookies := &http.Request{
+		Header: http.Header{
+			httpheader.Cookie: []string{string(requester.PeekHeader(httpheader.Cookie))},
+		},
+	}
+
+	parameterErrors := ParameterErrors{}
+	for _, parameter := range self.parameters {
+		values, exists := parameter.lookup(requester, cookies)
+		if message, ok := parameter.set(handlerValue.Elem().Field(parameter.fieldNum), values, exists); !ok {
+			parameterErrors = append(parameterErrors, ParameterError{
+				In:      parameter.in,
+				Name:    parameter.name,
+				Message: message,
+			})
+		}
+	}
+
+	if len(parameterErrors) != 0 {
+		return parameterErrors
+	}
+
+	return nil
+}
@@ -0,0 +1,357 @@
+package endpoint
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"unicode"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+)
+
+const (
+	mimeTypeProblemJson = "application/problem+json"
+	mimeTypeProblemXml  = "application/problem+xml"
+
+	// problemXmlNamespace of XML problem details (RFC 7807 Appendix A).
+	problemXmlNamespace = "urn:ietf:rfc:7807"
+
+	defaultProblemTypeBaseUri = "urn:spiderweb:problem:"
+)
+
+// ErrorContentTyper may be implemented by an ErrorHandler to set the Content-Type of error responses.
+type ErrorContentTyper interface {
+	// ErrorContentType returns the Content-Type of an error response that is marshaled with the negotiated MIME type.
+	ErrorContentType(accept []byte, mimeType string) string
+}
+
+// ProblemExtender may be implemented by errors to add extension members to a Problem.
+type ProblemExtender interface {
+	ProblemExtensions() map[string]any
+}
+
+// Problem details of an error response (RFC 7807).
+type Problem struct {
+	Type     string `json:"type,omitempty" xml:"type,omitempty"`
+	Title    string `json:"title,omitempty" xml:"title,omitempty"`
+	Status   int    `json:"status,omitempty" xml:"status,omitempty"`
+	Detail   string `json:"detail,omitempty" xml:"detail,omitempty"`
+	Instance string `json:"instance,omitempty" xml:"instance,omitempty"`
+	// Extensions are additional members of the problem. Members that collide with the standard members are ignored.
+	Extensions map[string]any `json:"-" xml:"-"`
+}
+
+type problemMembers Problem
+
+func (self Problem) MarshalJSON() ([]byte, error) {
+	standardBytes, err := json.Marshal(problemMembers(self))
+	if err != nil {
+		return nil, err
+	}
+	if len(self.Extensions) == 0 {
+		return standardBytes, nil
+	}
+
+	members := map[string]any{}
+	for name, value := range self.Extensions {
+		members[name] = value
+	}
+	standard := map[string]json.RawMessage{}
+	if err := json.Unmarshal(standardBytes, &standard); err != nil {
+		return nil, err
+	}
+	for name, value := range standard {
+		members[name] = value
+	}
+
+	return json.Marshal(members)
+}
+
+func (self *Problem) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*problemMembers)(self)); err != nil {
+		return err
+	}
+
+	members := map[string]any{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	for _, name := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(members, name)
+	}
+	if len(members) != 0 {
+		self.Extensions = members
+	}
+
+	return nil
+}
+
+// MarshalXML as a "problem" element (RFC 7807 Appendix A).
+// Extensions are encoded as their JSON values would be: objects as child elements and arrays as "i" elements.
+// Object members that are not valid XML names are "member" elements with a "name" attribute.
+func (self Problem) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{
+		Name: xml.Name{Space: problemXmlNamespace, Local: "problem"},
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	standard := []struct {
+		name  string
+		value any
+		empty bool
+	}{
+		{name: "type", value: self.Type, empty: self.Type == ""},
+		{name: "title", value: self.Title, empty: self.Title == ""},
+		{name: "status", value: self.Status, empty: self.Status == 0},
+		{name: "detail", value: self.Detail, empty: self.Detail == ""},
+		{name: "instance", value: self.Instance, empty: self.Instance == ""},
+	}
+	for _, member := range standard {
+		if member.empty {
+			continue
+		}
+		if err := encoder.EncodeElement(member.value, xml.StartElement{Name: xml.Name{Local: member.name}}); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(self.Extensions))
+	for name := range self.Extensions {
+		switch name {
+		case "type", "title", "status", "detail", "instance":
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := jsonValue(self.Extensions[name])
+		if err != nil {
+			return err
+		}
+		if err := encodeXmlMember(encoder, name, value); err != nil {
+			return err
+		}
+	}
+
+	if err := encoder.EncodeToken(start.End()); err != nil {
+		return err
+	}
+
+	return encoder.Flush()
+}
+
+// jsonValue converts the value to the generic form of its JSON encoding.
+func jsonValue(value any) (any, error) {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(valueBytes))
+	decoder.UseNumber()
+
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return generic, nil
+}
+
+func encodeXmlMember(encoder *xml.Encoder, name string, value any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !isXmlName(name) {
+		start = xml.StartElement{
+			Name: xml.Name{Local: "member"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: name}},
+		}
+	}
+
+	return encodeXmlValue(encoder, start, value)
+}
+
+func encodeXmlValue(encoder *xml.Encoder, start xml.StartElement, value any) error {
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch typedValue := value.(type) {
+	case map[string]any:
+		names := make([]string, 0, len(typedValue))
+		for name := range typedValue {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if err := encodeXmlMember(encoder, name, typedValue[name]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range typedValue {
+			if err := encodeXmlValue(encoder, xml.StartElement{Name: xml.Name{Local: "i"}}, item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(typedValue))); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+func isXmlName(name string) bool {
+	for index, char := range name {
+		if unicode.IsLetter(char) || char == '_' {
+			continue
+		}
+		if index != 0 && (unicode.IsDigit(char) || char == '-' || char == '.') {
+			continue
+		}
+
+		return false
+	}
+
+	return name != ""
+}
+
+// ProblemType of errors that match Err.
+type ProblemType struct {
+	Err   error
+	Type  string
+	Title string
+}
+
+// ProblemErrorHandler creates "application/problem+json" and "application/problem+xml" error responses (RFC 7807).
+// The instance is the request ID.
+type ProblemErrorHandler struct {
+	// Types of errors, checked in order before the built in types.
+	Types []ProblemType
+	// TypeBaseUri is prefixed to the built in problem types. Defaults to "urn:spiderweb:problem:".
+	TypeBaseUri string
+}
+
+var _ ErrorHandler = ProblemErrorHandler{}
+var _ ErrorContentTyper = ProblemErrorHandler{}
+
+// builtInProblemTypes of the sentinel errors.
+// More specific errors come first.
+var builtInProblemTypes = []ProblemType{
+	{Err: ErrInvalidBody, Type: "invalid-body", Title: "Invalid Body"},
+	{Err: ErrRequestBodyTooLarge, Type: "request-body-too-large", Title: "Request Body Too Large"},
+	{Err: ErrBadRequest, Type: "bad-request", Title: "Bad Request"},
+	{Err: ErrInvalidMimeType, Type: "invalid-mime-type", Title: "Invalid MIME Type"},
+	{Err: ErrNotAcceptable, Type: "not-acceptable", Title: "Not Acceptable"},
+	{Err: ErrRequestTimeout, Type: "request-timeout", Title: "Request Timeout"},
+	{Err: ErrUnauthorized, Type: "unauthorized", Title: "Unauthorized"},
+	{Err: ErrForbidden, Type: "forbidden", Title: "Forbidden"},
+	{Err: ErrInternalServerError, Type: "internal-server-error", Title: "Internal Server Error"},
+}
+
+func (self ProblemErrorHandler) HandleError(ctx context.Context, httpStatus int, err error) (int, any) {
+	httpStatus = httpErrorStatus(err, httpStatus)
+
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(httpStatus),
+		Status: httpStatus,
+		Detail: LocalizeError(ctx, err),
+	}
+
+	if problemType, ok := self.problemType(err); ok {
+		problem.Type = problemType.Type
+		problem.Title = problemType.Title
+	}
+
+	if routeInfo, ok := RequestRoute(ctx); ok {
+		problem.Instance = routeInfo.RequestId
+	}
+
+	extensions := map[string]any{}
+
+	var parameterErrors ParameterErrors
+	if errors.As(err, &parameterErrors) {
+		extensions["parameters"] = parameterErrors
+	}
+
+	var validationErrors ValidationErrors
+	if errors.As(err, &validationErrors) {
+		extensions["fields"] = validationErrors.Fields()
+		extensions["pointers"] = validationErrors.Pointers()
+	}
+
+	var extender ProblemExtender
+	if errors.As(err, &extender) {
+		for name, value := range extender.ProblemExtensions() {
+			extensions[name] = value
+		}
+	}
+
+	if len(extensions) != 0 {
+		problem.Extensions = extensions
+	}
+
+	return httpStatus, problem
+}
+
+func (self ProblemErrorHandler) problemType(err error) (ProblemType, bool) {
+	for _, problemType := range self.Types {
+		if errors.Is(err, problemType.Err) {
+			return problemType, true
+		}
+	}
+
+	typeBaseUri := self.TypeBaseUri
+	if typeBaseUri == "" {
+		typeBaseUri = defaultProblemTypeBaseUri
+	}
+
+	for _, problemType := range builtInProblemTypes {
+		if errors.Is(err, problemType.Err) {
+			problemType.Type = typeBaseUri + problemType.Type
+
+			return problemType, true
+		}
+	}
+
+	return ProblemType{}, false
+}
+
+// ErrorContentType is "application/problem+json" for JSON responses and "application/problem+xml" for XML responses,
+// unless the client only accepts "application/json" or "application/xml".
+func (self ProblemErrorHandler) ErrorContentType(accept []byte, mimeType string) string {
+	var problemMimeType string
+	switch mimeType {
+	case mimeTypeJson:
+		problemMimeType = mimeTypeProblemJson
+	case mimeTypeXml:
+		problemMimeType = mimeTypeProblemXml
+	default:
+		return mimeType
+	}
+
+	acceptValue := string(accept)
+	if len(accept) == 0 {
+		acceptValue = mimeTypeAny
+	}
+
+	// Prefer the problem type when the client accepts both equally, such as "*/*".
+	contentType, _, ok := negotiate(parseAccept(acceptValue), []string{problemMimeType, mimeType})
+	if !ok {
+		return mimeType
+	}
+
+	return contentType
+}
@@ -0,0 +1,335 @@
+package endpoint_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+var errOutOfStock = errors.New("out of stock")
+
+type stockError struct {
+	sku       string
+	available int
+}
+
+func (self stockError) Error() string {
+	return "not enough stock: " + self.sku
+}
+
+func (self stockError) Is(target error) bool {
+	return target == errOutOfStock
+}
+
+func (self stockError) ProblemExtensions() map[string]any {
+	return map[string]any{
+		"sku":       self.sku,
+		"available": self.available,
+	}
+}
+
+type problemRequest struct {
+	Sku string `json:"sku"`
+}
+
+type problemResponse struct {
+	Sku string `json:"sku"`
+}
+
+type problemEndpoint struct {
+	Quantity     int              `spiderweb:"query=quantity,required"`
+	RequestBody  *problemRequest  `spiderweb:"request,mime=application/json"`
+	ResponseBody *problemResponse `spiderweb:"response,mime=application/json"`
+}
+
+func (self *problemEndpoint) Handle(ctx context.Context) (int, error) {
+	switch self.RequestBody.Sku {
+	case "sold-out":
+		return httpstatus.Conflict, stockError{sku: self.RequestBody.Sku, available: 0}
+	case "missing":
+		return httpstatus.NotFound, errors.New("sku not found")
+	}
+
+	self.ResponseBody = &problemResponse{Sku: self.RequestBody.Sku}
+
+	return httpstatus.OK, nil
+}
+
+func Test_ProblemErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                string
+		errorHandler        endpoint.ErrorHandler
+		target              string
+		contentType         string
+		accept              string
+		body                string
+		expectedHttpStatus  int
+		expectedContentType string
+		expectedProblem     map[string]any
+	}{
+		{
+			name:                "invalid parameter",
+			errorHandler:        endpoint.ProblemErrorHandler{},
+			target:              "/stock?quantity=many",
+			contentType:         "application/json",
+			body:                `{"sku":"abc"}`,
+			expectedHttpStatus:  httpstatus.BadRequest,
+			expectedContentType: "application/problem+json",
+			expectedProblem: map[string]any{
+				"type":   "urn:spiderweb:problem:bad-request",
+				"title":  "Bad Request",
+				"status": float64(400),
+				"detail": "bad request: query parameter 'quantity' has invalid value 'many'",
+				"parameters": []any{
+					map[string]any{"in": "query", "name": "quantity", "message": "has invalid value 'many'"},
+				},
+			},
+		},
+		{
+			name:                "client only accepts json",
+			errorHandler:        endpoint.ProblemErrorHandler{},
+			target:              "/stock?quantity=1",
+			contentType:         "application/json",
+			accept:              "application/json",
+			body:                `{"sku":"missing"}`,
+			expectedHttpStatus:  httpstatus.NotFound,
+			expectedContentType: "application/json",
+			expectedProblem: map[string]any{
+				"type":   "about:blank",
+				"title":  "Not Found",
+				"status": float64(404),
+				"detail": "sku not found",
+			},
+		},
+		{
+			name:                "client prefers json",
+			errorHandler:        endpoint.ProblemErrorHandler{},
+			target:              "/stock?quantity=1",
+			contentType:         "application/json",
+			accept:              "application/problem+json;q=0.5, application/json",
+			body:                `{"sku":"missing"}`,
+			expectedHttpStatus:  httpstatus.NotFound,
+			expectedContentType: "application/json",
+			expectedProblem: map[string]any{
+				"type":   "about:blank",
+				"title":  "Not Found",
+				"status": float64(404),
+				"detail": "sku not found",
+			},
+		},
+		{
+			name:                "unsupported content type",
+			errorHandler:        endpoint.ProblemErrorHandler{TypeBaseUri: "https://example.com/problems/"},
+			target:              "/stock?quantity=1",
+			contentType:         "text/csv",
+			body:                `sku`,
+			expectedHttpStatus:  httpstatus.UnsupportedMediaType,
+			expectedContentType: "application/problem+json",
+			expectedProblem: map[string]any{
+				"type":   "https://example.com/problems/invalid-mime-type",
+				"title":  "Invalid MIME Type",
+				"status": float64(415),
+				"detail": "invalid MIME type",
+			},
+		},
+		{
+			name:                "extension members",
+			errorHandler:        endpoint.ProblemErrorHandler{},
+			target:              "/stock?quantity=1",
+			contentType:         "application/json",
+			body:                `{"sku":"sold-out"}`,
+			expectedHttpStatus:  httpstatus.Conflict,
+			expectedContentType: "application/problem+json",
+			expectedProblem: map[string]any{
+				"type":      "about:blank",
+				"title":     "Conflict",
+				"status":    float64(409),
+				"detail":    "not enough stock: sold-out",
+				"sku":       "sold-out",
+				"available": float64(0),
+			},
+		},
+		{
+			name: "custom type",
+			errorHandler: endpoint.ProblemErrorHandler{
+				Types: []endpoint.ProblemType{
+					{Err: errOutOfStock, Type: "https://example.com/problems/out-of-stock", Title: "Out of Stock"},
+				},
+			},
+			target:              "/stock?quantity=1",
+			contentType:         "application/json",
+			body:                `{"sku":"sold-out"}`,
+			expectedHttpStatus:  httpstatus.Conflict,
+			expectedContentType: "application/problem+json",
+			expectedProblem: map[string]any{
+				"type":      "https://example.com/problems/out-of-stock",
+				"title":     "Out of Stock",
+				"status":    float64(409),
+				"detail":    "not enough stock: sold-out",
+				"sku":       "sold-out",
+				"available": float64(0),
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig:    log.NewConfig().WithLevel(log.LevelError),
+				ErrorHandler: testCase.errorHandler,
+			}, &problemEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, testCase.target, bytes.NewBufferString(testCase.body))
+			assert.Nil(t, err)
+			req.Header.Add("Content-Type", testCase.contentType)
+			if testCase.accept != "" {
+				req.Header.Add("Accept", testCase.accept)
+			}
+
+			requester, err := endpoint.NewHttpRequester("/stock", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedContentType, requester.ResponseContentType())
+
+			problem := map[string]any{}
+			assert.Nil(t, json.Unmarshal(responseBodyBytes, &problem), string(responseBodyBytes))
+			assert.Equal(t, requester.RequestId(), problem["instance"])
+			delete(problem, "instance")
+			assert.Equal(t, testCase.expectedProblem, problem)
+		})
+	}
+}
+
+func Test_Problem_JSON(t *testing.T) {
+	t.Parallel()
+
+	problem := endpoint.Problem{
+		Type:   "about:blank",
+		Title:  "Conflict",
+		Status: httpstatus.Conflict,
+		Extensions: map[string]any{
+			"sku":   "abc",
+			"title": "ignored",
+		},
+	}
+
+	problemBytes, err := json.Marshal(problem)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"sku":"abc","status":409,"title":"Conflict","type":"about:blank"}`, string(problemBytes))
+
+	decoded := endpoint.Problem{}
+	assert.Nil(t, json.Unmarshal(problemBytes, &decoded))
+	assert.Equal(t, endpoint.Problem{
+		Type:       "about:blank",
+		Title:      "Conflict",
+		Status:     httpstatus.Conflict,
+		Extensions: map[string]any{"sku": "abc"},
+	}, decoded)
+}
+
+type xmlProblemEndpoint struct {
+	ResponseBody *problemResponse `spiderweb:"response,mime=application/xml"`
+}
+
+func (self *xmlProblemEndpoint) Handle(ctx context.Context) (int, error) {
+	return httpstatus.Conflict, stockError{sku: "sold-out", available: 0}
+}
+
+func Test_ProblemErrorHandler_XML(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+	testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+		LogConfig:    log.NewConfig().WithLevel(log.LevelError),
+		ErrorHandler: endpoint.ProblemErrorHandler{},
+	}, &xmlProblemEndpoint{})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/stock", nil)
+	assert.Nil(t, err)
+	req.Header.Add("Accept", "application/problem+xml, application/xml")
+
+	requester, err := endpoint.NewHttpRequester("/stock", req)
+	assert.Nil(t, err)
+
+	var httpStatus int
+	var responseBodyBytes []byte
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+	}()
+	wg.Wait()
+
+	assert.Equal(t, httpstatus.Conflict, httpStatus)
+	assert.Equal(t, "application/problem+xml", requester.ResponseContentType())
+	assert.Equal(t, `<problem xmlns="urn:ietf:rfc:7807">`+
+		`<type>about:blank</type><title>Conflict</title><status>409</status><detail>not enough stock: sold-out</detail><instance>`+requester.RequestId()+`</instance>`+
+		`<available>0</available><sku>sold-out</sku>`+
+		`</problem>`, string(responseBodyBytes))
+}
+
+func Test_Problem_XML(t *testing.T) {
+	t.Parallel()
+
+	problem := endpoint.Problem{
+		Type:   "about:blank",
+		Title:  "Bad Request",
+		Status: httpstatus.BadRequest,
+		Extensions: map[string]any{
+			"title":    "ignored",
+			"pointers": map[string]string{"/items/0/name": "is required"},
+			"fields":   map[string]any{"name": "is required", "tags": []string{"a", "b"}, "note": nil},
+		},
+	}
+
+	problemBytes, err := xml.Marshal(problem)
+	assert.Nil(t, err)
+	assert.Equal(t, `<problem xmlns="urn:ietf:rfc:7807">`+
+		`<type>about:blank</type><title>Bad Request</title><status>400</status>`+
+		`<fields><name>is required</name><note></note><tags><i>a</i><i>b</i></tags></fields>`+
+		`<pointers><member name="/items/0/name">is required</member></pointers>`+
+		`</problem>`, string(problemBytes))
+
+	decoded := endpoint.Problem{}
+	assert.Nil(t, xml.Unmarshal(problemBytes, &decoded))
+	assert.Equal(t, endpoint.Problem{
+		Type:   "about:blank",
+		Title:  "Bad Request",
+		Status: httpstatus.BadRequest,
+	}, decoded)
+}
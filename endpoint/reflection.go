@@ -1,9 +1,13 @@
 package endpoint
 
 import (
+	"encoding"
+	"io"
+	"net/textproto"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/wspowell/context"
 	"github.com/wspowell/errors"
@@ -16,11 +20,22 @@ import (
 const (
 	structTagPath     = "path"
 	structTagQuery    = "query"
+	structTagHeader   = "header"
+	structTagCookie   = "cookie"
 	structTagResource = "resource"
 	structTagETag     = "etag"
 	structTagMaxAge   = "max-age"
 
 	tagValueRequired = "required"
+
+	sliceValueSeparator = ","
+)
+
+// nolint:gochecknoglobals // reason: cached reflection types
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+	authorizerType      = reflect.TypeOf((*Authorizer)(nil)).Elem()
 )
 
 type handlerAllocation struct {
@@ -65,16 +80,30 @@ type handlerTypeData struct {
 	shouldValidateRequest  bool
 	shouldValidateResponse bool
 
+	isRequestStream  bool
+	isResponseStream bool
+
 	requestMimeTypes  []string
 	responseMimeTypes []string
 
-	resources               map[string]resourceTypeData
-	pathParameters          map[string]int
-	queryParameters         map[string]int
-	requiredQueryParameters map[string]struct{}
+	resources       map[string]resourceTypeData
+	parameters      []parameterTypeData
+	files           map[string]int
+	requiredFiles   map[string]struct{}
+	responseHeaders map[string]int
+	responseCookies []int
 
 	eTagEnabled   bool
 	maxAgeSeconds int
+
+	isBeforeHandler  bool
+	isAfterHandler   bool
+	isOnErrorHandler bool
+
+	hasPrincipal        bool
+	isPrincipalOptional bool
+	principalFieldNum   int
+	principalType       reflect.Type
 }
 
 func newHandlerTypeData(ctx context.Context, handler any) handlerTypeData {
@@ -94,17 +123,30 @@ func newHandlerTypeData(ctx context.Context, handler any) handlerTypeData {
 	var authFieldNum int
 	var shouldValidateRequest bool
 	var shouldValidateResponse bool
+	var isRequestStream bool
+	var isResponseStream bool
 	var hasRequestBody bool
 	var hasResponseBody bool
 	var hasAuth bool
 	requestMimeTypes := []string{}
 	responseMimeTypes := []string{}
 	resources := map[string]resourceTypeData{}
-	pathParameters := map[string]int{}
-	queryParameters := map[string]int{}
-	requiredQueryParameters := map[string]struct{}{}
+	parameters := []parameterTypeData{}
+	files := map[string]int{}
+	requiredFiles := map[string]struct{}{}
+	responseHeaders := map[string]int{}
+	responseCookies := []int{}
 	var eTagEnabled bool
 	var maxAgeSeconds int
+	var hasPrincipal bool
+	var isPrincipalOptional bool
+	var principalFieldNum int
+	var principalType reflect.Type
+
+	// Lifecycle hooks are optional interfaces on the handler struct.
+	_, isBeforeHandler := handler.(BeforeHandler)
+	_, isAfterHandler := handler.(AfterHandler)
+	_, isOnErrorHandler := handler.(OnErrorHandler)
 
 	structValue = reflect.ValueOf(handler)
 	if structValue.Kind() == reflect.Ptr {
@@ -118,7 +160,7 @@ func newHandlerTypeData(ctx context.Context, handler any) handlerTypeData {
 		structFieldValue := structValue.Field(i)
 		structField := structValue.Type().Field(i)
 		if tagValue, exists := structField.Tag.Lookup(structTagKey); exists {
-			tagValueParts := strings.Split(tagValue, ",")
+			tagValueParts := splitStructTag(tagValue)
 
 			mimeTypes := []string{}
 
@@ -141,7 +183,7 @@ func newHandlerTypeData(ctx context.Context, handler any) handlerTypeData {
 				if strings.HasPrefix(tagValuePart, structTagPath+"=") {
 					pathTagValue := strings.SplitN(tagValuePart, "=", 2)
 					pathVariable := pathTagValue[1]
-					pathParameters[pathVariable] = i
+					parameters = append(parameters, newParameterTypeData(ctx, ParameterInPath, pathVariable, i, structField, tagValueParts))
 
 					break
 				}
@@ -150,10 +192,62 @@ func newHandlerTypeData(ctx context.Context, handler any) handlerTypeData {
 				if strings.HasPrefix(tagValuePart, structTagQuery+"=") {
 					queryTagValue := strings.SplitN(tagValuePart, "=", 2)
 					queryVariable := queryTagValue[1]
-					queryParameters[queryVariable] = i
-					// Detect if query param is required.
-					if tagValueParts[len(tagValueParts)-1] == tagValueRequired {
-						requiredQueryParameters[queryVariable] = struct{}{}
+					parameters = append(parameters, newParameterTypeData(ctx, ParameterInQuery, queryVariable, i, structField, tagValueParts))
+
+					break
+				}
+
+				// Detect response header.
+				if strings.HasPrefix(tagValuePart, structTagResponseHeader+"=") {
+					responseHeaderTagValue := strings.SplitN(tagValuePart, "=", 2)
+					headerName := textproto.CanonicalMIMEHeaderKey(responseHeaderTagValue[1])
+					if err := validateResponseHeaderField(structField); err != nil {
+						log.Fatal(ctx, "%v", err)
+					}
+					responseHeaders[headerName] = i
+
+					break
+				}
+
+				// Detect set cookie.
+				if tagValuePart == structTagSetCookie {
+					if err := validateSetCookieField(structField); err != nil {
+						log.Fatal(ctx, "%v", err)
+					}
+					responseCookies = append(responseCookies, i)
+
+					break
+				}
+
+				// Detect header.
+				if strings.HasPrefix(tagValuePart, structTagHeader+"=") {
+					headerTagValue := strings.SplitN(tagValuePart, "=", 2)
+					headerName := textproto.CanonicalMIMEHeaderKey(headerTagValue[1])
+					parameters = append(parameters, newParameterTypeData(ctx, ParameterInHeader, headerName, i, structField, tagValueParts))
+
+					break
+				}
+
+				// Detect cookie.
+				if strings.HasPrefix(tagValuePart, structTagCookie+"=") {
+					cookieTagValue := strings.SplitN(tagValuePart, "=", 2)
+					cookieName := cookieTagValue[1]
+					parameters = append(parameters, newParameterTypeData(ctx, ParameterInCookie, cookieName, i, structField, tagValueParts))
+
+					break
+				}
+
+				// Detect file.
+				if strings.HasPrefix(tagValuePart, structTagFile+"=") {
+					fileTagValue := strings.SplitN(tagValuePart, "=", 2)
+					fileName := fileTagValue[1]
+					if err := validateFileField(structField); err != nil {
+						log.Fatal(ctx, "%v", err)
+					}
+					files[fileName] = i
+					// Detect if file is required.
+					if hasStructTagOption(tagValue, tagValueRequired) {
+						requiredFiles[fileName] = struct{}{}
 					}
 
 					break
@@ -163,7 +257,9 @@ func newHandlerTypeData(ctx context.Context, handler any) handlerTypeData {
 				if tagValueParts[0] == structTagValueRequest || tagValueParts[0] == structTagValueResponse {
 					if strings.HasPrefix(tagValuePart, structTagMimeType+"=") {
 						mimeTagValue := strings.SplitN(tagValuePart, "=", 2)
-						mimeTypes = strings.Split(mimeTagValue[1], mimeTypeSeparator)
+						for _, mimeType := range strings.Split(mimeTagValue[1], mimeTypeSeparator) {
+							mimeTypes = append(mimeTypes, strings.ToLower(strings.TrimSpace(mimeType)))
+						}
 
 						continue
 					}
@@ -198,6 +294,12 @@ func newHandlerTypeData(ctx context.Context, handler any) handlerTypeData {
 				requestMimeTypes = mimeTypes
 				hasRequestBody = structFieldValue.IsValid()
 				requestBodyType = structFieldValue.Type()
+				isRequestStream = hasStructTagOption(tagValue, structTagOptionStream)
+				if isRequestStream {
+					if err := validateStreamField(structField, true); err != nil {
+						log.Fatal(ctx, "%v", err)
+					}
+				}
 			case structTagValueResponse:
 				responseBodyValue = getFieldValue(structFieldValue)
 				responseFieldNum = i
@@ -206,48 +308,81 @@ func newHandlerTypeData(ctx context.Context, handler any) handlerTypeData {
 				responseMimeTypes = mimeTypes
 				hasResponseBody = structFieldValue.IsValid()
 				responseBodyType = structFieldValue.Type()
+				isResponseStream = hasStructTagOption(tagValue, structTagOptionStream)
+				if isResponseStream {
+					if err := validateStreamField(structField, false); err != nil {
+						log.Fatal(ctx, "%v", err)
+					}
+				}
 			case structTagAuth:
-				authValue = getFieldValue(structFieldValue)
-				authFieldNum = i
-				isAuthPtr = structFieldValue.Kind() == reflect.Ptr
-				hasAuth = structFieldValue.IsValid()
-				authType = structFieldValue.Type()
+				// Authorizers authenticate themselves. Any other type receives the principal of the Authenticators.
+				if structField.Type.Implements(authorizerType) {
+					authValue = getFieldValue(structFieldValue)
+					authFieldNum = i
+					isAuthPtr = structFieldValue.Kind() == reflect.Ptr
+					hasAuth = structFieldValue.IsValid()
+					authType = structFieldValue.Type()
+
+					break
+				}
+
+				fallthrough
+			case structTagPrincipal:
+				hasPrincipal = true
+				isPrincipalOptional = hasStructTagOption(tagValue, tagValueOptional)
+				principalFieldNum = i
+				principalType = structField.Type
 			}
 		}
 	}
 
 	return handlerTypeData{
-		structName:              structValue.Type().Name(),
-		structValue:             structValue,
-		requestBodyValue:        requestBodyValue,
-		responseBodyValue:       responseBodyValue,
-		authValue:               authValue,
-		requestBodyType:         requestBodyType,
-		responseBodyType:        responseBodyType,
-		authType:                authType,
-		isStructPtr:             isStructPtr,
-		isRequestPtr:            isRequestPtr,
-		isResponsePtr:           isResponsePtr,
-		isAuthPtr:               isAuthPtr,
-		requestFieldNum:         requestFieldNum,
-		responseFieldNum:        responseFieldNum,
-		authFieldNum:            authFieldNum,
-		shouldValidateRequest:   shouldValidateRequest,
-		shouldValidateResponse:  shouldValidateResponse,
-		requestMimeTypes:        requestMimeTypes,
-		responseMimeTypes:       responseMimeTypes,
-		hasRequestBody:          hasRequestBody,
-		hasResponseBody:         hasResponseBody,
-		hasAuth:                 hasAuth,
-		resources:               resources,
-		pathParameters:          pathParameters,
-		queryParameters:         queryParameters,
-		requiredQueryParameters: requiredQueryParameters,
-		eTagEnabled:             eTagEnabled,
-		maxAgeSeconds:           maxAgeSeconds,
+		structName:             structValue.Type().Name(),
+		structValue:            structValue,
+		requestBodyValue:       requestBodyValue,
+		responseBodyValue:      responseBodyValue,
+		authValue:              authValue,
+		requestBodyType:        requestBodyType,
+		responseBodyType:       responseBodyType,
+		authType:               authType,
+		isStructPtr:            isStructPtr,
+		isRequestPtr:           isRequestPtr,
+		isResponsePtr:          isResponsePtr,
+		isAuthPtr:              isAuthPtr,
+		requestFieldNum:        requestFieldNum,
+		responseFieldNum:       responseFieldNum,
+		authFieldNum:           authFieldNum,
+		shouldValidateRequest:  shouldValidateRequest,
+		shouldValidateResponse: shouldValidateResponse,
+		isRequestStream:        isRequestStream,
+		isResponseStream:       isResponseStream,
+		requestMimeTypes:       requestMimeTypes,
+		responseMimeTypes:      responseMimeTypes,
+		hasRequestBody:         hasRequestBody,
+		hasResponseBody:        hasResponseBody,
+		hasAuth:                hasAuth,
+		resources:              resources,
+		parameters:             parameters,
+		files:                  files,
+		requiredFiles:          requiredFiles,
+		responseHeaders:        responseHeaders,
+		responseCookies:        responseCookies,
+		eTagEnabled:            eTagEnabled,
+		maxAgeSeconds:          maxAgeSeconds,
+		isBeforeHandler:        isBeforeHandler,
+		isAfterHandler:         isAfterHandler,
+		isOnErrorHandler:       isOnErrorHandler,
+		hasPrincipal:           hasPrincipal,
+		isPrincipalOptional:    isPrincipalOptional,
+		principalFieldNum:      principalFieldNum,
+		principalType:          principalType,
 	}
 }
 
+func (self handlerTypeData) hasFiles() bool {
+	return len(self.files) != 0
+}
+
 func (self handlerTypeData) allocateHandler() *handlerAllocation {
 	handlerValue := self.newHandlerValue()
 
@@ -307,6 +442,67 @@ func (self handlerTypeData) newStruct(handlerValue reflect.Value, valueType refl
 	}
 }
 
+// setRequestStream sets the request body stream on the "request,stream" field.
+func (self handlerTypeData) setRequestStream(handlerValue reflect.Value, stream io.Reader) {
+	handlerValue.Elem().Field(self.requestFieldNum).Set(reflect.ValueOf(stream))
+}
+
+// getResponseStream returns the stream set by the handler on the "response,stream" field.
+// Returns nil if the handler did not set a stream.
+func (self handlerTypeData) getResponseStream(handlerValue reflect.Value) io.Reader {
+	streamValue := handlerValue.Elem().Field(self.responseFieldNum)
+	if (streamValue.Kind() == reflect.Interface || streamValue.Kind() == reflect.Ptr) && streamValue.IsNil() {
+		return nil
+	}
+
+	if stream, ok := streamValue.Interface().(io.Reader); ok {
+		return stream
+	}
+
+	return nil
+}
+
+// setFiles binds multipart file parts to the "file=<name>" fields.
+// Returns the files that were opened so they can be closed once the handler completes.
+func (self handlerTypeData) setFiles(handlerValue reflect.Value, form *MultipartForm) ([]io.Closer, error) {
+	openedFiles := []io.Closer{}
+
+	for fileName, fieldNum := range self.files {
+		fileValue := handlerValue.Elem().Field(fieldNum)
+
+		if !fileValue.CanSet() {
+			return openedFiles, errors.New("cannot set file: %s", fileName)
+		}
+
+		fileHeaders := form.File[fileName]
+		if len(fileHeaders) == 0 {
+			if _, isRequired := self.requiredFiles[fileName]; isRequired {
+				return openedFiles, errors.New("file not found: %s", fileName)
+			}
+
+			// File not required. Leave the value at the zero value.
+			continue
+		}
+
+		switch fileValue.Type() {
+		case fileHeaderType:
+			fileValue.Set(reflect.ValueOf(fileHeaders[0]))
+		case fileHeaderSliceType:
+			fileValue.Set(reflect.ValueOf(fileHeaders))
+		default:
+			file, err := fileHeaders[0].Open()
+			if err != nil {
+				return openedFiles, errors.Wrap(err, ErrInvalidBody)
+			}
+			openedFiles = append(openedFiles, file)
+
+			fileValue.Set(reflect.ValueOf(file))
+		}
+	}
+
+	return openedFiles, nil
+}
+
 func (self handlerTypeData) setResources(handlerValue reflect.Value, resources map[string]any) error {
 	for resourceName, resourceData := range self.resources {
 		if resource, exists := resources[resourceName]; exists {
@@ -330,184 +526,126 @@ func (self handlerTypeData) setResources(handlerValue reflect.Value, resources m
 	return nil
 }
 
-func (self handlerTypeData) setPathParameters(handlerValue reflect.Value, requester Requester) error {
-	for param, fieldNum := range self.pathParameters {
-		parameterValue := handlerValue.Elem().Field(fieldNum)
-
-		if !parameterValue.CanSet() {
-			return errors.New("cannot set path param: %s", param)
-		}
-
-		value, ok := requester.PathParam(param)
-		if !ok {
-			return errors.New("path param value not found: %s", param)
-		}
-
-		if err := setValueFromString(parameterValue, value); err != nil {
+// setValueFromString parses the string into the variable based on its type.
+// Supported types are:
+//   - encoding.TextUnmarshaler (ex: time.Time, UUIDs, enums)
+//   - time.Duration
+//   - string, bool, int, uint, and float kinds
+//   - pointers to a supported type, allocated only when a value is set
+//   - slices of a supported type, parsed from a comma separated list
+func setValueFromString(variable reflect.Value, value string) error {
+	if variable.Kind() == reflect.Ptr {
+		elemValue := reflect.New(variable.Type().Elem())
+		if err := setValueFromString(elemValue.Elem(), value); err != nil {
 			return err
 		}
-	}
+		variable.Set(elemValue)
 
-	return nil
-}
-
-func (self handlerTypeData) setQueryParameters(handlerValue reflect.Value, requester Requester) error {
-	for query, fieldNum := range self.queryParameters {
-		queryValue := handlerValue.Elem().Field(fieldNum)
+		return nil
+	}
 
-		if !queryValue.CanSet() {
-			return errors.New("cannot set query param: %s", query)
+	if variable.CanAddr() && variable.Addr().Type().Implements(textUnmarshalerType) {
+		// nolint:forcetypeassert // reason: checked by Implements()
+		if err := variable.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return errors.New("could not set value (%s) from string (%s): %v", variable.Type(), value, err)
 		}
 
-		_, isRequired := self.requiredQueryParameters[query]
-		queryBytes, ok := requester.QueryParam(query)
-		if !ok {
-			if isRequired {
-				return errors.New("query param value not found: %s", query)
-			} else {
-				// Query param not required. Leave the value at the zero value.
-				continue
-			}
-		}
+		return nil
+	}
 
-		if err := setValueFromString(queryValue, string(queryBytes)); err != nil {
-			return err
+	if variable.Type() == durationType {
+		parsedValue, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("could not set value (%s) from string (%s): %v", variable.Type(), value, err)
 		}
-	}
+		variable.SetInt(int64(parsedValue))
 
-	return nil
-}
+		return nil
+	}
 
-func setValueFromString(variable reflect.Value, value string) error {
 	switch variable.Kind() {
 	case reflect.String:
-		variable.Set(reflect.ValueOf(value))
+		variable.SetString(value)
 
 		return nil
 	case reflect.Bool:
 		if parsedValue, err := strconv.ParseBool(value); err == nil {
-			val := reflect.ValueOf(parsedValue)
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+			variable.SetBool(parsedValue)
 
-				return nil
-			}
-		}
-	case reflect.Int:
-		if parsedValue, err := strconv.ParseInt(value, 10, 32); err == nil {
-			val := reflect.ValueOf(int(parsedValue))
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
-
-				return nil
-			}
+			return nil
 		}
-	case reflect.Int8:
-		if parsedValue, err := strconv.ParseInt(value, 10, 8); err == nil {
-			val := reflect.ValueOf(int8(parsedValue))
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if parsedValue, err := strconv.ParseInt(value, 10, variable.Type().Bits()); err == nil {
+			variable.SetInt(parsedValue)
 
-				return nil
-			}
-		}
-	case reflect.Int16:
-		if parsedValue, err := strconv.ParseInt(value, 10, 16); err == nil {
-			val := reflect.ValueOf(int16(parsedValue))
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
-
-				return nil
-			}
+			return nil
 		}
-	case reflect.Int32:
-		if parsedValue, err := strconv.ParseInt(value, 10, 32); err == nil {
-			val := reflect.ValueOf(int32(parsedValue))
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if parsedValue, err := strconv.ParseUint(value, 10, variable.Type().Bits()); err == nil {
+			variable.SetUint(parsedValue)
 
-				return nil
-			}
+			return nil
 		}
-	case reflect.Int64:
-		if parsedValue, err := strconv.ParseInt(value, 10, 64); err == nil {
-			val := reflect.ValueOf(parsedValue)
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+	case reflect.Float32, reflect.Float64:
+		if parsedValue, err := strconv.ParseFloat(value, variable.Type().Bits()); err == nil {
+			variable.SetFloat(parsedValue)
 
-				return nil
-			}
+			return nil
 		}
-	case reflect.Uint:
-		if parsedValue, err := strconv.ParseUint(value, 10, 32); err == nil {
-			val := reflect.ValueOf(uint(parsedValue))
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+	case reflect.Slice:
+		return setValuesFromStrings(variable, []string{value})
+	case reflect.Array, reflect.Chan, reflect.Complex128, reflect.Complex64,
+		reflect.Func, reflect.Interface, reflect.Invalid, reflect.Map, reflect.Ptr,
+		reflect.Struct, reflect.Uintptr, reflect.UnsafePointer:
+		return errors.New("could not set value (%s) from string (%s) because due to invalid type (%s)", variable.Type(), value, variable.Kind())
+	}
 
-				return nil
-			}
-		}
-	case reflect.Uint8:
-		if parsedValue, err := strconv.ParseUint(value, 10, 8); err == nil {
-			val := reflect.ValueOf(uint8(parsedValue))
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+	return errors.New("could not set value (%s) from string (%s)", variable.Type(), value)
+}
 
-				return nil
-			}
-		}
-	case reflect.Uint16:
-		if parsedValue, err := strconv.ParseUint(value, 10, 16); err == nil {
-			val := reflect.ValueOf(uint16(parsedValue))
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+// setValuesFromStrings parses multiple values, such as a repeated query parameter, into the variable.
+// Slices are appended with every comma separated value of every given value.
+// Any other type is set using only the first value.
+func setValuesFromStrings(variable reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
 
-				return nil
-			}
+	variableType := variable.Type()
+	if variableType.Kind() == reflect.Ptr && variableType.Elem().Kind() == reflect.Slice {
+		elemValue := reflect.New(variableType.Elem())
+		if err := setValuesFromStrings(elemValue.Elem(), values); err != nil {
+			return err
 		}
-	case reflect.Uint32:
-		if parsedValue, err := strconv.ParseUint(value, 10, 32); err == nil {
-			val := reflect.ValueOf(uint32(parsedValue))
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+		variable.Set(elemValue)
 
-				return nil
-			}
-		}
-	case reflect.Uint64:
-		if parsedValue, err := strconv.ParseUint(value, 10, 64); err == nil {
-			val := reflect.ValueOf(parsedValue)
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+		return nil
+	}
 
-				return nil
-			}
-		}
-	case reflect.Float32:
-		if parsedValue, err := strconv.ParseFloat(value, 32); err == nil {
-			val := reflect.ValueOf(float32(parsedValue))
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+	switch {
+	case variable.Kind() != reflect.Slice, reflect.PtrTo(variableType).Implements(textUnmarshalerType):
+		// TextUnmarshaler slice types (ex: net.IP) are parsed as a single value.
+		return setValueFromString(variable, values[0])
+	case variableType.Elem().Kind() == reflect.Uint8:
+		// Byte slices are set to the raw value.
+		variable.SetBytes([]byte(values[0]))
 
-				return nil
-			}
-		}
-	case reflect.Float64:
-		if parsedValue, err := strconv.ParseFloat(value, 64); err == nil {
-			val := reflect.ValueOf(parsedValue)
-			if val.Type().AssignableTo(variable.Type()) {
-				variable.Set(val)
+		return nil
+	}
 
-				return nil
+	sliceValue := reflect.MakeSlice(variableType, 0, len(values))
+	for _, value := range values {
+		for _, item := range strings.Split(value, sliceValueSeparator) {
+			itemValue := reflect.New(variableType.Elem()).Elem()
+			if err := setValueFromString(itemValue, strings.TrimSpace(item)); err != nil {
+				return err
 			}
+			sliceValue = reflect.Append(sliceValue, itemValue)
 		}
-	case reflect.Array, reflect.Chan, reflect.Complex128, reflect.Complex64,
-		reflect.Func, reflect.Interface, reflect.Invalid, reflect.Map, reflect.Ptr,
-		reflect.Slice, reflect.Struct, reflect.Uintptr, reflect.UnsafePointer:
-		return errors.New("could not set value (%v) from string (%s) because due to invalid type (%s)", variable, value, variable.Kind())
 	}
+	variable.Set(sliceValue)
 
-	return errors.New("could not set value (%v) from string (%s)", variable, value)
+	return nil
 }
 
 func getFieldValue(structValue reflect.Value) reflect.Value {
@@ -519,8 +657,21 @@ func getFieldValue(structValue reflect.Value) reflect.Value {
 	return structValue
 }
 
+// splitStructTag into its comma separated options.
+// The "pattern" option takes the rest of the tag, so it must be the last option and its expression may contain commas.
+func splitStructTag(structTag string) []string {
+	tagParts := strings.Split(structTag, ",")
+	for index, tagPart := range tagParts {
+		if strings.HasPrefix(tagPart, structTagPattern+"=") {
+			return append(tagParts[:index], strings.Join(tagParts[index:], ","))
+		}
+	}
+
+	return tagParts
+}
+
 func hasStructTagOption(structTag string, tagOption string) bool {
-	for _, tagPart := range strings.Split(structTag, ",") {
+	for _, tagPart := range splitStructTag(structTag) {
 		if tagPart == tagOption {
 			return true
 		}
@@ -1,11 +1,15 @@
 package endpoint
 
 import (
+	"net"
 	"net/http"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
 )
 
 type myRequestBodyModel struct {
@@ -233,3 +237,104 @@ func Test_handlerTypeData_etag_maxage(t *testing.T) {
 	assert.True(t, typeData.eTagEnabled)
 	assert.Equal(t, 300, typeData.maxAgeSeconds)
 }
+
+type testLevel int
+
+func (self *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*self = 1
+	case "high":
+		*self = 2
+	default:
+		return errors.New("invalid level: %s", text)
+	}
+
+	return nil
+}
+
+func Test_setValueFromString(t *testing.T) {
+	t.Parallel()
+
+	type values struct {
+		Int       int
+		Int64     int64
+		Uint8     uint8
+		Float     float64
+		Bool      bool
+		String    string
+		IntPtr    *int
+		Ints      []int
+		Strings   []string
+		Bytes     []byte
+		Time      time.Time
+		Duration  time.Duration
+		Level     testLevel
+		LevelPtr  *testLevel
+		IP        net.IP
+		LevelList []testLevel
+	}
+
+	testCases := []struct {
+		name     string
+		field    string
+		value    string
+		expected any
+		fails    bool
+	}{
+		{name: "int is 64 bit", field: "Int", value: "4294967296", expected: 4294967296},
+		{name: "int64", field: "Int64", value: "-5", expected: int64(-5)},
+		{name: "uint8 overflow", field: "Uint8", value: "256", fails: true},
+		{name: "float", field: "Float", value: "1.5", expected: 1.5},
+		{name: "bool", field: "Bool", value: "true", expected: true},
+		{name: "invalid bool", field: "Bool", value: "yes please", fails: true},
+		{name: "string", field: "String", value: "a,b", expected: "a,b"},
+		{name: "pointer", field: "IntPtr", value: "0", expected: func() *int { value := 0; return &value }()},
+		{name: "comma separated slice", field: "Ints", value: "1, 2,3", expected: []int{1, 2, 3}},
+		{name: "invalid slice item", field: "Ints", value: "1,two", fails: true},
+		{name: "string slice", field: "Strings", value: "a,b", expected: []string{"a", "b"}},
+		{name: "bytes", field: "Bytes", value: "a,b", expected: []byte("a,b")},
+		{name: "time", field: "Time", value: "2022-01-02T03:04:05Z", expected: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "invalid time", field: "Time", value: "yesterday", fails: true},
+		{name: "duration", field: "Duration", value: "1m30s", expected: 90 * time.Second},
+		{name: "text unmarshaler", field: "Level", value: "high", expected: testLevel(2)},
+		{name: "invalid text unmarshaler", field: "Level", value: "medium", fails: true},
+		{name: "text unmarshaler pointer", field: "LevelPtr", value: "low", expected: func() *testLevel { value := testLevel(1); return &value }()},
+		{name: "text unmarshaler slice type", field: "IP", value: "127.0.0.1", expected: net.IPv4(127, 0, 0, 1)},
+		{name: "text unmarshaler slice", field: "LevelList", value: "low,high", expected: []testLevel{1, 2}},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			variable := reflect.ValueOf(&values{}).Elem().FieldByName(testCase.field)
+			err := setValueFromString(variable, testCase.value)
+			if testCase.fails {
+				assert.NotNil(t, err)
+
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.expected, variable.Interface())
+		})
+	}
+}
+
+func Test_setValuesFromStrings(t *testing.T) {
+	t.Parallel()
+
+	var ints []int
+	assert.Nil(t, setValuesFromStrings(reflect.ValueOf(&ints).Elem(), []string{"1", "2,3"}))
+	assert.Equal(t, []int{1, 2, 3}, ints)
+
+	var intsPtr *[]int
+	assert.Nil(t, setValuesFromStrings(reflect.ValueOf(&intsPtr).Elem(), []string{"4"}))
+	assert.Equal(t, &[]int{4}, intsPtr)
+
+	var single int
+	assert.Nil(t, setValuesFromStrings(reflect.ValueOf(&single).Elem(), []string{"5", "6"}))
+	assert.Equal(t, 5, single)
+}
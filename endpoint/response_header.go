@@ -0,0 +1,129 @@
+package endpoint
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/wspowell/errors"
+)
+
+const (
+	structTagResponseHeader = "response-header"
+	structTagSetCookie      = "set-cookie"
+)
+
+// nolint:gochecknoglobals // reason: cached reflection types
+var (
+	cookieType        = reflect.TypeOf((*http.Cookie)(nil))
+	cookieSliceType   = reflect.TypeOf([]*http.Cookie{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// validateResponseHeaderField checks that a "response-header=<name>" field can be formatted as a header value.
+func validateResponseHeaderField(structField reflect.StructField) error {
+	fieldType := structField.Type
+	if fieldType.Implements(textMarshalerType) || fieldType.Implements(stringerType) {
+		return nil
+	}
+
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return nil
+	}
+
+	return errors.New("response header field %s must be a string, bool, number, fmt.Stringer, or encoding.TextMarshaler", structField.Name)
+}
+
+// validateSetCookieField checks that a "set-cookie" field is a cookie type.
+func validateSetCookieField(structField reflect.StructField) error {
+	switch structField.Type {
+	case cookieType, cookieSliceType:
+		return nil
+	}
+
+	return errors.New("set-cookie field %s must be one of *http.Cookie, []*http.Cookie", structField.Name)
+}
+
+// formatValueToString is the inverse of setValueFromString for response header values.
+func formatValueToString(value reflect.Value) (string, error) {
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return "", nil
+	}
+
+	switch typedValue := value.Interface().(type) {
+	case encoding.TextMarshaler:
+		text, err := typedValue.MarshalText()
+		if err != nil {
+			return "", err
+		}
+
+		return string(text), nil
+	case fmt.Stringer:
+		return typedValue.String(), nil
+	}
+
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64), nil
+	}
+
+	return "", errors.New("cannot format value of type: %s", value.Type())
+}
+
+// setResponseHeaders copies "response-header=<name>" and "set-cookie" fields onto the response.
+// Zero values such as 0 and false are written. A pointer field makes the header optional, since nil is not written.
+// Empty strings are not written either.
+func (self handlerTypeData) setResponseHeaders(handlerValue reflect.Value, requester Requester) error {
+	for header, fieldNum := range self.responseHeaders {
+		value, err := formatValueToString(handlerValue.Elem().Field(fieldNum))
+		if err != nil {
+			return errors.Wrap(err, errors.New("invalid response header: %s", header))
+		}
+
+		if value != "" {
+			requester.SetResponseHeader(header, value)
+		}
+	}
+
+	for _, fieldNum := range self.responseCookies {
+		switch cookies := handlerValue.Elem().Field(fieldNum).Interface().(type) {
+		case *http.Cookie:
+			if cookies != nil {
+				requester.SetResponseCookie(cookies)
+			}
+		case []*http.Cookie:
+			for _, cookie := range cookies {
+				if cookie != nil {
+					requester.SetResponseCookie(cookie)
+				}
+			}
+		}
+	}
+
+	return nil
+}
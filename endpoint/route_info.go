@@ -0,0 +1,26 @@
+package endpoint
+
+import (
+	"github.com/wspowell/context"
+)
+
+type routeInfoKey struct{}
+
+// RouteInfo describes the route of the request being executed.
+type RouteInfo struct {
+	// Method of the request. Ex: GET
+	Method string
+	// Path is the matched route path. Ex: /some/path/{id}
+	Path string
+	// RequestId of the request.
+	RequestId string
+	Endpoint  *Endpoint
+}
+
+// RequestRoute returns the route of the request being executed.
+// This is available to handlers and to everything called by Execute, such as validators.
+func RequestRoute(ctx context.Context) (RouteInfo, bool) {
+	routeInfo, ok := ctx.Value(routeInfoKey{}).(RouteInfo)
+
+	return routeInfo, ok
+}
@@ -0,0 +1,108 @@
+package endpoint
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+)
+
+const (
+	structTagOptionStream = "stream"
+
+	mimeTypeOctetStream = "application/octet-stream"
+)
+
+// nolint:gochecknoglobals // reason: cached reflection type
+var readerType = reflect.TypeOf((*io.Reader)(nil)).Elem()
+
+// contextReader stops reading once the context is canceled or its deadline is exceeded.
+// This gives streamed bodies the same timeout semantics as buffered bodies.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func newContextReader(ctx context.Context, reader io.Reader) io.Reader {
+	return &contextReader{
+		ctx:    ctx,
+		reader: reader,
+	}
+}
+
+func (self *contextReader) Read(p []byte) (int, error) {
+	if !ShouldContinue(self.ctx) {
+		return 0, ErrRequestTimeout
+	}
+
+	return self.reader.Read(p)
+}
+
+// Close the underlying reader, if it is closable.
+func (self *contextReader) Close() error {
+	if closer, ok := self.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// supportsMimeType returns true if the MIME type is in the list of supported MIME types.
+// Streams are not marshaled, so any MIME type is allowed when there are no supported MIME types.
+func supportsMimeType(mimeType string, supportedMimeTypes []string) bool {
+	if len(supportedMimeTypes) == 0 {
+		return true
+	}
+
+	for _, supportedMimeType := range supportedMimeTypes {
+		if supportedMimeType == mimeType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NegotiateMediaType from the request Accept header without requiring a registered MimeTypeHandler.
+// This is used for streamed response bodies which are written as-is.
+// Defaults to "application/octet-stream" if there are no supported MIME types.
+func NegotiateMediaType(accept []byte, supportedMimeTypes []string) (MediaType, bool) {
+	candidates := supportedMimeTypes
+	if len(candidates) == 0 {
+		candidates = []string{mimeTypeOctetStream}
+	}
+
+	acceptValue := string(accept)
+	if len(accept) == 0 {
+		acceptValue = mimeTypeAny
+	}
+
+	mimeType, _, ok := negotiate(parseAccept(acceptValue), candidates)
+	if !ok {
+		return MediaType{}, false
+	}
+
+	mediaType, err := ParseMediaType(mimeType)
+	if err != nil {
+		return MediaType{}, false
+	}
+
+	return mediaType, true
+}
+
+func validateStreamField(structField reflect.StructField, isRequest bool) error {
+	if isRequest {
+		if structField.Type != readerType {
+			return errors.New("request stream field %s must be of type io.Reader", structField.Name)
+		}
+
+		return nil
+	}
+
+	if !structField.Type.Implements(readerType) {
+		return errors.New("response stream field %s must implement io.Reader", structField.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,420 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+)
+
+const (
+	structTagValidate = "validate"
+
+	validateRuleRequired  = "required"
+	validateRuleOmitEmpty = "omitempty"
+	validateRuleMin       = "min"
+	validateRuleMax       = "max"
+	validateRuleLen       = "len"
+	validateRuleOneOf     = "oneof"
+	validateRuleEmail     = "email"
+	validateRuleUrl       = "url"
+	validateRuleSkip      = "-"
+
+	validateOneOfSeparator = "|"
+)
+
+// nolint:gochecknoglobals // reason: cached reflection types
+var (
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// FieldError is a single request body field that failed validation.
+type FieldError struct {
+	// Field path using the JSON field names, ex: "address.city" or "items[0].name".
+	Field string
+	// Pointer is the location of the field as a JSON Pointer (RFC 6901), ex: "/address/city" or "/items/0/name".
+	Pointer string
+	Rule    string
+	Message string
+}
+
+// ValidationErrors lists every request body field that failed validation.
+// ValidationErrors is an ErrBadRequest.
+type ValidationErrors []FieldError
+
+func (self ValidationErrors) Error() string {
+	messages := make([]string, len(self))
+	for index, fieldError := range self {
+		messages[index] = fmt.Sprintf("%s %s", fieldError.Field, fieldError.Message)
+	}
+
+	return ErrBadRequest.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (self ValidationErrors) Is(target error) bool {
+	return target == ErrBadRequest
+}
+
+// Fields returns the validation message for each failing field.
+func (self ValidationErrors) Fields() map[string]string {
+	fields := make(map[string]string, len(self))
+	for _, fieldError := range self {
+		fields[fieldError.Field] = fieldError.Message
+	}
+
+	return fields
+}
+
+// Pointers returns the validation message for each failing field, keyed by JSON Pointer.
+func (self ValidationErrors) Pointers() map[string]string {
+	pointers := make(map[string]string, len(self))
+	for _, fieldError := range self {
+		pointers[fieldError.Pointer] = fieldError.Message
+	}
+
+	return pointers
+}
+
+// TagValidator validates an unmarshaled request body using the "validate" struct tag.
+// Rules are comma separated and may be given in any order:
+//   - "required" - The value must not be the zero value.
+//   - "omitempty" - Skip all other rules if the value is the zero value.
+//   - "min=<n>" / "max=<n>" - Inclusive limits. Numbers are limited by value, strings by length, and slices and maps by number of items.
+//   - "len=<n>" - Exact length of a string, slice, or map.
+//   - "oneof=<a|b|c>" - The value must be one of the "|" separated values.
+//   - "email" - The value must be an email address.
+//   - "url" - The value must be an absolute URL.
+//   - "-" - Skip the field, including nested structs.
+//
+// Nested structs and slices of structs are always validated.
+type TagValidator struct {
+	// Cache of reflect.Type to []validateField.
+	rules sync.Map
+}
+
+var (
+	_ RequestBodyValidator     = (*TagValidator)(nil)
+	_ RequestBodyTypeValidator = (*TagValidator)(nil)
+)
+
+func NewTagValidator() *TagValidator {
+	return &TagValidator{}
+}
+
+// ValidateRequestBody using the "validate" struct tags of the request body.
+func (self *TagValidator) ValidateRequestBody(ctx context.Context, requestBody any) (int, error) {
+	if err := self.Validate(requestBody); err != nil {
+		var validationErrors ValidationErrors
+		if errors.As(err, &validationErrors) {
+			return http.StatusBadRequest, err
+		}
+
+		return http.StatusInternalServerError, errors.Wrap(err, ErrInternalServerError)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Validate the value using its "validate" struct tags.
+// Returns ValidationErrors listing every failing field.
+// Any other error means the struct tags are invalid.
+func (self *TagValidator) Validate(value any) error {
+	validationErrors := ValidationErrors{}
+	if err := self.validateValue(reflect.ValueOf(value), "", "", &validationErrors); err != nil {
+		return err
+	}
+
+	if len(validationErrors) != 0 {
+		return validationErrors
+	}
+
+	return nil
+}
+
+// ValidateRequestBodyType parses the "validate" struct tags of the type and every nested struct.
+// Returns an error if any struct tag is invalid.
+func (self *TagValidator) ValidateRequestBodyType(requestBodyType reflect.Type) error {
+	return self.validateType(requestBodyType, map[reflect.Type]struct{}{})
+}
+
+func (self *TagValidator) validateType(valueType reflect.Type, visited map[reflect.Type]struct{}) error {
+	for valueType.Kind() == reflect.Ptr || valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Array {
+		valueType = valueType.Elem()
+	}
+
+	if valueType.Kind() != reflect.Struct || valueType == timeType {
+		return nil
+	}
+	// Recursive types are only parsed once.
+	if _, exists := visited[valueType]; exists {
+		return nil
+	}
+	visited[valueType] = struct{}{}
+
+	fields, err := self.structRules(valueType)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if err := self.validateType(valueType.Field(field.fieldNum).Type, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type validateRule struct {
+	name   string
+	param  string
+	number float64
+}
+
+type validateField struct {
+	fieldNum  int
+	name      string
+	omitEmpty bool
+	rules     []validateRule
+}
+
+func (self *TagValidator) validateValue(value reflect.Value, path string, pointer string, validationErrors *ValidationErrors) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() == timeType {
+			return nil
+		}
+
+		return self.validateStruct(value, path, pointer, validationErrors)
+	case reflect.Slice, reflect.Array:
+		for index := 0; index < value.Len(); index++ {
+			if err := self.validateValue(value.Index(index), fmt.Sprintf("%s[%d]", path, index), fmt.Sprintf("%s/%d", pointer, index), validationErrors); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (self *TagValidator) validateStruct(structValue reflect.Value, path string, pointer string, validationErrors *ValidationErrors) error {
+	fields, err := self.structRules(structValue.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		fieldPath := field.name
+		if path != "" {
+			fieldPath = path + "." + field.name
+		}
+		fieldPointer := pointer + "/" + strings.ReplaceAll(strings.ReplaceAll(field.name, "~", "~0"), "/", "~1")
+
+		fieldValue := structValue.Field(field.fieldNum)
+
+		if fieldValue.IsZero() && field.omitEmpty {
+			continue
+		}
+
+		for _, rule := range field.rules {
+			if message, ok := checkValidateRule(rule, fieldValue); !ok {
+				*validationErrors = append(*validationErrors, FieldError{
+					Field:   fieldPath,
+					Pointer: fieldPointer,
+					Rule:    rule.name,
+					Message: message,
+				})
+
+				// Only report the first failure for each field.
+				break
+			}
+		}
+
+		if err := self.validateValue(fieldValue, fieldPath, fieldPointer, validationErrors); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// structRules parses the "validate" struct tags once per type.
+func (self *TagValidator) structRules(structType reflect.Type) ([]validateField, error) {
+	if cached, exists := self.rules.Load(structType); exists {
+		// nolint:forcetypeassert // reason: only []validateField is stored
+		return cached.([]validateField), nil
+	}
+
+	fields := []validateField{}
+	for fieldNum := 0; fieldNum < structType.NumField(); fieldNum++ {
+		structField := structType.Field(fieldNum)
+		if !structField.IsExported() {
+			continue
+		}
+
+		tagValue := structField.Tag.Get(structTagValidate)
+		if tagValue == validateRuleSkip {
+			continue
+		}
+
+		field := validateField{
+			fieldNum: fieldNum,
+			name:     validateFieldName(structField),
+		}
+
+		if tagValue != "" {
+			for _, tagValuePart := range strings.Split(tagValue, ",") {
+				rule, err := newValidateRule(structField, strings.TrimSpace(tagValuePart))
+				if err != nil {
+					return nil, err
+				}
+
+				if rule.name == validateRuleOmitEmpty {
+					field.omitEmpty = true
+
+					continue
+				}
+
+				field.rules = append(field.rules, rule)
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	self.rules.Store(structType, fields)
+
+	return fields, nil
+}
+
+func newValidateRule(structField reflect.StructField, tagValuePart string) (validateRule, error) {
+	ruleParts := strings.SplitN(tagValuePart, "=", 2)
+	rule := validateRule{
+		name: ruleParts[0],
+	}
+	if len(ruleParts) == 2 {
+		rule.param = ruleParts[1]
+	}
+
+	switch rule.name {
+	case validateRuleRequired, validateRuleOmitEmpty, validateRuleEmail, validateRuleUrl:
+	case validateRuleOneOf:
+		if rule.param == "" {
+			return rule, errors.New("validate rule '%s' on %s requires values", rule.name, structField.Name)
+		}
+	case validateRuleMin, validateRuleMax, validateRuleLen:
+		number, err := strconv.ParseFloat(rule.param, 64)
+		if err != nil {
+			return rule, errors.New("validate rule '%s' on %s requires a number: %s", rule.name, structField.Name, rule.param)
+		}
+		rule.number = number
+	default:
+		return rule, errors.New("unknown validate rule '%s' on %s", rule.name, structField.Name)
+	}
+
+	return rule, nil
+}
+
+// validateFieldName is the JSON name of the field so that errors refer to the request body as the caller sent it.
+func validateFieldName(structField reflect.StructField) string {
+	if jsonTag, exists := structField.Tag.Lookup("json"); exists {
+		if name := strings.Split(jsonTag, ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return structField.Name
+}
+
+func checkValidateRule(rule validateRule, value reflect.Value) (string, bool) {
+	if rule.name == validateRuleRequired {
+		if value.IsZero() {
+			return "is required", false
+		}
+
+		return "", true
+	}
+
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			// Only "required" applies to missing values.
+			return "", true
+		}
+		value = value.Elem()
+	}
+
+	switch rule.name {
+	case validateRuleMin, validateRuleMax, validateRuleLen:
+		measure, size, ok := validateSize(value)
+		if !ok {
+			return fmt.Sprintf("cannot be validated with '%s'", rule.name), false
+		}
+
+		limit := strconv.FormatFloat(rule.number, 'f', -1, 64)
+		switch {
+		case rule.name == validateRuleMin && size < rule.number:
+			return fmt.Sprintf("%s at least %s", measure, limit), false
+		case rule.name == validateRuleMax && size > rule.number:
+			return fmt.Sprintf("%s at most %s", measure, limit), false
+		case rule.name == validateRuleLen && size != rule.number:
+			return fmt.Sprintf("%s exactly %s", measure, limit), false
+		}
+	case validateRuleOneOf:
+		values := strings.Split(rule.param, validateOneOfSeparator)
+		if !containsString(values, fmt.Sprintf("%v", value.Interface())) {
+			return fmt.Sprintf("must be one of [%s]", strings.Join(values, ", ")), false
+		}
+	case validateRuleEmail:
+		if value.Kind() != reflect.String {
+			return "must be an email address", false
+		}
+		address, err := mail.ParseAddress(value.String())
+		if err != nil || address.Address != value.String() {
+			return "must be an email address", false
+		}
+	case validateRuleUrl:
+		if value.Kind() != reflect.String {
+			return "must be a URL", false
+		}
+		parsedUrl, err := url.ParseRequestURI(value.String())
+		if err != nil || parsedUrl.Scheme == "" || parsedUrl.Host == "" {
+			return "must be a URL", false
+		}
+	}
+
+	return "", true
+}
+
+// validateSize returns the measure used by min, max, and len rules.
+func validateSize(value reflect.Value) (string, float64, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return "length must be", float64(utf8.RuneCountInString(value.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "number of items must be", float64(value.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "must be", float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "must be", float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return "must be", value.Float(), true
+	}
+
+	return "", 0, false
+}
@@ -0,0 +1,153 @@
+package endpoint_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/endpoint"
+)
+
+type validateAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"omitempty,len=5"`
+}
+
+type validateItem struct {
+	Name     string `json:"name" validate:"required,max=5"`
+	Quantity int    `json:"quantity" validate:"min=1,max=10"`
+}
+
+type validateModel struct {
+	Name     string           `json:"name" validate:"required,min=3"`
+	Email    string           `json:"email" validate:"omitempty,email"`
+	Website  *string          `json:"website" validate:"url"`
+	Order    string           `json:"order" validate:"oneof=asc|desc"`
+	Tags     []string         `json:"tags" validate:"max=2"`
+	Address  *validateAddress `json:"address" validate:"required"`
+	Items    []validateItem   `json:"items"`
+	Internal validateAddress  `json:"-" validate:"-"`
+}
+
+func Test_TagValidator_Validate(t *testing.T) {
+	t.Parallel()
+
+	website := "not a url"
+
+	testCases := []struct {
+		name     string
+		value    any
+		expected map[string]string
+	}{
+		{
+			name: "valid",
+			value: &validateModel{
+				Name:    "bob",
+				Email:   "bob@example.com",
+				Order:   "asc",
+				Address: &validateAddress{City: "Springfield", Zip: "12345"},
+				Items:   []validateItem{{Name: "pen", Quantity: 1}},
+			},
+		},
+		{
+			name: "every failure listed",
+			value: &validateModel{
+				Name:    "bo",
+				Email:   "bob",
+				Website: &website,
+				Order:   "up",
+				Tags:    []string{"a", "b", "c"},
+				Items:   []validateItem{{Name: "pen", Quantity: 1}, {Name: "marker", Quantity: 0}},
+			},
+			expected: map[string]string{
+				"name":              "length must be at least 3",
+				"email":             "must be an email address",
+				"website":           "must be a URL",
+				"order":             "must be one of [asc, desc]",
+				"tags":              "number of items must be at most 2",
+				"address":           "is required",
+				"items[1].name":     "length must be at most 5",
+				"items[1].quantity": "must be at least 1",
+			},
+		},
+		{
+			name: "nested struct",
+			value: &validateModel{
+				Name:    "bob",
+				Order:   "desc",
+				Address: &validateAddress{Zip: "123"},
+			},
+			expected: map[string]string{
+				"address.city": "is required",
+				"address.zip":  "length must be exactly 5",
+			},
+		},
+	}
+
+	validator := endpoint.NewTagValidator()
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validator.Validate(testCase.value)
+			if testCase.expected == nil {
+				assert.Nil(t, err)
+
+				return
+			}
+
+			var validationErrors endpoint.ValidationErrors
+			assert.True(t, errors.As(err, &validationErrors))
+			assert.True(t, errors.Is(err, endpoint.ErrBadRequest))
+			assert.Equal(t, testCase.expected, validationErrors.Fields())
+		})
+	}
+}
+
+func Test_TagValidator_Pointers(t *testing.T) {
+	t.Parallel()
+
+	err := endpoint.NewTagValidator().Validate(&validateModel{
+		Name:    "bob",
+		Order:   "asc",
+		Address: &validateAddress{City: "Springfield"},
+		Items:   []validateItem{{Name: "pen", Quantity: 1}, {Quantity: 1}},
+	})
+
+	var validationErrors endpoint.ValidationErrors
+	assert.True(t, errors.As(err, &validationErrors))
+	assert.Equal(t, map[string]string{"/items/1/name": "is required"}, validationErrors.Pointers())
+}
+
+func Test_TagValidator_invalid_tag(t *testing.T) {
+	t.Parallel()
+
+	type invalidModel struct {
+		Name string `validate:"shiny"`
+	}
+
+	err := endpoint.NewTagValidator().Validate(&invalidModel{})
+	assert.NotNil(t, err)
+
+	var validationErrors endpoint.ValidationErrors
+	assert.False(t, errors.As(err, &validationErrors))
+}
+
+func Test_TagValidator_ValidateRequestBodyType(t *testing.T) {
+	t.Parallel()
+
+	type invalidItem struct {
+		Name string `validate:"min=two"`
+	}
+	type invalidModel struct {
+		Items []*invalidItem
+	}
+
+	validator := endpoint.NewTagValidator()
+	assert.Nil(t, validator.ValidateRequestBodyType(reflect.TypeOf(&validateModel{})))
+	assert.NotNil(t, validator.ValidateRequestBodyType(reflect.TypeOf(&invalidModel{})))
+}
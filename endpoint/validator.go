@@ -1,6 +1,10 @@
 package endpoint
 
-import "github.com/wspowell/context"
+import (
+	"reflect"
+
+	"github.com/wspowell/context"
+)
 
 type RequestValidator interface {
 	// ValidateRequest and return validation failures.
@@ -9,6 +13,19 @@ type RequestValidator interface {
 	ValidateRequest(ctx context.Context, requestBody []byte) (int, error)
 }
 
+type RequestBodyValidator interface {
+	// ValidateRequestBody after it has been unmarshaled and return validation failures.
+	// Errors returned are passed straight through to the ErrorHandler.
+	// Returned status code is not used unless error is not nil.
+	ValidateRequestBody(ctx context.Context, requestBody any) (int, error)
+}
+
+// RequestBodyTypeValidator is an optional RequestBodyValidator interface.
+// The request body type is checked when the endpoint is created so that invalid rules fail at startup instead of on every request.
+type RequestBodyTypeValidator interface {
+	ValidateRequestBodyType(requestBodyType reflect.Type) error
+}
+
 type ResponseValidator interface {
 	// ValidateRequest and return validation failures.
 	// Errors returned are passed straight through to the ErrorHandler.
@@ -1,17 +1,21 @@
 package resources
 
 import (
-	"github.com/wspowell/log"
-
 	"github.com/wspowell/spiderweb/endpoint"
 	"github.com/wspowell/spiderweb/server/restful"
 	"github.com/wspowell/spiderweb/server/route"
 )
 
-func Routes(custom *restful.Server, config *endpoint.Config) {
-	getConfig := config
-	getConfig.LogConfig = log.NewConfig().WithLevel(log.LevelDebug)
+// RouteDefinitions of every resource route.
+func RouteDefinitions() []route.Route {
+	return []route.Route{
+		route.Post("/resources", &postResource{}),
+		route.Get("/resources/{id}", &getResource{}),
+	}
+}
 
-	custom.Handle(config, route.Post("/resources", &postResource{}))
-	custom.Handle(getConfig, route.Get("/resources/{id}", &getResource{}))
+func Routes(custom *restful.Server, config *endpoint.Config) {
+	for _, routeDefinition := range RouteDefinitions() {
+		custom.Handle(config, routeDefinition)
+	}
 }
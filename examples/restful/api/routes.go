@@ -11,11 +11,13 @@ import (
 	"github.com/wspowell/spiderweb/examples/restful/middleware"
 	"github.com/wspowell/spiderweb/examples/restful/resources/db"
 	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/openapi"
 	"github.com/wspowell/spiderweb/server/restful"
 )
 
-func Routes(custom *restful.Server) {
-	endpointConfig := &endpoint.Config{
+// EndpointConfig used by every route.
+func EndpointConfig() *endpoint.Config {
+	return &endpoint.Config{
 		ErrorHandler:      middleware.ErrorJsonWithCodeResponse{},
 		LogConfig:         log.NewConfig().WithLevel(log.LevelDebug),
 		MimeTypeHandlers:  endpoint.NewMimeTypeHandlers(),
@@ -26,8 +28,13 @@ func Routes(custom *restful.Server) {
 		},
 		Timeout: 30 * time.Second,
 	}
+}
+
+func Routes(custom *restful.Server) {
+	endpointConfig := EndpointConfig()
 
 	custom.HandleNotFound(endpointConfig, &noRoute{})
+	custom.HandleOpenApi("/openapi", openapi.Info{Title: "Example", Version: "1.0.0"})
 	resources.Routes(custom, endpointConfig)
 }
 
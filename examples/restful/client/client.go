@@ -0,0 +1,4 @@
+// Package client calls the example API.
+package client
+
+//go:generate go run ../cmd/clientgen -out client_gen.go
@@ -0,0 +1,79 @@
+// Code generated by spiderweb clientgen. DO NOT EDIT.
+
+package client
+
+import (
+	"net/http"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/spiderweb/client"
+)
+
+// Client calls the API.
+type Client struct {
+	client *client.Client
+}
+
+// New client for the API at the base URL. Ex: https://api.example.com
+func New(baseUrl string, options ...client.Option) *Client {
+	return &Client{
+		client: client.New(baseUrl, options...),
+	}
+}
+
+// Error returned by every method when the response status is 400 or greater.
+type Error = client.Error[ErrorJsonWithCodeResponse]
+
+// PostResource calls POST /resources.
+func (self *Client) PostResource(ctx context.Context, requestBody *MyRequestBodyModel) (*MyResponseBodyModel, error) {
+	request := client.NewRequest(http.MethodPost, "/resources")
+	request.SetBody("application/json", requestBody)
+	request.SetAccept("application/json")
+
+	responseBody := &MyResponseBodyModel{}
+	if err := client.Do[ErrorJsonWithCodeResponse](ctx, self.client, request, responseBody); err != nil {
+		return nil, err
+	}
+
+	return responseBody, nil
+}
+
+// GetResourceParams are the parameters of GetResource.
+type GetResourceParams struct {
+	// ResourceId is the path parameter 'id'.
+	ResourceId int
+}
+
+// GetResource calls GET /resources/{id}.
+func (self *Client) GetResource(ctx context.Context, params GetResourceParams) (*MyResponseBodyModel, error) {
+	request := client.NewRequest(http.MethodGet, "/resources/{id}")
+	request.SetPathParam("id", params.ResourceId)
+	request.SetAccept("application/json")
+
+	responseBody := &MyResponseBodyModel{}
+	if err := client.Do[ErrorJsonWithCodeResponse](ctx, self.client, request, responseBody); err != nil {
+		return nil, err
+	}
+
+	return responseBody, nil
+}
+
+// ErrorJsonWithCodeResponse is a copy of middleware.ErrorJsonWithCodeResponse.
+type ErrorJsonWithCodeResponse struct {
+	Code         string `json:"code"`
+	InternalCode string `json:"internal_code"`
+	Message      string `json:"message"`
+}
+
+// MyRequestBodyModel is a copy of resources.MyRequestBodyModel.
+type MyRequestBodyModel struct {
+	MyString   string `json:"myString"`
+	MyInt      int    `json:"myInt"`
+	ShouldFail bool   `json:"shouldFail"`
+}
+
+// MyResponseBodyModel is a copy of resources.MyResponseBodyModel.
+type MyResponseBodyModel struct {
+	MyString string `json:"outputString"`
+	MyInt    int    `json:"outputInt"`
+}
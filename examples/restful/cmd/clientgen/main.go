@@ -0,0 +1,35 @@
+// Command clientgen generates the typed client of the example API.
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/clientgen"
+	"github.com/wspowell/spiderweb/examples/restful/api"
+	"github.com/wspowell/spiderweb/examples/restful/api/resources"
+)
+
+func main() {
+	out := flag.String("out", "client_gen.go", "file the client is written to")
+	packageName := flag.String("package", "client", "package name of the client")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	source, err := clientgen.Generate(ctx, clientgen.Config{
+		PackageName:    *packageName,
+		EndpointConfig: api.EndpointConfig(),
+	}, resources.RouteDefinitions())
+	if err != nil {
+		log.Fatal(ctx, "failed to generate client: %v", err)
+	}
+
+	// nolint:gosec // reason: generated source is not secret
+	if err := os.WriteFile(*out, source, 0o644); err != nil {
+		log.Fatal(ctx, "failed to write client: %v", err)
+	}
+}
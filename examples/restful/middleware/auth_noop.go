@@ -4,10 +4,12 @@ import (
 	"net/http"
 
 	"github.com/wspowell/context"
+
+	"github.com/wspowell/spiderweb/endpoint"
 )
 
 type AuthNoop struct{}
 
-func (self AuthNoop) Authorization(ctx context.Context, PeekHeader func(key string) []byte) (int, error) {
+func (self AuthNoop) Authorization(ctx context.Context, requester endpoint.Requester) (int, error) {
 	return http.StatusOK, nil
 }
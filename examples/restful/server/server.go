@@ -11,11 +11,12 @@ import (
 
 func New() *restful.Server {
 	serverConfig := &restful.ServerConfig{
-		LogConfig:    log.NewConfig().WithLevel(log.LevelDebug),
-		Host:         "localhost",
-		Port:         8080,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		LogConfig:       log.NewConfig().WithLevel(log.LevelDebug),
+		Host:            "localhost",
+		Port:            8080,
+		ReadTimeout:     30 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		ShutdownSignals: restful.DefaultShutdownSignals,
 	}
 
 	custom := restful.NewServer(serverConfig)
@@ -3,15 +3,20 @@ module github.com/wspowell/spiderweb
 go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/aws/aws-lambda-go v1.26.0
 	github.com/fasthttp/router v1.4.3
+	github.com/fxamacker/cbor/v2 v2.4.0
 	github.com/google/gofuzz v1.2.0
 	github.com/opentracing/opentracing-go v1.2.0
 	github.com/stretchr/testify v1.7.0
 	github.com/valyala/fasthttp v1.30.0
+	github.com/vmihailenco/msgpack/v5 v5.3.5
 	github.com/wspowell/context v0.0.7
 	github.com/wspowell/errors v0.3.0
 	github.com/wspowell/log v0.0.9
+	google.golang.org/protobuf v1.28.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -25,6 +30,7 @@ require (
 	github.com/savsgio/gotils v0.0.0-20210907153846-c06938798b52 // indirect
 	github.com/stretchr/objx v0.1.0 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 	gopkg.in/check.v1 v1.0.0-20200227125254-8fa46927fb4f // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776 // indirect
 )
@@ -0,0 +1,188 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/wspowell/errors"
+)
+
+var ErrInvalidCatalog = errors.New("invalid catalog")
+
+// Catalog of message templates by language.
+type Catalog struct {
+	defaultLanguage string
+
+	mutex     sync.RWMutex
+	messages  map[string]map[string]string
+	fallbacks map[string][]string
+}
+
+// NewCatalog with the language used when none of the request languages have a message.
+func NewCatalog(defaultLanguage string) *Catalog {
+	return &Catalog{
+		defaultLanguage: strings.ToLower(defaultLanguage),
+		messages:        map[string]map[string]string{},
+		fallbacks:       map[string][]string{},
+	}
+}
+
+// Add message templates, by key, for the language.
+func (self *Catalog) Add(language string, messages map[string]string) {
+	language = strings.ToLower(language)
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.messages[language] == nil {
+		self.messages[language] = map[string]string{}
+	}
+	for key, message := range messages {
+		self.messages[language][key] = message
+	}
+}
+
+// SetFallbacks of a language, which are tried after the language and before its parents.
+// Ex: "pt-br" may fall back to "pt-pt" before "pt".
+func (self *Catalog) SetFallbacks(language string, fallbacks ...string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	lowerFallbacks := make([]string, 0, len(fallbacks))
+	for _, fallback := range fallbacks {
+		lowerFallbacks = append(lowerFallbacks, strings.ToLower(fallback))
+	}
+	self.fallbacks[strings.ToLower(language)] = lowerFallbacks
+}
+
+// LoadFS loads every "<language>.json" and "<language>.toml" file in the directory, such as an embed.FS.
+// Nested objects and tables are flattened into dotted keys. Ex: {"user": {"not_found": "..."}} is "user.not_found"
+func (self *Catalog) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return errors.Wrap(err, ErrInvalidCatalog)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		extension := path.Ext(entry.Name())
+		if extension != ".json" && extension != ".toml" {
+			continue
+		}
+
+		fileBytes, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return errors.Wrap(err, ErrInvalidCatalog)
+		}
+
+		values := map[string]any{}
+		if extension == ".json" {
+			err = json.Unmarshal(fileBytes, &values)
+		} else {
+			err = toml.Unmarshal(fileBytes, &values)
+		}
+		if err != nil {
+			return errors.Wrap(errors.New("%s: %v", entry.Name(), err), ErrInvalidCatalog)
+		}
+
+		messages := map[string]string{}
+		flatten("", values, messages)
+		self.Add(strings.TrimSuffix(entry.Name(), extension), messages)
+	}
+
+	return nil
+}
+
+func flatten(prefix string, values map[string]any, messages map[string]string) {
+	for key, value := range values {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch typedValue := value.(type) {
+		case map[string]any:
+			flatten(key, typedValue, messages)
+		case string:
+			messages[key] = typedValue
+		default:
+			messages[key] = fmt.Sprint(typedValue)
+		}
+	}
+}
+
+// Localize the message in the first of the languages that has it.
+// Each language is followed by its fallbacks and then its parents. The default language is tried last.
+// Returns false if no language has the message.
+func (self *Catalog) Localize(languages []string, key string, args Args) (string, bool) {
+	template, _, ok := self.lookup(languages, key)
+	if !ok {
+		return "", false
+	}
+
+	return Format(template, args), true
+}
+
+// LocalizeError localizes the message of a Localizable error.
+// Returns the language of the message, or false if the error is not Localizable or no language has the message.
+func (self *Catalog) LocalizeError(languages []string, err error) (string, string, bool) {
+	var localizable Localizable
+	if !errors.As(err, &localizable) {
+		return "", "", false
+	}
+
+	template, language, ok := self.lookup(languages, localizable.MessageKey())
+	if !ok {
+		return "", "", false
+	}
+
+	return Format(template, localizable.MessageArgs()), language, true
+}
+
+func (self *Catalog) lookup(languages []string, key string) (string, string, bool) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	for _, language := range self.chain(languages) {
+		if template, exists := self.messages[language][key]; exists {
+			return template, language, true
+		}
+	}
+
+	return "", "", false
+}
+
+// chain of languages to try, in order, without duplicates.
+func (self *Catalog) chain(languages []string) []string {
+	chain := []string{}
+	seen := map[string]bool{}
+	add := func(language string) {
+		if !seen[language] {
+			seen[language] = true
+			chain = append(chain, language)
+		}
+	}
+
+	for _, language := range languages {
+		for _, parent := range parents(strings.ToLower(language)) {
+			add(parent)
+			for _, fallback := range self.fallbacks[parent] {
+				add(fallback)
+			}
+		}
+	}
+	if self.defaultLanguage != "" {
+		for _, parent := range parents(self.defaultLanguage) {
+			add(parent)
+		}
+	}
+
+	return chain
+}
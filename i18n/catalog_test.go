@@ -0,0 +1,90 @@
+package i18n_test
+
+import (
+	"embed"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/i18n"
+)
+
+//go:embed testdata/locales
+var locales embed.FS
+
+func newTestCatalog(t *testing.T) *i18n.Catalog {
+	t.Helper()
+
+	catalog := i18n.NewCatalog("en")
+	assert.Nil(t, catalog.LoadFS(locales, "testdata/locales"))
+	catalog.SetFallbacks("pt-BR", "pt-PT")
+
+	return catalog
+}
+
+func Test_Catalog_Localize(t *testing.T) {
+	t.Parallel()
+
+	catalog := newTestCatalog(t)
+
+	testCases := []struct {
+		name            string
+		acceptLanguage  string
+		key             string
+		expectedMessage string
+		expectedOk      bool
+	}{
+		{name: "exact", acceptLanguage: "de", key: "greeting", expectedMessage: "Hallo", expectedOk: true},
+		{name: "parent", acceptLanguage: "de-CH", key: "user.not_found", expectedMessage: "Benutzer 42 wurde nicht gefunden.", expectedOk: true},
+		{name: "quality", acceptLanguage: "en;q=0.5, de-AT", key: "greeting", expectedMessage: "Hallo", expectedOk: true},
+		{name: "missing in language", acceptLanguage: "de", key: "user.invalid_name", expectedMessage: "Name must be at most 10 characters.", expectedOk: true},
+		{name: "fallback", acceptLanguage: "pt-BR", key: "user.not_found", expectedMessage: "O utilizador 42 não foi encontrado.", expectedOk: true},
+		{name: "rejected language", acceptLanguage: "de;q=0, fr", key: "greeting", expectedMessage: "Hello", expectedOk: true},
+		{name: "no language", acceptLanguage: "", key: "greeting", expectedMessage: "Hello", expectedOk: true},
+		{name: "unknown key", acceptLanguage: "de", key: "user.unknown", expectedMessage: "", expectedOk: false},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			message, ok := catalog.Localize(i18n.ParseAcceptLanguage(testCase.acceptLanguage), testCase.key, i18n.Args{"id": 42, "max": 10})
+			assert.Equal(t, testCase.expectedOk, ok)
+			assert.Equal(t, testCase.expectedMessage, message)
+		})
+	}
+}
+
+func Test_Catalog_LocalizeError(t *testing.T) {
+	t.Parallel()
+
+	catalog := newTestCatalog(t)
+
+	err := errors.Wrap(errors.New("no rows"), i18n.NewMessage("user.not_found", "user {id} not found", i18n.Args{"id": "abc"}))
+	assert.Equal(t, "user abc not found", err.Error())
+
+	message, language, ok := catalog.LocalizeError([]string{"de-de"}, err)
+	assert.True(t, ok)
+	assert.Equal(t, "de", language)
+	assert.Equal(t, "Benutzer abc wurde nicht gefunden.", message)
+
+	_, _, ok = catalog.LocalizeError([]string{"de"}, errors.New("not localizable"))
+	assert.False(t, ok)
+}
+
+func Test_ParseAcceptLanguage(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"fr-ch", "fr", "en", "de"}, i18n.ParseAcceptLanguage("fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5"))
+	assert.Equal(t, []string{"en"}, i18n.ParseAcceptLanguage("da;q=0, en, ;q=1, es;q=invalid"))
+	assert.Equal(t, []string{}, i18n.ParseAcceptLanguage(""))
+}
+
+func Test_Format(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "User 42 has {unknown} items", i18n.Format("User {id} has {unknown} items", i18n.Args{"id": 42}))
+	assert.Equal(t, "unbalanced {id", i18n.Format("unbalanced {id", i18n.Args{"id": 42}))
+}
@@ -0,0 +1,66 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseAcceptLanguage returns the language tags of an Accept-Language header in order of preference (RFC 7231, section 5.3.5).
+// Tags are lowercase. The "*" range and tags with a quality of zero are skipped.
+func ParseAcceptLanguage(acceptLanguage string) []string {
+	type languageRange struct {
+		tag     string
+		quality float64
+	}
+
+	ranges := []languageRange{}
+	for _, value := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(value, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsedQuality, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil || parsedQuality < 0 || parsedQuality > 1 {
+				continue
+			}
+			quality = parsedQuality
+		}
+		if quality == 0 {
+			continue
+		}
+
+		ranges = append(ranges, languageRange{
+			tag:     tag,
+			quality: quality,
+		})
+	}
+
+	sort.SliceStable(ranges, func(i int, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	languages := make([]string, 0, len(ranges))
+	for _, languageRange := range ranges {
+		languages = append(languages, languageRange.tag)
+	}
+
+	return languages
+}
+
+// parents of a language tag, most specific first. Ex: "de-ch-1996" -> "de-ch-1996", "de-ch", "de"
+func parents(tag string) []string {
+	chain := []string{tag}
+	for {
+		index := strings.LastIndexByte(tag, '-')
+		if index == -1 {
+			return chain
+		}
+		tag = tag[:index]
+		chain = append(chain, tag)
+	}
+}
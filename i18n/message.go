@@ -0,0 +1,84 @@
+// Package i18n localizes messages with catalogs and the Accept-Language header.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Args of a message, referenced as "{name}" in message templates.
+type Args map[string]any
+
+// Localizable is implemented by errors that have a localized message.
+type Localizable interface {
+	MessageKey() string
+	MessageArgs() Args
+}
+
+// Message is an error with a localized message.
+type Message struct {
+	Key  string
+	Args Args
+	// Default message template used when the key is not in a catalog. Defaults to the key.
+	Default string
+}
+
+var _ Localizable = (*Message)(nil)
+
+// NewMessage error with a message key, default message template, and args.
+func NewMessage(key string, defaultMessage string, args Args) *Message {
+	return &Message{
+		Key:     key,
+		Args:    args,
+		Default: defaultMessage,
+	}
+}
+
+func (self *Message) MessageKey() string {
+	return self.Key
+}
+
+func (self *Message) MessageArgs() Args {
+	return self.Args
+}
+
+// Error is the default message.
+func (self *Message) Error() string {
+	if self.Default == "" {
+		return self.Key
+	}
+
+	return Format(self.Default, self.Args)
+}
+
+// Format replaces each "{name}" in the template with the arg of the same name.
+// Placeholders without an arg are left as is.
+func Format(template string, args Args) string {
+	if len(args) == 0 || !strings.Contains(template, "{") {
+		return template
+	}
+
+	formatted := strings.Builder{}
+	for {
+		start := strings.IndexByte(template, '{')
+		if start == -1 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end == -1 {
+			break
+		}
+		end += start
+
+		formatted.WriteString(template[:start])
+		if value, exists := args[template[start+1:end]]; exists {
+			formatted.WriteString(fmt.Sprint(value))
+		} else {
+			formatted.WriteString(template[start : end+1])
+		}
+		template = template[end+1:]
+	}
+	formatted.WriteString(template)
+
+	return formatted.String()
+}
@@ -0,0 +1,311 @@
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	structTagJson        = "json"
+	structTagValidate    = "validate"
+	structTagDescription = "description"
+)
+
+// nolint:gochecknoglobals // reason: cached reflection types
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Generator creates schemas from Go types using the same rules as encoding/json.
+// Named struct types are generated once as definitions and referenced using "$ref".
+//
+// Struct fields may use the following tags:
+//   - `json:"<name>"` - Property name. Fields named "-" are skipped.
+//   - `validate:"<rules>"` - The endpoint.TagValidator rules are converted to schema keywords
+//     ("required", "min", "max", "len", "oneof", "email", "url").
+//   - `description:"<text>"` - Property description.
+type Generator struct {
+	// RefPrefix of definition references. Defaults to DefsRefPrefix.
+	RefPrefix string
+	// Definitions generated for named struct types.
+	Definitions map[string]*Schema
+
+	definitionTypes map[string]reflect.Type
+}
+
+func NewGenerator(refPrefix string) *Generator {
+	if refPrefix == "" {
+		refPrefix = DefsRefPrefix
+	}
+
+	return &Generator{
+		RefPrefix:       refPrefix,
+		Definitions:     map[string]*Schema{},
+		definitionTypes: map[string]reflect.Type{},
+	}
+}
+
+// Generate a standalone schema for the Go type, with all definitions included in "$defs".
+func Generate(goType reflect.Type) *Schema {
+	generator := NewGenerator(DefsRefPrefix)
+	schema := generator.Schema(goType)
+
+	if len(generator.Definitions) != 0 {
+		schema.Defs = generator.Definitions
+	}
+
+	return schema
+}
+
+// Schema for the Go type. Named struct types are added to Definitions.
+func (self *Generator) Schema(goType reflect.Type) *Schema {
+	for goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+
+	switch {
+	case goType == timeType:
+		return &Schema{Type: Types{TypeString}, Format: FormatDateTime}
+	case goType == rawMessageType:
+		return &Schema{}
+	case goType.Kind() == reflect.Struct && goType.Name() != "" && !implementsMarshaler(goType):
+		return &Schema{Ref: self.RefPrefix + self.define(goType)}
+	}
+
+	return self.inlineSchema(goType)
+}
+
+func (self *Generator) inlineSchema(goType reflect.Type) *Schema {
+	if implementsMarshaler(goType) {
+		// Custom JSON encodings are unknown, except TextMarshaler which is always a string.
+		if goType.Implements(textMarshalerType) || reflect.PtrTo(goType).Implements(textMarshalerType) {
+			return &Schema{Type: Types{TypeString}}
+		}
+
+		return &Schema{}
+	}
+
+	switch goType.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: Types{TypeBoolean}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: Types{TypeInteger}}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum := 0.0
+
+		return &Schema{Type: Types{TypeInteger}, Minimum: &minimum}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{TypeNumber}}
+	case reflect.String:
+		return &Schema{Type: Types{TypeString}}
+	case reflect.Slice, reflect.Array:
+		if goType.Elem().Kind() == reflect.Uint8 && goType.Kind() == reflect.Slice {
+			// encoding/json encodes []byte as a base64 string.
+			return &Schema{Type: Types{TypeString}, Format: FormatByte}
+		}
+
+		return &Schema{Type: Types{TypeArray}, Items: self.nullableSchema(goType.Elem())}
+	case reflect.Map:
+		return &Schema{Type: Types{TypeObject}, AdditionalProperties: self.nullableSchema(goType.Elem())}
+	case reflect.Struct:
+		return self.structSchema(goType)
+	}
+
+	// Interfaces, and anything else, may be any value.
+	return &Schema{}
+}
+
+// nullableSchema allows null for types that encoding/json may encode as null.
+func (self *Generator) nullableSchema(goType reflect.Type) *Schema {
+	schema := self.Schema(goType)
+
+	switch goType.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		if goType.Kind() == reflect.Slice && goType.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+
+		if schema.Ref != "" {
+			return &Schema{AnyOf: []*Schema{schema, {Type: Types{TypeNull}}}}
+		}
+		if len(schema.Type) != 0 {
+			schema.Type = append(schema.Type, TypeNull)
+		}
+	}
+
+	return schema
+}
+
+func (self *Generator) define(goType reflect.Type) string {
+	name := goType.Name()
+	if existingType, exists := self.definitionTypes[name]; exists && existingType != goType {
+		// Same name from a different package.
+		name = strings.ReplaceAll(goType.PkgPath(), "/", "_") + "_" + name
+	}
+
+	if _, exists := self.definitionTypes[name]; exists {
+		return name
+	}
+
+	// Register before generating so recursive types reference the definition.
+	self.definitionTypes[name] = goType
+	self.Definitions[name] = &Schema{}
+	*self.Definitions[name] = *self.structSchema(goType)
+
+	return name
+}
+
+func (self *Generator) structSchema(goType reflect.Type) *Schema {
+	schema := &Schema{
+		Type:       Types{TypeObject},
+		Properties: map[string]*Schema{},
+	}
+
+	self.addStructFields(schema, goType)
+
+	if len(schema.Properties) == 0 {
+		schema.Properties = nil
+	}
+
+	return schema
+}
+
+func (self *Generator) addStructFields(schema *Schema, goType reflect.Type) {
+	for fieldNum := 0; fieldNum < goType.NumField(); fieldNum++ {
+		structField := goType.Field(fieldNum)
+
+		jsonTag := structField.Tag.Get(structTagJson)
+		if jsonTag == "-" {
+			continue
+		}
+		name := strings.Split(jsonTag, ",")[0]
+
+		fieldType := structField.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		// Embedded structs without a name are promoted, the same as encoding/json.
+		if structField.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			self.addStructFields(schema, fieldType)
+
+			continue
+		}
+
+		if !structField.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = structField.Name
+		}
+
+		property := self.nullableSchema(structField.Type)
+		if description := structField.Tag.Get(structTagDescription); description != "" {
+			if property.Ref != "" {
+				property = &Schema{AllOf: []*Schema{property}}
+			}
+			property.Description = description
+		}
+
+		if applyValidateRules(property, structField.Tag.Get(structTagValidate)) {
+			schema.Required = append(schema.Required, name)
+		}
+
+		schema.Properties[name] = property
+	}
+}
+
+// applyValidateRules converts "validate" struct tag rules into schema keywords.
+// Returns true if the field is required.
+func applyValidateRules(schema *Schema, tagValue string) bool {
+	if tagValue == "" || tagValue == "-" {
+		return false
+	}
+
+	isRequired := false
+	for _, rule := range strings.Split(tagValue, ",") {
+		ruleParts := strings.SplitN(strings.TrimSpace(rule), "=", 2)
+		var param string
+		if len(ruleParts) == 2 {
+			param = ruleParts[1]
+		}
+
+		switch ruleParts[0] {
+		case "required":
+			isRequired = true
+		case "email":
+			schema.Format = FormatEmail
+		case "url":
+			schema.Format = FormatUri
+		case "oneof":
+			for _, value := range strings.Split(param, "|") {
+				schema.Enum = append(schema.Enum, enumValue(schema, value))
+			}
+		case "min", "max", "len":
+			number, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			applyLimit(schema, ruleParts[0], number)
+		}
+	}
+
+	return isRequired
+}
+
+func applyLimit(schema *Schema, rule string, number float64) {
+	count := int(number)
+
+	switch {
+	case schema.Type.Has(TypeString):
+		if rule != "max" {
+			schema.MinLength = &count
+		}
+		if rule != "min" {
+			schema.MaxLength = &count
+		}
+	case schema.Type.Has(TypeArray):
+		if rule != "max" {
+			schema.MinItems = &count
+		}
+		if rule != "min" {
+			schema.MaxItems = &count
+		}
+	case schema.Type.Has(TypeInteger) || schema.Type.Has(TypeNumber):
+		if rule == "min" {
+			schema.Minimum = &number
+		}
+		if rule == "max" {
+			schema.Maximum = &number
+		}
+	}
+}
+
+// enumValue converts the "oneof" value to the JSON type of the schema.
+func enumValue(schema *Schema, value string) any {
+	if schema.Type.Has(TypeInteger) || schema.Type.Has(TypeNumber) {
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number
+		}
+	}
+	if schema.Type.Has(TypeBoolean) {
+		if boolean, err := strconv.ParseBool(value); err == nil {
+			return boolean
+		}
+	}
+
+	return value
+}
+
+func implementsMarshaler(goType reflect.Type) bool {
+	return goType.Implements(jsonMarshalerType) || reflect.PtrTo(goType).Implements(jsonMarshalerType) ||
+		goType.Implements(textMarshalerType) || reflect.PtrTo(goType).Implements(textMarshalerType)
+}
@@ -0,0 +1,79 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wspowell/spiderweb/jsonschema"
+)
+
+type generateAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type generateEmbedded struct {
+	Id string `json:"id"`
+}
+
+type generateNode struct {
+	Children []generateNode `json:"children"`
+}
+
+type generateModel struct {
+	generateEmbedded
+	Name      string            `json:"name" validate:"required,min=3,max=10" description:"Display name."`
+	Email     string            `json:"email,omitempty" validate:"omitempty,email"`
+	Order     string            `json:"order" validate:"oneof=asc|desc"`
+	Count     uint              `json:"count" validate:"max=5"`
+	Tags      []string          `json:"tags" validate:"max=2"`
+	Labels    map[string]string `json:"labels"`
+	Address   *generateAddress  `json:"address"`
+	Tree      generateNode      `json:"tree"`
+	CreatedAt time.Time         `json:"created_at"`
+	Data      []byte            `json:"data"`
+	Any       any               `json:"any"`
+	Skipped   string            `json:"-"`
+	internal  string
+}
+
+func Test_Generate(t *testing.T) {
+	t.Parallel()
+
+	schema := jsonschema.Generate(reflect.TypeOf(generateModel{}))
+
+	schemaBytes, err := json.Marshal(schema)
+	assert.Nil(t, err)
+
+	expected := `{"$ref":"#/$defs/generateModel","$defs":{` +
+		`"generateAddress":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]},` +
+		`"generateModel":{"type":"object","properties":{` +
+		`"address":{"anyOf":[{"$ref":"#/$defs/generateAddress"},{"type":"null"}]},` +
+		`"any":{},` +
+		`"count":{"type":"integer","minimum":0,"maximum":5},` +
+		`"created_at":{"type":"string","format":"date-time"},` +
+		`"data":{"type":"string","format":"byte"},` +
+		`"email":{"type":"string","format":"email"},` +
+		`"id":{"type":"string"},` +
+		`"labels":{"type":["object","null"],"additionalProperties":{"type":"string"}},` +
+		`"name":{"description":"Display name.","type":"string","minLength":3,"maxLength":10},` +
+		`"order":{"type":"string","enum":["asc","desc"]},` +
+		`"tags":{"type":["array","null"],"items":{"type":"string"},"maxItems":2},` +
+		`"tree":{"$ref":"#/$defs/generateNode"}},` +
+		`"required":["name"]},` +
+		`"generateNode":{"type":"object","properties":{"children":{"type":["array","null"],"items":{"$ref":"#/$defs/generateNode"}}}}}}`
+	assert.Equal(t, expected, string(schemaBytes))
+}
+
+func Test_Generator_RefPrefix(t *testing.T) {
+	t.Parallel()
+
+	generator := jsonschema.NewGenerator(jsonschema.ComponentsRefPrefix)
+	schema := generator.Schema(reflect.TypeOf([]generateAddress{}))
+
+	assert.Equal(t, "#/components/schemas/generateAddress", schema.Items.Ref)
+	assert.Contains(t, generator.Definitions, "generateAddress")
+}
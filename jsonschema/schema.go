@@ -0,0 +1,153 @@
+// Package jsonschema describes and validates JSON request and response bodies.
+//
+// Schemas follow JSON Schema draft 2020-12, the dialect used by OpenAPI 3.1.
+// Only the keywords needed to describe request and response bodies are supported.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/wspowell/errors"
+)
+
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+
+	FormatDateTime = "date-time"
+	FormatDate     = "date"
+	FormatEmail    = "email"
+	FormatUri      = "uri"
+	FormatUuid     = "uuid"
+	FormatByte     = "byte"
+	FormatBinary   = "binary"
+
+	// DefsRefPrefix is the prefix of references to Schema.Defs.
+	DefsRefPrefix = "#/$defs/"
+	// ComponentsRefPrefix is the prefix of references to OpenAPI component schemas.
+	ComponentsRefPrefix = "#/components/schemas/"
+)
+
+// Schema is a JSON Schema.
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Defs        map[string]*Schema `json:"$defs,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        Types              `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Enum        []any              `json:"enum,omitempty"`
+	Default     any                `json:"default,omitempty"`
+	// Nullable is the OpenAPI 3.0 equivalent of including "null" in Type.
+	// It is only read from loaded documents and never generated.
+	Nullable bool `json:"nullable,omitempty"`
+
+	// Objects.
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	// Arrays.
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	// Strings.
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+
+	// Numbers.
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// Composition.
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+}
+
+// schemaFields prevents UnmarshalJSON from recursing.
+type schemaFields Schema
+
+// UnmarshalJSON supports boolean schemas.
+// "true" accepts any value and "false" accepts no value.
+func (self *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*self = Schema{}
+
+		return nil
+	case "false":
+		*self = Schema{Not: &Schema{}}
+
+		return nil
+	}
+
+	fields := schemaFields{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return errors.Wrap(err, errors.New("invalid schema"))
+	}
+	*self = Schema(fields)
+
+	return nil
+}
+
+// RefName returns the definition name of a "$ref" to either Schema.Defs or OpenAPI component schemas.
+func RefName(ref string) (string, bool) {
+	if strings.HasPrefix(ref, DefsRefPrefix) {
+		return strings.TrimPrefix(ref, DefsRefPrefix), true
+	}
+	if strings.HasPrefix(ref, ComponentsRefPrefix) {
+		return strings.TrimPrefix(ref, ComponentsRefPrefix), true
+	}
+
+	return "", false
+}
+
+// Types is the "type" keyword, which may be a single type or a list of types.
+type Types []string
+
+// Has returns true if the type is allowed.
+func (self Types) Has(typeName string) bool {
+	for _, allowed := range self {
+		if allowed == typeName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (self Types) MarshalJSON() ([]byte, error) {
+	if len(self) == 1 {
+		return json.Marshal(self[0])
+	}
+
+	return json.Marshal([]string(self))
+}
+
+func (self *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*self = Types{single}
+
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return errors.Wrap(err, errors.New("invalid schema type: %s", data))
+	}
+	*self = Types(multiple)
+
+	return nil
+}
@@ -0,0 +1,482 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wspowell/errors"
+)
+
+var (
+	ErrInvalidJson = errors.New("invalid JSON")
+)
+
+// nolint:gochecknoglobals // reason: compiled patterns are cached since schemas are reused for every request
+var patternCache sync.Map
+
+// nolint:gochecknoglobals // reason: compiled once
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidationError is a single value that failed validation.
+type ValidationError struct {
+	// Pointer is the location of the value as a JSON Pointer (RFC 6901). Ex: /items/0/name
+	Pointer string
+	// Keyword is the schema keyword that failed. Ex: required
+	Keyword string
+	Message string
+}
+
+// ValidationErrors lists every value that failed validation.
+type ValidationErrors []ValidationError
+
+func (self ValidationErrors) Error() string {
+	messages := make([]string, len(self))
+	for index, validationError := range self {
+		pointer := validationError.Pointer
+		if pointer == "" {
+			pointer = "/"
+		}
+		messages[index] = fmt.Sprintf("%s %s", pointer, validationError.Message)
+	}
+
+	return "schema validation failed: " + strings.Join(messages, "; ")
+}
+
+// Validate the JSON document against the schema.
+// Returns ValidationErrors listing every failing value, or ErrInvalidJson if the document cannot be parsed.
+// Any other error means the schema is invalid.
+func (self *Schema) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return errors.Wrap(err, ErrInvalidJson)
+	}
+
+	// The document is a single value, so anything after it is invalid. Ex: {}{}
+	var trailing any
+	if err := decoder.Decode(&trailing); !errors.Is(err, io.EOF) {
+		return errors.Wrap(errors.New("unexpected data after JSON value"), ErrInvalidJson)
+	}
+
+	return self.ValidateValue(value)
+}
+
+// ValidateValue validates a decoded JSON value against the schema.
+// Numbers may be json.Number or float64.
+func (self *Schema) ValidateValue(value any) error {
+	validation := &validation{
+		root: self,
+	}
+
+	if err := validation.validate(self, value, ""); err != nil {
+		return err
+	}
+
+	if len(validation.errors) != 0 {
+		return validation.errors
+	}
+
+	return nil
+}
+
+type validation struct {
+	root   *Schema
+	errors ValidationErrors
+}
+
+func (self *validation) fail(pointer string, keyword string, format string, values ...any) {
+	self.errors = append(self.errors, ValidationError{
+		Pointer: pointer,
+		Keyword: keyword,
+		Message: fmt.Sprintf(format, values...),
+	})
+}
+
+// isValid checks the value against the schema without recording failures.
+func (self *validation) isValid(schema *Schema, value any, pointer string) (bool, error) {
+	subValidation := &validation{
+		root: self.root,
+	}
+	if err := subValidation.validate(schema, value, pointer); err != nil {
+		return false, err
+	}
+
+	return len(subValidation.errors) == 0, nil
+}
+
+func (self *validation) resolve(schema *Schema) (*Schema, error) {
+	for depth := 0; schema.Ref != ""; depth++ {
+		if depth > 32 {
+			return nil, errors.New("schema reference loop: %s", schema.Ref)
+		}
+
+		name, ok := RefName(schema.Ref)
+		if !ok {
+			return nil, errors.New("unsupported schema reference: %s", schema.Ref)
+		}
+
+		definition, exists := self.root.Defs[name]
+		if !exists {
+			return nil, errors.New("schema reference not found: %s", schema.Ref)
+		}
+		schema = definition
+	}
+
+	return schema, nil
+}
+
+func (self *validation) validate(schema *Schema, value any, pointer string) error {
+	schema, err := self.resolve(schema)
+	if err != nil {
+		return err
+	}
+
+	if schema.Not != nil {
+		valid, err := self.isValid(schema.Not, value, pointer)
+		if err != nil {
+			return err
+		}
+		if valid {
+			self.fail(pointer, "not", "is not allowed")
+
+			return nil
+		}
+	}
+
+	if value == nil && schema.Nullable {
+		return nil
+	}
+
+	if len(schema.Type) != 0 && !matchesType(schema.Type, value) {
+		self.fail(pointer, "type", "must be of type %s", strings.Join(schema.Type, " or "))
+
+		// Other keywords are meaningless for the wrong type.
+		return nil
+	}
+
+	if len(schema.Enum) != 0 && !containsValue(schema.Enum, value) {
+		self.fail(pointer, "enum", "must be one of %s", formatValues(schema.Enum))
+	}
+
+	if err := self.validateComposition(schema, value, pointer); err != nil {
+		return err
+	}
+
+	switch typedValue := value.(type) {
+	case map[string]any:
+		return self.validateObject(schema, typedValue, pointer)
+	case []any:
+		return self.validateArray(schema, typedValue, pointer)
+	case string:
+		return self.validateString(schema, typedValue, pointer)
+	case json.Number, float64:
+		self.validateNumber(schema, toFloat(typedValue), pointer)
+	}
+
+	return nil
+}
+
+func (self *validation) validateComposition(schema *Schema, value any, pointer string) error {
+	for _, allOf := range schema.AllOf {
+		if err := self.validate(allOf, value, pointer); err != nil {
+			return err
+		}
+	}
+
+	if len(schema.AnyOf) != 0 {
+		matched := false
+		for _, anyOf := range schema.AnyOf {
+			valid, err := self.isValid(anyOf, value, pointer)
+			if err != nil {
+				return err
+			}
+			if valid {
+				matched = true
+
+				break
+			}
+		}
+		if !matched {
+			self.fail(pointer, "anyOf", "must match at least one schema")
+		}
+	}
+
+	if len(schema.OneOf) != 0 {
+		matches := 0
+		for _, oneOf := range schema.OneOf {
+			valid, err := self.isValid(oneOf, value, pointer)
+			if err != nil {
+				return err
+			}
+			if valid {
+				matches++
+			}
+		}
+		if matches != 1 {
+			self.fail(pointer, "oneOf", "must match exactly one schema")
+		}
+	}
+
+	return nil
+}
+
+func (self *validation) validateObject(schema *Schema, object map[string]any, pointer string) error {
+	for _, required := range schema.Required {
+		if _, exists := object[required]; !exists {
+			self.fail(pointer+"/"+escapePointer(required), "required", "is required")
+		}
+	}
+
+	// Sort the properties so that errors are in a stable order.
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPointer := pointer + "/" + escapePointer(name)
+
+		if property, exists := schema.Properties[name]; exists {
+			if err := self.validate(property, object[name], propertyPointer); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if schema.AdditionalProperties != nil {
+			if err := self.validate(schema.AdditionalProperties, object[name], propertyPointer); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (self *validation) validateArray(schema *Schema, array []any, pointer string) error {
+	if schema.MinItems != nil && len(array) < *schema.MinItems {
+		self.fail(pointer, "minItems", "must have at least %d items", *schema.MinItems)
+	}
+	if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+		self.fail(pointer, "maxItems", "must have at most %d items", *schema.MaxItems)
+	}
+
+	if schema.Items != nil {
+		for index, item := range array {
+			if err := self.validate(schema.Items, item, pointer+"/"+strconv.Itoa(index)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (self *validation) validateString(schema *Schema, value string, pointer string) error {
+	length := utf8.RuneCountInString(value)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		self.fail(pointer, "minLength", "length must be at least %d", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		self.fail(pointer, "maxLength", "length must be at most %d", *schema.MaxLength)
+	}
+
+	if schema.Pattern != "" {
+		pattern, err := compilePattern(schema.Pattern)
+		if err != nil {
+			return err
+		}
+		if !pattern.MatchString(value) {
+			self.fail(pointer, "pattern", "must match pattern %s", schema.Pattern)
+		}
+	}
+
+	if schema.Format != "" && !matchesFormat(schema.Format, value) {
+		self.fail(pointer, "format", "must be a valid %s", schema.Format)
+	}
+
+	return nil
+}
+
+func (self *validation) validateNumber(schema *Schema, value float64, pointer string) {
+	if schema.Minimum != nil && value < *schema.Minimum {
+		self.fail(pointer, "minimum", "must be at least %s", formatNumber(*schema.Minimum))
+	}
+	if schema.Maximum != nil && value > *schema.Maximum {
+		self.fail(pointer, "maximum", "must be at most %s", formatNumber(*schema.Maximum))
+	}
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if cached, exists := patternCache.Load(pattern); exists {
+		// nolint:forcetypeassert // reason: only *regexp.Regexp is stored
+		return cached.(*regexp.Regexp), nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.New("invalid schema pattern: %s", pattern))
+	}
+	patternCache.Store(pattern, compiled)
+
+	return compiled, nil
+}
+
+// matchesFormat validates the well known formats. Unknown formats are annotations only.
+func matchesFormat(format string, value string) bool {
+	switch format {
+	case FormatDateTime:
+		_, err := time.Parse(time.RFC3339, value)
+
+		return err == nil
+	case FormatDate:
+		_, err := time.Parse("2006-01-02", value)
+
+		return err == nil
+	case FormatEmail:
+		address, err := mail.ParseAddress(value)
+
+		return err == nil && address.Address == value
+	case FormatUri:
+		parsedUrl, err := url.Parse(value)
+
+		return err == nil && parsedUrl.Scheme != ""
+	case FormatUuid:
+		return uuidPattern.MatchString(value)
+	}
+
+	return true
+}
+
+func matchesType(types Types, value any) bool {
+	for _, typeName := range types {
+		switch typeName {
+		case TypeNull:
+			if value == nil {
+				return true
+			}
+		case TypeObject:
+			if _, ok := value.(map[string]any); ok {
+				return true
+			}
+		case TypeArray:
+			if _, ok := value.([]any); ok {
+				return true
+			}
+		case TypeString:
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case TypeBoolean:
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case TypeNumber:
+			if isNumber(value) {
+				return true
+			}
+		case TypeInteger:
+			if isNumber(value) {
+				number := toFloat(value)
+				if number == math.Trunc(number) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func isNumber(value any) bool {
+	switch value.(type) {
+	case json.Number, float64:
+		return true
+	}
+
+	return false
+}
+
+func toFloat(value any) float64 {
+	switch number := value.(type) {
+	case json.Number:
+		parsed, _ := number.Float64()
+
+		return parsed
+	case float64:
+		return number
+	}
+
+	return 0
+}
+
+// containsValue compares JSON values by their JSON encoding so that numbers compare by value.
+func containsValue(values []any, value any) bool {
+	if isNumber(value) {
+		value = toFloat(value)
+	}
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+
+	for _, candidate := range values {
+		if isNumber(candidate) {
+			candidate = toFloat(candidate)
+		}
+		if encodedCandidate, err := json.Marshal(candidate); err == nil && bytes.Equal(encodedValue, encodedCandidate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatValues(values []any) string {
+	formatted := make([]string, len(values))
+	for index, value := range values {
+		encoded, _ := json.Marshal(value)
+		formatted[index] = string(encoded)
+	}
+
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+// escapePointer escapes a JSON Pointer reference token (RFC 6901).
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// UnescapePointer splits a JSON Pointer into its unescaped reference tokens.
+func UnescapePointer(pointer string) []string {
+	if pointer == "" {
+		return []string{}
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for index, token := range tokens {
+		tokens[index] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens
+}
@@ -0,0 +1,131 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/jsonschema"
+)
+
+const validateSchema = `{
+	"$ref": "#/$defs/order",
+	"$defs": {
+		"order": {
+			"type": "object",
+			"required": ["id", "items"],
+			"properties": {
+				"id": {"type": "string", "format": "uuid"},
+				"status": {"enum": ["open", "closed"]},
+				"note": {"type": ["string", "null"], "maxLength": 5},
+				"items": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/item"}},
+				"a/b": {"type": "integer"}
+			},
+			"additionalProperties": false
+		},
+		"item": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string", "pattern": "^[a-z]+$"},
+				"quantity": {"type": "integer", "minimum": 1}
+			}
+		}
+	}
+}`
+
+func Test_Schema_Validate(t *testing.T) {
+	t.Parallel()
+
+	schema := &jsonschema.Schema{}
+	assert.Nil(t, json.Unmarshal([]byte(validateSchema), schema))
+
+	testCases := []struct {
+		name     string
+		document string
+		expected jsonschema.ValidationErrors
+	}{
+		{
+			name:     "valid",
+			document: `{"id":"7f0c1b8e-4d1a-4c56-9a55-2b3f1d0e6a10","status":"open","note":null,"items":[{"name":"pen","quantity":2}]}`,
+		},
+		{
+			name:     "every failure listed",
+			document: `{"id":"abc","status":"lost","note":"too long","items":[{"name":"pen"},{"name":"Pen","quantity":0.5},{"quantity":0}],"a/b":"x","extra":1}`,
+			expected: jsonschema.ValidationErrors{
+				{Pointer: "/a~1b", Keyword: "type", Message: "must be of type integer"},
+				{Pointer: "/extra", Keyword: "not", Message: "is not allowed"},
+				{Pointer: "/id", Keyword: "format", Message: "must be a valid uuid"},
+				{Pointer: "/items/1/name", Keyword: "pattern", Message: "must match pattern ^[a-z]+$"},
+				{Pointer: "/items/1/quantity", Keyword: "type", Message: "must be of type integer"},
+				{Pointer: "/items/2/name", Keyword: "required", Message: "is required"},
+				{Pointer: "/items/2/quantity", Keyword: "minimum", Message: "must be at least 1"},
+				{Pointer: "/note", Keyword: "maxLength", Message: "length must be at most 5"},
+				{Pointer: "/status", Keyword: "enum", Message: `must be one of ["open", "closed"]`},
+			},
+		},
+		{
+			name:     "wrong root type",
+			document: `[]`,
+			expected: jsonschema.ValidationErrors{
+				{Pointer: "", Keyword: "type", Message: "must be of type object"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := schema.Validate([]byte(testCase.document))
+			if testCase.expected == nil {
+				assert.Nil(t, err)
+
+				return
+			}
+
+			var validationErrors jsonschema.ValidationErrors
+			assert.True(t, errors.As(err, &validationErrors))
+			assert.Equal(t, testCase.expected, validationErrors)
+		})
+	}
+}
+
+func Test_Schema_Validate_invalid_json(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{name: "truncated", data: `{"name":`},
+		{name: "trailing garbage", data: `{"a":1} garbage`},
+		{name: "multiple values", data: `{}{}`},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := (&jsonschema.Schema{}).Validate([]byte(testCase.data))
+			assert.True(t, errors.Is(err, jsonschema.ErrInvalidJson))
+		})
+	}
+
+	// Trailing whitespace is not a value.
+	assert.Nil(t, (&jsonschema.Schema{}).Validate([]byte("{}\n")))
+}
+
+func Test_Schema_Validate_missing_ref(t *testing.T) {
+	t.Parallel()
+
+	err := (&jsonschema.Schema{Ref: "#/$defs/missing"}).Validate([]byte(`{}`))
+	assert.NotNil(t, err)
+
+	var validationErrors jsonschema.ValidationErrors
+	assert.False(t, errors.As(err, &validationErrors))
+}
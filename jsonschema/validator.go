@@ -0,0 +1,197 @@
+package jsonschema
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+
+	"github.com/wspowell/spiderweb/endpoint"
+)
+
+const (
+	mimeTypeJson       = "application/json"
+	mimeTypeJsonSuffix = "+json"
+)
+
+// SchemaSource finds the schemas of a route.
+// Returns false if the route has no schema, in which case the body is not validated.
+type SchemaSource interface {
+	RequestSchema(route endpoint.RouteInfo, mimeType string) (*Schema, bool, error)
+	ResponseSchema(route endpoint.RouteInfo, httpStatus int, mimeType string) (*Schema, bool, error)
+}
+
+var _ endpoint.RequestValidator = (*Validator)(nil)
+var _ endpoint.ResponseValidator = (*Validator)(nil)
+
+// Validator checks JSON request and response bodies against the schemas of the route.
+// Only endpoints with the "validate" option on the request or response body are validated.
+// Request failures respond with 400 Bad Request and list every failing value as an endpoint.ValidationErrors,
+// using JSON Pointers for endpoint.FieldError.Pointer.
+// Response failures respond with 500 Internal Server Error since the handler returned an invalid response.
+type Validator struct {
+	source SchemaSource
+}
+
+func NewValidator(source SchemaSource) *Validator {
+	return &Validator{
+		source: source,
+	}
+}
+
+// NewGoTypeValidator validates against schemas generated from the request and response body Go types of each endpoint.
+func NewGoTypeValidator() *Validator {
+	return NewValidator(&goTypeSource{})
+}
+
+func (self *Validator) ValidateRequest(ctx context.Context, requestBody []byte) (int, error) {
+	route, ok := endpoint.RequestRoute(ctx)
+	if !ok {
+		return http.StatusOK, nil
+	}
+
+	mediaType, ok := endpoint.RequestMediaType(ctx)
+	if !ok || !isJson(mediaType.MimeType()) {
+		return http.StatusOK, nil
+	}
+
+	schema, exists, err := self.source.RequestSchema(route, mediaType.MimeType())
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(err, endpoint.ErrInternalServerError)
+	}
+	if !exists {
+		return http.StatusOK, nil
+	}
+
+	if err := schema.Validate(requestBody); err != nil {
+		var validationErrors ValidationErrors
+		switch {
+		case errors.As(err, &validationErrors):
+			return http.StatusBadRequest, toFieldErrors(validationErrors)
+		case errors.Is(err, ErrInvalidJson):
+			return http.StatusBadRequest, errors.Wrap(err, endpoint.ErrInvalidBody)
+		default:
+			return http.StatusInternalServerError, errors.Wrap(err, endpoint.ErrInternalServerError)
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+func (self *Validator) ValidateResponse(ctx context.Context, httpStatus int, responseBody []byte) (int, error) {
+	route, ok := endpoint.RequestRoute(ctx)
+	if !ok {
+		return httpStatus, nil
+	}
+
+	mediaType, ok := endpoint.ResponseMediaType(ctx)
+	if !ok || !isJson(mediaType.MimeType()) {
+		return httpStatus, nil
+	}
+
+	schema, exists, err := self.source.ResponseSchema(route, httpStatus, mediaType.MimeType())
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(err, endpoint.ErrInternalServerError)
+	}
+	if !exists {
+		return httpStatus, nil
+	}
+
+	if err := schema.Validate(responseBody); err != nil {
+		// Never expose the details of an invalid response to the caller. The details are logged by the endpoint.
+		return http.StatusInternalServerError, errors.Wrap(err, endpoint.ErrInternalServerError)
+	}
+
+	return httpStatus, nil
+}
+
+// toFieldErrors converts the schema failures so they are rendered the same as endpoint.TagValidator failures.
+func toFieldErrors(validationErrors ValidationErrors) endpoint.ValidationErrors {
+	fieldErrors := make(endpoint.ValidationErrors, len(validationErrors))
+	for index, validationError := range validationErrors {
+		fieldErrors[index] = endpoint.FieldError{
+			Field:   pointerToField(validationError.Pointer),
+			Pointer: validationError.Pointer,
+			Rule:    validationError.Keyword,
+			Message: validationError.Message,
+		}
+	}
+
+	return fieldErrors
+}
+
+// pointerToField converts a JSON Pointer to the field path format of endpoint.FieldError.
+// Ex: /items/0/name -> items[0].name
+func pointerToField(pointer string) string {
+	field := strings.Builder{}
+	for _, token := range UnescapePointer(pointer) {
+		if isIndex(token) {
+			field.WriteString("[" + token + "]")
+
+			continue
+		}
+
+		if field.Len() != 0 {
+			field.WriteString(".")
+		}
+		field.WriteString(token)
+	}
+
+	return field.String()
+}
+
+func isIndex(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	for _, char := range token {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isJson(mimeType string) bool {
+	return mimeType == mimeTypeJson || strings.HasSuffix(mimeType, mimeTypeJsonSuffix)
+}
+
+// goTypeSource generates schemas from the endpoint body Go types.
+type goTypeSource struct {
+	// Cache of reflect.Type to *Schema.
+	schemas sync.Map
+}
+
+func (self *goTypeSource) RequestSchema(route endpoint.RouteInfo, mimeType string) (*Schema, bool, error) {
+	return self.schema(route.Endpoint.RequestBodyType())
+}
+
+func (self *goTypeSource) ResponseSchema(route endpoint.RouteInfo, httpStatus int, mimeType string) (*Schema, bool, error) {
+	// Error responses are created by the ErrorHandler, not the handler response body.
+	if httpStatus >= http.StatusBadRequest {
+		return nil, false, nil
+	}
+
+	return self.schema(route.Endpoint.ResponseBodyType())
+}
+
+func (self *goTypeSource) schema(goType reflect.Type) (*Schema, bool, error) {
+	if goType == nil {
+		return nil, false, nil
+	}
+
+	if cached, exists := self.schemas.Load(goType); exists {
+		// nolint:forcetypeassert // reason: only *Schema is stored
+		return cached.(*Schema), true, nil
+	}
+
+	schema := Generate(goType)
+	self.schemas.Store(goType, schema)
+
+	return schema, true, nil
+}
@@ -0,0 +1,138 @@
+package jsonschema_test
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/jsonschema"
+)
+
+type noteItem struct {
+	Text string `json:"text" validate:"required,max=10"`
+}
+
+type noteRequest struct {
+	Title string     `json:"title" validate:"required,min=3"`
+	Items []noteItem `json:"items"`
+}
+
+type noteResponse struct {
+	Id     string `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status" validate:"oneof=open|closed"`
+}
+
+type noteEndpoint struct {
+	RequestBody  *noteRequest  `spiderweb:"request,mime=application/json,validate"`
+	ResponseBody *noteResponse `spiderweb:"response,mime=application/json,validate"`
+}
+
+func (self *noteEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &noteResponse{
+		Id:    "note-1",
+		Title: self.RequestBody.Title,
+	}
+
+	// Stands in for a handler bug that returns an invalid response.
+	if self.RequestBody.Title != "bug" {
+		self.ResponseBody.Status = "open"
+	}
+
+	return httpstatus.Created, nil
+}
+
+func executeNoteEndpoint(t *testing.T, validator *jsonschema.Validator, requestBody string) (int, string) {
+	t.Helper()
+
+	ctx := context.Background()
+	ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+	testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+		LogConfig:         log.NewConfig().WithLevel(log.LevelError),
+		RequestValidator:  validator,
+		ResponseValidator: validator,
+	}, &noteEndpoint{})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/notes", strings.NewReader(requestBody))
+	assert.Nil(t, err)
+
+	req.Header.Add(httpheader.ContentType, "application/json")
+
+	requester, err := endpoint.NewHttpRequester("/notes", req)
+	assert.Nil(t, err)
+
+	var httpStatus int
+	var responseBodyBytes []byte
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+	}()
+	wg.Wait()
+
+	return httpStatus, string(responseBodyBytes)
+}
+
+func Test_GoTypeValidator(t *testing.T) {
+	t.Parallel()
+
+	validator := jsonschema.NewGoTypeValidator()
+
+	testCases := []struct {
+		name               string
+		requestBody        string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{
+			name:               "valid",
+			requestBody:        `{"title":"groceries","items":[{"text":"milk"}]}`,
+			expectedHttpStatus: httpstatus.Created,
+			expectedBody:       `{"id":"note-1","title":"groceries","status":"open"}`,
+		},
+		{
+			name:               "invalid request",
+			requestBody:        `{"title":7,"items":[{"text":"milk"},{"text":"bread and butter"},{}]}`,
+			expectedHttpStatus: httpstatus.BadRequest,
+			expectedBody: `{"message":"bad request: items[1].text length must be at most 10; items[2].text is required; title must be of type string",` +
+				`"fields":{"items[1].text":"length must be at most 10","items[2].text":"is required","title":"must be of type string"},` +
+				`"pointers":{"/items/1/text":"length must be at most 10","/items/2/text":"is required","/title":"must be of type string"}}`,
+		},
+		{
+			name:               "invalid json",
+			requestBody:        `{"title":`,
+			expectedHttpStatus: httpstatus.BadRequest,
+		},
+		{
+			name:               "invalid response",
+			requestBody:        `{"title":"bug"}`,
+			expectedHttpStatus: httpstatus.InternalServerError,
+			expectedBody:       `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			httpStatus, responseBody := executeNoteEndpoint(t, validator, testCase.requestBody)
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			if testCase.expectedBody != "" {
+				assert.Equal(t, testCase.expectedBody, responseBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wspowell/context"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+	"github.com/wspowell/spiderweb/jsonschema"
+)
+
+const (
+	mimeTypeMultipartFormData = "multipart/form-data"
+
+	responseSuccess = "2XX"
+)
+
+// New creates a document describing every route.
+// Schemas of named body types are added to the document components and referenced by each operation.
+func New(ctx context.Context, info Info, routes []endpoint.RouteInfo) *Document {
+	generator := jsonschema.NewGenerator(jsonschema.ComponentsRefPrefix)
+
+	document := &Document{
+		OpenApi: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+	}
+
+	operationIds := map[string]int{}
+	for _, route := range routes {
+		pathItem, exists := document.Paths[route.Path]
+		if !exists {
+			pathItem = &PathItem{}
+			document.Paths[route.Path] = pathItem
+		}
+
+		operation := newOperation(generator, route.Endpoint.Describe(ctx))
+
+		// Handlers may be used for more than one route, but operation IDs must be unique.
+		operationIds[operation.OperationId]++
+		if count := operationIds[operation.OperationId]; count > 1 {
+			operation.OperationId += strconv.Itoa(count)
+		}
+
+		pathItem.SetOperation(route.Method, operation)
+	}
+
+	if len(generator.Definitions) != 0 {
+		document.Components = &Components{
+			Schemas: generator.Definitions,
+		}
+	}
+
+	return document
+}
+
+func newOperation(generator *jsonschema.Generator, description endpoint.Description) *Operation {
+	operation := &Operation{
+		OperationId: description.Name,
+		Responses:   map[string]*Response{},
+	}
+
+	for _, parameter := range description.Parameters {
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:     parameter.Name,
+			In:       parameter.In,
+			Required: parameter.IsRequired,
+			Schema:   parameterSchema(generator, parameter),
+		})
+	}
+
+	if description.RequestBodyType != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{},
+		}
+		for _, mimeType := range description.RequestMimeTypes {
+			operation.RequestBody.Content[mimeType] = &MediaType{
+				Schema: requestBodySchema(generator, description, mimeType),
+			}
+		}
+	}
+
+	operation.Responses[responseSuccess] = successResponse(generator, description)
+
+	if description.ETagEnabled {
+		operation.Responses[strconv.Itoa(http.StatusNotModified)] = &Response{
+			Description: http.StatusText(http.StatusNotModified),
+		}
+	}
+
+	if description.ErrorBodyType != nil {
+		errorSchema := generator.Schema(description.ErrorBodyType)
+
+		errorMimeTypes := description.ErrorMimeTypes
+
+		if len(description.Parameters) != 0 || description.RequestBodyType != nil {
+			operation.Responses[strconv.Itoa(http.StatusBadRequest)] = errorResponse(http.StatusText(http.StatusBadRequest), errorSchema, errorMimeTypes)
+		}
+		if description.HasAuth {
+			operation.Responses[strconv.Itoa(http.StatusUnauthorized)] = errorResponse(http.StatusText(http.StatusUnauthorized), errorSchema, errorMimeTypes)
+		}
+		if description.HasPolicies {
+			operation.Responses[strconv.Itoa(http.StatusForbidden)] = errorResponse(http.StatusText(http.StatusForbidden), errorSchema, errorMimeTypes)
+		}
+		operation.Responses[ResponseDefault] = errorResponse("Error.", errorSchema, errorMimeTypes)
+	}
+
+	return operation
+}
+
+func successResponse(generator *jsonschema.Generator, description endpoint.Description) *Response {
+	response := &Response{
+		Description: "Success.",
+		Headers:     map[string]*Header{},
+	}
+
+	if description.ResponseBodyType != nil {
+		response.Content = map[string]*MediaType{}
+		for _, mimeType := range description.ResponseMimeTypes {
+			schema := &jsonschema.Schema{Type: jsonschema.Types{jsonschema.TypeString}, Format: jsonschema.FormatBinary}
+			if !description.IsResponseStream {
+				schema = generator.Schema(description.ResponseBodyType)
+			}
+			response.Content[mimeType] = &MediaType{
+				Schema: schema,
+			}
+		}
+	}
+
+	stringSchema := &jsonschema.Schema{Type: jsonschema.Types{jsonschema.TypeString}}
+	for _, name := range description.ResponseHeaders {
+		response.Headers[name] = &Header{Schema: stringSchema}
+	}
+	if description.SetsCookies {
+		response.Headers[httpheader.SetCookie] = &Header{Schema: stringSchema}
+	}
+	if description.ETagEnabled {
+		response.Headers[httpheader.ETag] = &Header{Schema: stringSchema}
+	}
+	if description.MaxAgeSeconds != 0 {
+		response.Headers[httpheader.CacheControl] = &Header{
+			Description: "max-age=" + strconv.Itoa(description.MaxAgeSeconds),
+			Schema:      stringSchema,
+		}
+	}
+
+	if len(response.Headers) == 0 {
+		response.Headers = nil
+	}
+
+	return response
+}
+
+func errorResponse(description string, errorSchema *jsonschema.Schema, mimeTypes []string) *Response {
+	response := &Response{
+		Description: description,
+		Content:     map[string]*MediaType{},
+	}
+	for _, mimeType := range mimeTypes {
+		response.Content[mimeType] = &MediaType{
+			Schema: errorSchema,
+		}
+	}
+
+	return response
+}
+
+func requestBodySchema(generator *jsonschema.Generator, description endpoint.Description, mimeType string) *jsonschema.Schema {
+	if description.IsRequestStream {
+		return &jsonschema.Schema{Type: jsonschema.Types{jsonschema.TypeString}, Format: jsonschema.FormatBinary}
+	}
+
+	schema := generator.Schema(description.RequestBodyType)
+
+	if mimeType == mimeTypeMultipartFormData && len(description.Files) != 0 {
+		// File parts are bound to handler fields rather than the request body.
+		files := &jsonschema.Schema{
+			Type:       jsonschema.Types{jsonschema.TypeObject},
+			Properties: map[string]*jsonschema.Schema{},
+			Required:   description.RequiredFiles,
+		}
+		for _, name := range description.Files {
+			files.Properties[name] = &jsonschema.Schema{Type: jsonschema.Types{jsonschema.TypeString}, Format: jsonschema.FormatBinary}
+		}
+
+		return &jsonschema.Schema{AllOf: []*jsonschema.Schema{schema, files}}
+	}
+
+	return schema
+}
+
+func parameterSchema(generator *jsonschema.Generator, parameter endpoint.ParameterDescription) *jsonschema.Schema {
+	schema := generator.Schema(parameter.Type)
+
+	// Constraints apply to each item of slices.
+	itemSchema := schema
+	if schema.Type.Has(jsonschema.TypeArray) && schema.Items != nil {
+		itemSchema = schema.Items
+	}
+
+	if parameter.IsDuration {
+		// Durations are parsed from strings, ex: 1m30s.
+		*itemSchema = jsonschema.Schema{
+			Type:        jsonschema.Types{jsonschema.TypeString},
+			Description: "Duration, ex: 1m30s",
+		}
+	}
+
+	for _, value := range parameter.Enum {
+		itemSchema.Enum = append(itemSchema.Enum, parameterValue(itemSchema, value))
+	}
+	if parameter.Pattern != "" {
+		itemSchema.Pattern = parameter.Pattern
+	}
+	if !parameter.IsDuration {
+		applyParameterLimits(itemSchema, parameter)
+	}
+
+	if parameter.Default != nil {
+		if schema == itemSchema {
+			schema.Default = parameterValue(schema, *parameter.Default)
+		} else {
+			schema.Default = *parameter.Default
+		}
+	}
+
+	return schema
+}
+
+func applyParameterLimits(schema *jsonschema.Schema, parameter endpoint.ParameterDescription) {
+	if schema.Type.Has(jsonschema.TypeString) {
+		if parameter.Min != nil {
+			minLength := int(*parameter.Min)
+			schema.MinLength = &minLength
+		}
+		if parameter.Max != nil {
+			maxLength := int(*parameter.Max)
+			schema.MaxLength = &maxLength
+		}
+
+		return
+	}
+
+	if parameter.Min != nil {
+		schema.Minimum = parameter.Min
+	}
+	if parameter.Max != nil {
+		schema.Maximum = parameter.Max
+	}
+}
+
+// parameterValue converts a struct tag value to the JSON type of the schema.
+func parameterValue(schema *jsonschema.Schema, value string) any {
+	switch {
+	case schema.Type.Has(jsonschema.TypeInteger) || schema.Type.Has(jsonschema.TypeNumber):
+		if number, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return number
+		}
+	case schema.Type.Has(jsonschema.TypeBoolean):
+		if boolean, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			return boolean
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,124 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/openapi"
+)
+
+type listNotesEndpoint struct {
+	Limit        int           `spiderweb:"query=limit,default=10,min=1,max=100"`
+	Order        string        `spiderweb:"query=order,enum=asc|desc"`
+	Tags         []string      `spiderweb:"query=tag,max=5"`
+	Wait         time.Duration `spiderweb:"query=wait"`
+	TenantId     string        `spiderweb:"header=X-Tenant-Id,required"`
+	RequestId    string        `spiderweb:"response-header=X-Trace-Id"`
+	ResponseBody *[]note       `spiderweb:"response,mime=application/json,etag,max-age=60"`
+}
+
+func (self *listNotesEndpoint) Handle(ctx context.Context) (int, error) {
+	return httpstatus.OK, nil
+}
+
+func newTestEndpoint(handler endpoint.Handler) *endpoint.Endpoint {
+	ctx := log.WithContext(context.Background(), log.NewConfig().WithLevel(log.LevelError))
+
+	return endpoint.NewEndpoint(ctx, &endpoint.Config{
+		LogConfig: log.NewConfig().WithLevel(log.LevelError),
+	}, handler)
+}
+
+func newTestDocument() *openapi.Document {
+	return openapi.New(context.Background(), openapi.Info{Title: "Notes", Version: "1.0.0"}, []endpoint.RouteInfo{
+		{Method: http.MethodGet, Path: "/notes", Endpoint: newTestEndpoint(&listNotesEndpoint{})},
+		{Method: http.MethodPut, Path: "/notes/{id}", Endpoint: newTestEndpoint(&putNoteEndpoint{})},
+	})
+}
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+
+	document := newTestDocument()
+
+	assert.Equal(t, openapi.Version, document.OpenApi)
+	assert.Equal(t, []string{"ParameterError", "defaultErrorResponse", "note"}, sortedKeys(document.Components.Schemas))
+
+	list := document.Paths["/notes"].Get
+	assert.Equal(t, "listNotesEndpoint", list.OperationId)
+	assert.Equal(t, []string{"2XX", "304", "400", "default"}, sortedKeys(list.Responses))
+
+	parametersBytes, err := json.Marshal(list.Parameters)
+	assert.Nil(t, err)
+	assert.Equal(t, `[`+
+		`{"name":"limit","in":"query","schema":{"type":"integer","default":10,"minimum":1,"maximum":100}},`+
+		`{"name":"order","in":"query","schema":{"type":"string","enum":["asc","desc"]}},`+
+		`{"name":"tag","in":"query","schema":{"type":"array","items":{"type":"string","maxLength":5}}},`+
+		`{"name":"wait","in":"query","schema":{"description":"Duration, ex: 1m30s","type":"string"}},`+
+		`{"name":"X-Tenant-Id","in":"header","required":true,"schema":{"type":"string"}}]`, string(parametersBytes))
+
+	successBytes, err := json.Marshal(list.Responses["2XX"])
+	assert.Nil(t, err)
+	assert.Equal(t, `{"description":"Success.",`+
+		`"headers":{"Cache-Control":{"description":"max-age=60","schema":{"type":"string"}},"Etag":{"schema":{"type":"string"}},"X-Trace-Id":{"schema":{"type":"string"}}},`+
+		`"content":{"application/json":{"schema":{"type":"array","items":{"$ref":"#/components/schemas/note"}}}}}`, string(successBytes))
+
+	errorBytes, err := json.Marshal(list.Responses["default"])
+	assert.Nil(t, err)
+	assert.Equal(t, `{"description":"Error.","content":{"application/json":{"schema":{"$ref":"#/components/schemas/defaultErrorResponse"}}}}`, string(errorBytes))
+
+	put := document.Paths["/notes/{id}"].Put
+	assert.Equal(t, `{"$ref":"#/components/schemas/note"}`, marshalString(t, put.RequestBody.Content["application/json"].Schema))
+	assert.True(t, put.Parameters[0].Required)
+}
+
+func Test_Document_Yaml(t *testing.T) {
+	t.Parallel()
+
+	document := newTestDocument()
+
+	yamlBytes, err := document.Yaml()
+	assert.Nil(t, err)
+
+	// Round trip through Load to make sure the YAML describes the same document.
+	loaded, err := openapi.Load(yamlBytes)
+	assert.Nil(t, err)
+
+	jsonBytes, err := document.Json()
+	assert.Nil(t, err)
+	loadedBytes, err := loaded.Json()
+	assert.Nil(t, err)
+	assert.Equal(t, string(jsonBytes), string(loadedBytes))
+
+	assert.Contains(t, string(yamlBytes), "openapi: 3.1.0\n")
+	assert.Contains(t, string(yamlBytes), "        2XX:\n")
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func marshalString(t *testing.T, value any) string {
+	t.Helper()
+
+	valueBytes, err := json.Marshal(value)
+	assert.Nil(t, err)
+
+	return string(valueBytes)
+}
@@ -0,0 +1,74 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/wspowell/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidDocument = errors.New("invalid OpenAPI document")
+)
+
+// Load an OpenAPI document from JSON or YAML.
+func Load(data []byte) (*Document, error) {
+	jsonData := data
+	if !json.Valid(bytes.TrimSpace(data)) {
+		var err error
+		if jsonData, err = yamlToJson(data); err != nil {
+			return nil, errors.Wrap(err, ErrInvalidDocument)
+		}
+	}
+
+	document := &Document{}
+	if err := json.Unmarshal(jsonData, document); err != nil {
+		return nil, errors.Wrap(err, ErrInvalidDocument)
+	}
+
+	if document.OpenApi == "" {
+		return nil, errors.Wrap(errors.New("missing openapi version"), ErrInvalidDocument)
+	}
+
+	return document, nil
+}
+
+// yamlToJson converts YAML to JSON so that documents are decoded the same regardless of format.
+func yamlToJson(data []byte) ([]byte, error) {
+	var value any
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, errors.Wrap(err, errors.New("invalid YAML"))
+	}
+
+	return json.Marshal(jsonKeys(value))
+}
+
+// jsonKeys converts YAML mappings to JSON objects.
+// YAML keys may be any scalar, ex: response status codes are decoded as integers.
+func jsonKeys(value any) any {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		for key, item := range typedValue {
+			typedValue[key] = jsonKeys(item)
+		}
+
+		return typedValue
+	case map[any]any:
+		object := make(map[string]any, len(typedValue))
+		for key, item := range typedValue {
+			object[fmt.Sprintf("%v", key)] = jsonKeys(item)
+		}
+
+		return object
+	case []any:
+		for index, item := range typedValue {
+			typedValue[index] = jsonKeys(item)
+		}
+
+		return typedValue
+	}
+
+	return value
+}
@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/wspowell/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Json encodes the document as indented JSON.
+func (self *Document) Json() ([]byte, error) {
+	documentBytes, err := json.MarshalIndent(self, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.New("failed to encode OpenAPI document as JSON"))
+	}
+
+	return documentBytes, nil
+}
+
+// Yaml encodes the document as YAML.
+// The document is encoded as JSON first so that both formats are identical, including the order of fields.
+func (self *Document) Yaml() ([]byte, error) {
+	jsonBytes, err := json.Marshal(self)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.New("failed to encode OpenAPI document as YAML"))
+	}
+
+	// JSON is valid YAML, so decoding into a node keeps the order of every mapping.
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(jsonBytes, node); err != nil {
+		return nil, errors.Wrap(err, errors.New("failed to encode OpenAPI document as YAML"))
+	}
+	blockStyle(node)
+
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, errors.Wrap(err, errors.New("failed to encode OpenAPI document as YAML"))
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Wrap(err, errors.New("failed to encode OpenAPI document as YAML"))
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// blockStyle replaces the JSON flow style with YAML block style.
+// Quoting is only kept where it is required, which the encoder decides when the style is cleared.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
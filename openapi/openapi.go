@@ -0,0 +1,136 @@
+// Package openapi describes spiderweb routes using OpenAPI 3.1 documents.
+package openapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/wspowell/spiderweb/jsonschema"
+)
+
+const (
+	Version = "3.1.0"
+
+	ParameterInPath   = "path"
+	ParameterInQuery  = "query"
+	ParameterInHeader = "header"
+	ParameterInCookie = "cookie"
+
+	// ResponseDefault is the response used for any status code not listed.
+	ResponseDefault = "default"
+)
+
+// Document is an OpenAPI document.
+// Only the objects needed to describe spiderweb routes are supported.
+type Document struct {
+	OpenApi    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths,omitempty"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	Url         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*jsonschema.Schema `json:"schemas,omitempty"`
+}
+
+// PathItem holds the operations of a single path.
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty"`
+}
+
+// Operation for the HTTP method. Returns nil if the method has no operation.
+func (self *PathItem) Operation(method string) *Operation {
+	if operation := self.operationField(method); operation != nil {
+		return *operation
+	}
+
+	return nil
+}
+
+// SetOperation for the HTTP method. Unknown methods are ignored.
+func (self *PathItem) SetOperation(method string, operation *Operation) {
+	if field := self.operationField(method); field != nil {
+		*field = operation
+	}
+}
+
+func (self *PathItem) operationField(method string) **Operation {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		return &self.Get
+	case http.MethodPut:
+		return &self.Put
+	case http.MethodPost:
+		return &self.Post
+	case http.MethodDelete:
+		return &self.Delete
+	case http.MethodOptions:
+		return &self.Options
+	case http.MethodHead:
+		return &self.Head
+	case http.MethodPatch:
+		return &self.Patch
+	case http.MethodTrace:
+		return &self.Trace
+	}
+
+	return nil
+}
+
+type Operation struct {
+	OperationId string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses,omitempty"`
+}
+
+type Parameter struct {
+	Name        string             `json:"name"`
+	In          string             `json:"in"`
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string             `json:"description,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema,omitempty"`
+}
+
+type MediaType struct {
+	Schema *jsonschema.Schema `json:"schema,omitempty"`
+}
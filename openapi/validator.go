@@ -0,0 +1,80 @@
+package openapi
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/jsonschema"
+)
+
+var _ jsonschema.SchemaSource = (*Document)(nil)
+
+// NewValidator validates request and response bodies against the schemas of an OpenAPI document (JSON or YAML).
+// Operations are found by the HTTP method and the route path, ex: "/resources/{id}".
+// Routes without an operation, or without a schema for the media type, are not validated.
+func NewValidator(document []byte) (*jsonschema.Validator, error) {
+	loadedDocument, err := Load(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonschema.NewValidator(loadedDocument), nil
+}
+
+// RequestSchema is the request body schema of the route operation.
+func (self *Document) RequestSchema(route endpoint.RouteInfo, mimeType string) (*jsonschema.Schema, bool, error) {
+	operation := self.operation(route)
+	if operation == nil || operation.RequestBody == nil {
+		return nil, false, nil
+	}
+
+	return self.contentSchema(operation.RequestBody.Content, mimeType)
+}
+
+// ResponseSchema is the response body schema of the route operation.
+// Responses are matched by status code, then status code range (ex: "2XX"), then "default".
+func (self *Document) ResponseSchema(route endpoint.RouteInfo, httpStatus int, mimeType string) (*jsonschema.Schema, bool, error) {
+	operation := self.operation(route)
+	if operation == nil {
+		return nil, false, nil
+	}
+
+	statusCode := strconv.Itoa(httpStatus)
+	for _, key := range []string{statusCode, statusCode[:1] + "XX", ResponseDefault} {
+		if response, exists := operation.Responses[key]; exists {
+			return self.contentSchema(response.Content, mimeType)
+		}
+	}
+
+	return nil, false, nil
+}
+
+func (self *Document) operation(route endpoint.RouteInfo) *Operation {
+	pathItem, exists := self.Paths[route.Path]
+	if !exists {
+		return nil
+	}
+
+	return pathItem.Operation(route.Method)
+}
+
+// contentSchema finds the schema for the MIME type, falling back to media ranges (ex: "application/*").
+// The returned schema includes the component schemas so that references resolve.
+func (self *Document) contentSchema(content map[string]*MediaType, mimeType string) (*jsonschema.Schema, bool, error) {
+	mimeTypeRange := strings.SplitN(mimeType, "/", 2)[0] + "/*"
+	for _, key := range []string{mimeType, mimeTypeRange, "*/*"} {
+		if mediaType, exists := content[key]; exists && mediaType.Schema != nil {
+			schema := &jsonschema.Schema{
+				AllOf: []*jsonschema.Schema{mediaType.Schema},
+			}
+			if self.Components != nil {
+				schema.Defs = self.Components.Schemas
+			}
+
+			return schema, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
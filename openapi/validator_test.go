@@ -0,0 +1,154 @@
+package openapi_test
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/openapi"
+)
+
+const notesDocument = `
+openapi: 3.1.0
+info:
+  title: Notes
+  version: 1.0.0
+paths:
+  /notes/{id}:
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Note'
+      responses:
+        200:
+          description: Updated note.
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Note'
+components:
+  schemas:
+    Note:
+      type: object
+      required: [title]
+      properties:
+        title:
+          type: string
+          minLength: 3
+        tags:
+          type: array
+          items:
+            type: string
+            maxLength: 5
+`
+
+type note struct {
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+}
+
+type putNoteEndpoint struct {
+	Id           string `spiderweb:"path=id"`
+	RequestBody  *note  `spiderweb:"request,mime=application/json,validate"`
+	ResponseBody *note  `spiderweb:"response,mime=application/json,validate"`
+}
+
+func (self *putNoteEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = self.RequestBody
+
+	return httpstatus.OK, nil
+}
+
+func Test_Load(t *testing.T) {
+	t.Parallel()
+
+	document, err := openapi.Load([]byte(notesDocument))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "3.1.0", document.OpenApi)
+	operation := document.Paths["/notes/{id}"].Operation(http.MethodPut)
+	assert.NotNil(t, operation)
+	assert.Contains(t, operation.Responses, "200")
+	assert.Contains(t, document.Components.Schemas, "Note")
+
+	_, err = openapi.Load([]byte(`{"info":{"title":"Notes"}}`))
+	assert.True(t, errors.Is(err, openapi.ErrInvalidDocument))
+}
+
+func Test_NewValidator(t *testing.T) {
+	t.Parallel()
+
+	validator, err := openapi.NewValidator([]byte(notesDocument))
+	assert.Nil(t, err)
+
+	testCases := []struct {
+		name               string
+		requestBody        string
+		expectedHttpStatus int
+		expectedBody       string
+	}{
+		{
+			name:               "valid",
+			requestBody:        `{"title":"groceries","tags":["food"]}`,
+			expectedHttpStatus: httpstatus.OK,
+			expectedBody:       `{"title":"groceries","tags":["food"]}`,
+		},
+		{
+			name:               "invalid",
+			requestBody:        `{"tags":["food","kitchen"]}`,
+			expectedHttpStatus: httpstatus.BadRequest,
+			expectedBody: `{"message":"bad request: title is required; tags[1] length must be at most 5",` +
+				`"fields":{"tags[1]":"length must be at most 5","title":"is required"},` +
+				`"pointers":{"/tags/1":"length must be at most 5","/title":"is required"}}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctx = log.WithContext(ctx, log.NewConfig().WithLevel(log.LevelError))
+
+			testEndpoint := endpoint.NewEndpoint(ctx, &endpoint.Config{
+				LogConfig:         log.NewConfig().WithLevel(log.LevelError),
+				RequestValidator:  validator,
+				ResponseValidator: validator,
+			}, &putNoteEndpoint{})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPut, "/notes/1", strings.NewReader(testCase.requestBody))
+			assert.Nil(t, err)
+
+			req.Header.Add(httpheader.ContentType, "application/json")
+
+			requester, err := endpoint.NewHttpRequester("/notes/{id}", req)
+			assert.Nil(t, err)
+
+			var httpStatus int
+			var responseBodyBytes []byte
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				httpStatus, responseBodyBytes = testEndpoint.Execute(ctx, requester)
+			}()
+			wg.Wait()
+
+			assert.Equal(t, testCase.expectedHttpStatus, httpStatus)
+			assert.Equal(t, testCase.expectedBody, string(responseBodyBytes))
+		})
+	}
+}
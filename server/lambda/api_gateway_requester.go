@@ -1,6 +1,10 @@
 package lambda
 
 import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -10,20 +14,43 @@ type ApiGatewayRequester struct {
 	matchedPath string
 	request     *events.APIGatewayProxyRequest
 	bodyBytes   []byte
+	// headers are keyed by canonical header name, since clients may send headers in any case.
+	headers map[string]string
 
-	responseHeaders map[string]string
+	responseHeaders    map[string]string
+	responseCookies    []string
+	responseBodyStream io.Reader
 }
 
 func NewApiGatewayRequester(matchedPath string, request *events.APIGatewayProxyRequest) *ApiGatewayRequester {
 	var bodyBytes []byte
 	if request.Body != "" {
 		bodyBytes = []byte(request.Body)
+
+		// Binary bodies, such as multipart file uploads, are base64 encoded by API Gateway.
+		if request.IsBase64Encoded {
+			decodedBytes, err := base64.StdEncoding.DecodeString(request.Body)
+			if err == nil {
+				bodyBytes = decodedBytes
+			}
+		}
+	}
+
+	headers := make(map[string]string, len(request.Headers))
+	for header, values := range request.MultiValueHeaders {
+		if len(values) != 0 {
+			headers[http.CanonicalHeaderKey(header)] = values[0]
+		}
+	}
+	for header, value := range request.Headers {
+		headers[http.CanonicalHeaderKey(header)] = value
 	}
 
 	return &ApiGatewayRequester{
 		matchedPath:     matchedPath,
 		request:         request,
 		bodyBytes:       bodyBytes,
+		headers:         headers,
 		responseHeaders: map[string]string{},
 	}
 }
@@ -41,15 +68,16 @@ func (self *ApiGatewayRequester) Path() []byte {
 }
 
 func (self *ApiGatewayRequester) ContentType() []byte {
-	return []byte(self.request.Headers["Content-Type"])
+	return []byte(self.headers["Content-Type"])
 }
 
 func (self *ApiGatewayRequester) Accept() []byte {
-	return []byte(self.request.Headers["Accept"])
+	return []byte(self.headers["Accept"])
 }
 
+// PeekHeader returns the value of the header, ignoring case.
 func (self *ApiGatewayRequester) PeekHeader(key string) []byte {
-	if value, exists := self.request.Headers[key]; exists {
+	if value, exists := self.headers[http.CanonicalHeaderKey(key)]; exists {
 		return []byte(value)
 	}
 
@@ -57,7 +85,7 @@ func (self *ApiGatewayRequester) PeekHeader(key string) []byte {
 }
 
 func (self *ApiGatewayRequester) VisitHeaders(f func(key []byte, value []byte)) {
-	for header, value := range self.request.Headers {
+	for header, value := range self.headers {
 		f([]byte(header), []byte(value))
 	}
 }
@@ -85,14 +113,60 @@ func (self *ApiGatewayRequester) QueryParam(param string) ([]byte, bool) {
 	return []byte(value), exists
 }
 
+func (self *ApiGatewayRequester) QueryParamValues(param string) ([][]byte, bool) {
+	values, exists := self.request.MultiValueQueryStringParameters[param]
+	if !exists {
+		// MultiValueQueryStringParameters is only populated when enabled on the API Gateway.
+		var value string
+		value, exists = self.request.QueryStringParameters[param]
+		values = []string{value}
+	}
+
+	if !exists {
+		return nil, false
+	}
+
+	byteValues := make([][]byte, len(values))
+	for index, value := range values {
+		byteValues[index] = []byte(value)
+	}
+
+	return byteValues, true
+}
+
+func (self *ApiGatewayRequester) VisitQueryParams(f func(key []byte, value []byte)) {
+	// MultiValueQueryStringParameters is only populated when enabled on the API Gateway.
+	if len(self.request.MultiValueQueryStringParameters) == 0 {
+		for param, value := range self.request.QueryStringParameters {
+			f([]byte(param), []byte(value))
+		}
+
+		return
+	}
+
+	for param, values := range self.request.MultiValueQueryStringParameters {
+		for _, value := range values {
+			f([]byte(param), []byte(value))
+		}
+	}
+}
+
 func (self *ApiGatewayRequester) RequestBody() []byte {
 	return self.bodyBytes
 }
 
+func (self *ApiGatewayRequester) RequestBodyStream() io.Reader {
+	return bytes.NewReader(self.bodyBytes)
+}
+
 func (self *ApiGatewayRequester) SetResponseHeader(header string, value string) {
 	self.responseHeaders[header] = value
 }
 
+func (self *ApiGatewayRequester) SetResponseCookie(cookie *http.Cookie) {
+	self.responseCookies = append(self.responseCookies, cookie.String())
+}
+
 func (self *ApiGatewayRequester) SetResponseContentType(contentType string) {
 	self.responseHeaders["Content-Type"] = contentType
 }
@@ -104,3 +178,11 @@ func (self *ApiGatewayRequester) ResponseContentType() string {
 func (self *ApiGatewayRequester) ResponseHeaders() map[string]string {
 	return self.responseHeaders
 }
+
+func (self *ApiGatewayRequester) SetResponseBodyStream(stream io.Reader) {
+	self.responseBodyStream = stream
+}
+
+func (self *ApiGatewayRequester) ResponseBodyStream() io.Reader {
+	return self.responseBodyStream
+}
@@ -0,0 +1,47 @@
+package lambda_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wspowell/spiderweb/server/lambda"
+)
+
+func Test_ApiGatewayRequester_Headers(t *testing.T) {
+	t.Parallel()
+
+	requester := lambda.NewApiGatewayRequester("/values", &events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/values",
+		// HTTP/2 clients send lowercase headers.
+		Headers: map[string]string{
+			"content-type": "application/json",
+			"accept":       "application/xml",
+			"x-tenant-id":  "12",
+		},
+		MultiValueHeaders: map[string][]string{
+			"x-trace-id": {"abc", "def"},
+		},
+	})
+
+	assert.Equal(t, "application/json", string(requester.ContentType()))
+	assert.Equal(t, "application/xml", string(requester.Accept()))
+	assert.Equal(t, "12", string(requester.PeekHeader("X-Tenant-Id")))
+	assert.Equal(t, "12", string(requester.PeekHeader("x-tenant-id")))
+	assert.Equal(t, "abc", string(requester.PeekHeader("X-Trace-Id")))
+	assert.Nil(t, requester.PeekHeader("X-Missing"))
+
+	headers := map[string]string{}
+	requester.VisitHeaders(func(key []byte, value []byte) {
+		headers[string(key)] = string(value)
+	})
+	assert.Equal(t, map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/xml",
+		"X-Tenant-Id":  "12",
+		"X-Trace-Id":   "abc",
+	}, headers)
+}
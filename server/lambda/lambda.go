@@ -1,6 +1,12 @@
 package lambda
 
 import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -8,6 +14,7 @@ import (
 	"github.com/wspowell/log"
 
 	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
 	"github.com/wspowell/spiderweb/server/route"
 )
 
@@ -28,7 +35,7 @@ func New(endpointConfig *endpoint.Config, routeDefinition route.Route) *Lambda {
 	return &Lambda{
 		endpointConfig: endpointConfig,
 		matchedPath:    routeDefinition.Path,
-		routeEndpoint:  endpoint.NewEndpoint(ctx, endpointConfig, routeDefinition.Handler),
+		routeEndpoint:  endpoint.NewEndpoint(ctx, routeDefinition.EndpointConfig(endpointConfig), routeDefinition.Handler),
 	}
 }
 
@@ -38,15 +45,11 @@ func (self *Lambda) Start() {
 	lambda.Start(wrappedHandler)
 }
 
-// FIXME: Should be able to execute a lambda, especially for testing.
-// func (self *Lambda) Execute() (int, []byte) {
-// 	self.router.Handler(fasthttpCtx)
-// 	return fasthttpCtx.Response.StatusCode(), fasthttpCtx.Response.Body()
-// }
-
-// func (self *Lambda) Invoke(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-
-// }
+// Invoke the lambda with one API Gateway request.
+// Useful for testing.
+func (self *Lambda) Invoke(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return self.wrapLambdaHandler(self.routeEndpoint)(ctx, request)
+}
 
 func (self *Lambda) wrapLambdaHandler(routeEndpoint *endpoint.Endpoint) HandlerAPIGateway {
 	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -56,18 +59,71 @@ func (self *Lambda) wrapLambdaHandler(routeEndpoint *endpoint.Endpoint) HandlerA
 		response := events.APIGatewayProxyResponse{}
 		requester := NewApiGatewayRequester(self.matchedPath, &request)
 
-		ctx, cancel := context.WithTimeout(ctx, self.endpointConfig.Timeout)
-		go func() {
-			<-ctx.Done()
-			cancel()
-		}()
+		ctx, cancel := context.WithTimeout(ctx, routeEndpoint.Config.Timeout)
+		defer cancel()
 
 		httpStatus, responseBody := routeEndpoint.Execute(ctx, requester)
 
-		response.Body = string(responseBody)
+		// API Gateway proxy responses cannot be streamed, so the stream is buffered into the response.
+		// The response is limited to the API Gateway payload size (6MB for Lambda proxy integrations).
+		if stream := requester.ResponseBodyStream(); stream != nil {
+			var err error
+			responseBody, err = readResponseBodyStream(stream)
+			if err != nil {
+				log.Error(ctx, "failed to read response body stream: %v", err)
+				httpStatus = http.StatusInternalServerError
+				responseBody = nil
+			}
+		}
+
+		// API Gateway requires binary bodies to be base64 encoded, otherwise they are corrupted.
+		if isBinaryBody(requester.ResponseContentType(), responseBody) {
+			response.Body = base64.StdEncoding.EncodeToString(responseBody)
+			response.IsBase64Encoded = true
+		} else {
+			response.Body = string(responseBody)
+		}
 		response.StatusCode = httpStatus
 		response.Headers = requester.responseHeaders
+		if len(requester.responseCookies) != 0 {
+			// Multiple cookies can only be returned as multi-value headers.
+			response.MultiValueHeaders = map[string][]string{
+				httpheader.SetCookie: requester.responseCookies,
+			}
+		}
 
 		return response, nil
 	}
 }
+
+// isBinaryBody returns true if the response body is not text, based on its Content-Type.
+// A body without a Content-Type is binary if it is not valid UTF-8.
+func isBinaryBody(contentType string, body []byte) bool {
+	if len(body) == 0 {
+		return false
+	}
+
+	mimeType, _, _ := strings.Cut(contentType, ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+
+	switch {
+	case mimeType == "":
+		return !utf8.Valid(body)
+	case strings.HasPrefix(mimeType, "text/"),
+		strings.HasSuffix(mimeType, "/json"), strings.HasSuffix(mimeType, "+json"),
+		strings.HasSuffix(mimeType, "/xml"), strings.HasSuffix(mimeType, "+xml"),
+		strings.HasSuffix(mimeType, "/yaml"), strings.HasSuffix(mimeType, "/javascript"),
+		mimeType == "application/x-www-form-urlencoded":
+		return false
+	}
+
+	return true
+}
+
+func readResponseBodyStream(stream io.Reader) ([]byte, error) {
+	if closer, ok := stream.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	return io.ReadAll(stream)
+}
@@ -0,0 +1,103 @@
+package lambda_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/server/lambda"
+	"github.com/wspowell/spiderweb/server/route"
+)
+
+var binaryBody = []byte{0x00, 0xff, 0xfe, 0x80, 'a'}
+
+type binaryStreamEndpoint struct {
+	ResponseBody io.Reader `spiderweb:"response,stream,mime=application/octet-stream"`
+}
+
+func (self *binaryStreamEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = bytes.NewReader(binaryBody)
+
+	return httpstatus.OK, nil
+}
+
+type textBody struct {
+	Value string `json:"value"`
+}
+
+type jsonEndpoint struct {
+	ResponseBody *textBody `spiderweb:"response,mime=application/json"`
+}
+
+func (self *jsonEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = &textBody{Value: "hello"}
+
+	return httpstatus.OK, nil
+}
+
+func Test_Lambda_Response_Body_Encoding(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                    string
+		accept                  string
+		handler                 endpoint.Handler
+		expectedIsBase64Encoded bool
+		expectedBody            []byte
+	}{
+		{name: "binary stream", accept: "application/octet-stream", handler: &binaryStreamEndpoint{}, expectedIsBase64Encoded: true, expectedBody: binaryBody},
+		{name: "json", accept: "application/json", handler: &jsonEndpoint{}, expectedIsBase64Encoded: false, expectedBody: []byte(`{"value":"hello"}`)},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := &endpoint.Config{
+				LogConfig: log.NewConfig().WithLevel(log.LevelFatal),
+			}
+			handler := lambda.New(config, route.Get("/values", testCase.handler))
+
+			ctx := context.Background()
+
+			var response events.APIGatewayProxyResponse
+			var err error
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				response, err = handler.Invoke(ctx, events.APIGatewayProxyRequest{
+					HTTPMethod: http.MethodGet,
+					Path:       "/values",
+					Headers: map[string]string{
+						"Accept": testCase.accept,
+					},
+				})
+			}()
+			wg.Wait()
+
+			assert.Nil(t, err)
+			assert.Equal(t, httpstatus.OK, response.StatusCode)
+			assert.Equal(t, testCase.expectedIsBase64Encoded, response.IsBase64Encoded)
+
+			body := []byte(response.Body)
+			if response.IsBase64Encoded {
+				body, err = base64.StdEncoding.DecodeString(response.Body)
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, testCase.expectedBody, body)
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package restful
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+// ErrCircuitOpen is the error of requests rejected by an open circuit.
+var ErrCircuitOpen = endpoint.NewHttpError(httpstatus.ServiceUnavailable, "", "circuit open")
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// CircuitBreakerConfig of the circuit breaker of each route.
+type CircuitBreakerConfig struct {
+	// FailureRatio of requests in the Window that opens the circuit. Defaults to 0.5.
+	FailureRatio float64
+	// MinRequests in the Window before the circuit can open. Defaults to 20.
+	MinRequests int
+	// Window that requests are counted in. Defaults to 10 seconds.
+	Window time.Duration
+	// OpenDuration before the circuit is half-open and allows probe requests. Defaults to 30 seconds.
+	OpenDuration time.Duration
+	// HalfOpenProbes that must succeed to close the circuit. Defaults to 1.
+	// Only this many requests are allowed while half-open. Others are rejected until the probes complete,
+	// or until the probes have been running for OpenDuration, when new probes are allowed.
+	HalfOpenProbes int
+	// IsFailure returns true if the response status counts as a failure. Defaults to 5xx and 408 responses.
+	// Panics are always failures.
+	IsFailure func(httpStatus int) bool
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// CircuitBreakerState of the circuit breaker of a route.
+type CircuitBreakerState struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// State is one of CircuitClosed, CircuitOpen, or CircuitHalfOpen.
+	State string `json:"state"`
+	// Requests and Failures in the current window.
+	Requests int `json:"requests"`
+	Failures int `json:"failures"`
+	// OpenedAt is when the circuit last opened. Zero if it has never opened.
+	OpenedAt time.Time `json:"openedAt"`
+	// Opened is the number of times the circuit has opened.
+	Opened int `json:"opened"`
+	// Rejected is the number of requests rejected while open.
+	Rejected int `json:"rejected"`
+}
+
+// circuitBreaker of one route. It is the outermost Middleware of the route endpoint.
+type circuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mutex       sync.Mutex
+	state       CircuitBreakerState
+	windowStart time.Time
+	probes      int
+	probesOk    int
+	// probeRound changes whenever a new set of probes is allowed, so that late results of earlier probes are ignored.
+	probeRound      int
+	probesStartedAt time.Time
+}
+
+var _ endpoint.Middleware = (*circuitBreaker)(nil)
+
+func newCircuitBreaker(httpMethod string, path string, config CircuitBreakerConfig) *circuitBreaker {
+	if config.FailureRatio == 0 {
+		config.FailureRatio = 0.5
+	}
+	if config.MinRequests == 0 {
+		config.MinRequests = 20
+	}
+	if config.Window == 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.OpenDuration == 0 {
+		config.OpenDuration = 30 * time.Second
+	}
+	if config.HalfOpenProbes == 0 {
+		config.HalfOpenProbes = 1
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = func(httpStatus int) bool {
+			return httpStatus >= 500 || httpStatus == httpstatus.RequestTimeout
+		}
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &circuitBreaker{
+		config: config,
+		state: CircuitBreakerState{
+			Method: httpMethod,
+			Path:   path,
+			State:  CircuitClosed,
+		},
+		windowStart: config.Now(),
+	}
+}
+
+func (self *circuitBreaker) HandleRequest(ctx context.Context, requester endpoint.Requester, next endpoint.Next) (int, []byte, error) {
+	allowed, probeRound, retryAfter := self.allow()
+	if !allowed {
+		requester.SetResponseHeader(httpheader.RetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+		return httpstatus.ServiceUnavailable, nil, ErrCircuitOpen
+	}
+
+	// The outcome is recorded once, either when the request times out or when the handler returns.
+	var recordOnce sync.Once
+	record := func(failed bool) {
+		recordOnce.Do(func() {
+			self.record(ctx, probeRound, failed)
+		})
+	}
+
+	returned := make(chan struct{})
+	defer close(returned)
+	go func() {
+		select {
+		case <-returned:
+		case <-ctx.Done():
+			// The request is answered with a timeout while the handler keeps running.
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				record(true)
+			}
+		}
+	}()
+
+	completed := false
+	httpStatus := httpstatus.InternalServerError
+	defer func() {
+		// A panic is recorded as a failure and still recovered by the endpoint.
+		record(!completed || self.config.IsFailure(httpStatus))
+	}()
+
+	var responseBody []byte
+	httpStatus, responseBody = next(ctx)
+	completed = true
+
+	return httpStatus, responseBody, nil
+}
+
+// allow the request through the circuit.
+// Returns the probe round if the request is a probe of a half-open circuit, otherwise 0.
+// Returns how long until the circuit half-opens if the request is not allowed.
+func (self *circuitBreaker) allow() (bool, int, time.Duration) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	now := self.config.Now()
+
+	switch self.state.State {
+	case CircuitOpen:
+		halfOpenAt := self.state.OpenedAt.Add(self.config.OpenDuration)
+		if now.Before(halfOpenAt) {
+			self.state.Rejected++
+
+			return false, 0, halfOpenAt.Sub(now)
+		}
+
+		self.state.State = CircuitHalfOpen
+		self.startProbes(now)
+
+		fallthrough
+	case CircuitHalfOpen:
+		if self.probes >= self.config.HalfOpenProbes {
+			if now.Sub(self.probesStartedAt) < self.config.OpenDuration {
+				self.state.Rejected++
+
+				// Probes are in flight, so try again shortly.
+				return false, 0, time.Second
+			}
+
+			// Probes that never return must not hold the circuit half-open forever.
+			self.startProbes(now)
+		}
+		self.probes++
+
+		return true, self.probeRound, 0
+	}
+
+	if now.Sub(self.windowStart) >= self.config.Window {
+		self.windowStart = now
+		self.state.Requests = 0
+		self.state.Failures = 0
+	}
+
+	return true, 0, 0
+}
+
+func (self *circuitBreaker) startProbes(now time.Time) {
+	self.probes = 0
+	self.probesOk = 0
+	self.probeRound++
+	self.probesStartedAt = now
+}
+
+func (self *circuitBreaker) record(ctx context.Context, probeRound int, failed bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	now := self.config.Now()
+
+	if probeRound != 0 {
+		if self.state.State != CircuitHalfOpen || probeRound != self.probeRound {
+			return
+		}
+
+		if failed {
+			log.Warn(ctx, "circuit breaker probe failed, reopening: %s %s", self.state.Method, self.state.Path)
+			self.open(now)
+
+			return
+		}
+
+		self.probesOk++
+		if self.probesOk >= self.config.HalfOpenProbes {
+			log.Info(ctx, "circuit breaker closed: %s %s", self.state.Method, self.state.Path)
+			self.state.State = CircuitClosed
+			self.state.Requests = 0
+			self.state.Failures = 0
+			self.windowStart = now
+		}
+
+		return
+	}
+
+	if self.state.State != CircuitClosed {
+		// Requests allowed before the circuit opened do not count towards the next window.
+		return
+	}
+
+	self.state.Requests++
+	if failed {
+		self.state.Failures++
+	}
+
+	if self.state.Requests >= self.config.MinRequests && float64(self.state.Failures)/float64(self.state.Requests) >= self.config.FailureRatio {
+		log.Warn(ctx, "circuit breaker opened: %s %s (%d/%d failed)", self.state.Method, self.state.Path, self.state.Failures, self.state.Requests)
+		self.open(now)
+	}
+}
+
+func (self *circuitBreaker) open(now time.Time) {
+	self.state.State = CircuitOpen
+	self.state.OpenedAt = now
+	self.state.Opened++
+}
+
+func (self *circuitBreaker) State() CircuitBreakerState {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	state := self.state
+	// The circuit only half-opens on the next request, but it is reported as soon as probes are allowed.
+	if state.State == CircuitOpen && !self.config.Now().Before(state.OpenedAt.Add(self.config.OpenDuration)) {
+		state.State = CircuitHalfOpen
+	}
+
+	return state
+}
+
+// CircuitBreakers returns the state of the circuit breaker of each route, in the order the routes were handled.
+// Empty if ServerConfig.CircuitBreaker is not set.
+func (self *Server) CircuitBreakers() []CircuitBreakerState {
+	states := make([]CircuitBreakerState, 0, len(self.circuitBreakers))
+	for _, breaker := range self.circuitBreakers {
+		states = append(states, breaker.State())
+	}
+
+	return states
+}
+
+// HandleCircuitBreakers serves the state of every circuit breaker as JSON at the path.
+func (self *Server) HandleCircuitBreakers(path string) {
+	self.router.GET(path, func(requestCtx *fasthttp.RequestCtx) {
+		statesBytes, err := json.Marshal(self.CircuitBreakers())
+		if err != nil {
+			log.Error(self.serverContext, "failed to marshal circuit breakers: %v", err)
+			requestCtx.SetStatusCode(httpstatus.InternalServerError)
+
+			return
+		}
+
+		requestCtx.Response.Header.Set(httpheader.ContentType, "application/json")
+		requestCtx.SetStatusCode(httpstatus.OK)
+		requestCtx.SetBody(statesBytes)
+	})
+}
@@ -0,0 +1,275 @@
+package restful_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/server/restful"
+	"github.com/wspowell/spiderweb/server/route"
+)
+
+type caller interface {
+	call() int
+}
+
+// downstream that the handler depends on.
+type downstream struct {
+	mutex    sync.Mutex
+	status   int
+	panics   bool
+	requests int
+	// hang blocks calls until it is closed.
+	hang chan struct{}
+}
+
+func (self *downstream) call() int {
+	self.mutex.Lock()
+	self.requests++
+	status, panics, hang := self.status, self.panics, self.hang
+	self.mutex.Unlock()
+
+	if hang != nil {
+		<-hang
+	}
+	if panics {
+		panic("downstream panic")
+	}
+
+	return status
+}
+
+func (self *downstream) setHang(hang chan struct{}) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.hang = hang
+}
+
+func (self *downstream) requestCount() int {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.requests
+}
+
+func (self *downstream) set(status int, panics bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.status = status
+	self.panics = panics
+}
+
+type dependentEndpoint struct {
+	Downstream caller `spiderweb:"resource=downstream"`
+}
+
+func (self *dependentEndpoint) Handle(ctx context.Context) (int, error) {
+	if httpStatus := self.Downstream.call(); httpStatus != httpstatus.OK {
+		return httpStatus, endpoint.ErrInternalServerError
+	}
+
+	return httpstatus.OK, nil
+}
+
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (self *fakeClock) Now() time.Time {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.now
+}
+
+func (self *fakeClock) Advance(duration time.Duration) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.now = self.now.Add(duration)
+}
+
+func newCircuitBreakerServer(clock *fakeClock, dependency *downstream, timeout time.Duration) *restful.Server {
+	server := newTestServer(&restful.ServerConfig{
+		CircuitBreaker: &restful.CircuitBreakerConfig{
+			FailureRatio: 0.5,
+			MinRequests:  4,
+			Window:       time.Minute,
+			OpenDuration: 30 * time.Second,
+			Now:          clock.Now,
+		},
+	}, &endpoint.Config{
+		Timeout: timeout,
+		Resources: map[string]any{
+			"downstream": dependency,
+		},
+	}, route.Get("/orders", &dependentEndpoint{}))
+	server.HandleCircuitBreakers("/admin/circuit-breakers")
+
+	return server
+}
+
+func Test_Server_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	dependency := &downstream{status: httpstatus.OK}
+	server := newCircuitBreakerServer(clock, dependency, 0)
+
+	// Closed: failures below the threshold are returned as is.
+	for _, status := range []int{httpstatus.OK, httpstatus.OK, httpstatus.BadGateway} {
+		dependency.set(status, false)
+		httpStatus, _, _ := executeGet(server, "/orders")
+		assert.Equal(t, status, httpStatus)
+	}
+	assert.Equal(t, restful.CircuitClosed, server.CircuitBreakers()[0].State)
+
+	// The 4th request is a failure, which reaches the failure ratio.
+	httpStatus, _, _ := executeGet(server, "/orders")
+	assert.Equal(t, httpstatus.BadGateway, httpStatus)
+	assert.Equal(t, restful.CircuitOpen, server.CircuitBreakers()[0].State)
+
+	// Open: fail fast without calling the handler.
+	clock.Advance(10 * time.Second)
+	httpStatus, responseBody, responseHeader := executeGet(server, "/orders")
+	assert.Equal(t, httpstatus.ServiceUnavailable, httpStatus)
+	assert.Equal(t, `{"message":"circuit open"}`, string(responseBody))
+	assert.Equal(t, "20", string(responseHeader.Peek("Retry-After")))
+	assert.Equal(t, 4, dependency.requests)
+
+	// Half-open: a failed probe opens the circuit again.
+	clock.Advance(20 * time.Second)
+	assert.Equal(t, restful.CircuitHalfOpen, server.CircuitBreakers()[0].State)
+	httpStatus, _, _ = executeGet(server, "/orders")
+	assert.Equal(t, httpstatus.BadGateway, httpStatus)
+	assert.Equal(t, restful.CircuitOpen, server.CircuitBreakers()[0].State)
+
+	// Half-open: a successful probe closes the circuit.
+	clock.Advance(30 * time.Second)
+	dependency.set(httpstatus.OK, false)
+	httpStatus, _, _ = executeGet(server, "/orders")
+	assert.Equal(t, httpstatus.OK, httpStatus)
+
+	httpStatus, responseBody, _ = executeGet(server, "/admin/circuit-breakers")
+	assert.Equal(t, httpstatus.OK, httpStatus)
+
+	states := []restful.CircuitBreakerState{}
+	assert.Nil(t, json.Unmarshal(responseBody, &states))
+	assert.Equal(t, []restful.CircuitBreakerState{
+		{
+			Method:   http.MethodGet,
+			Path:     "/orders",
+			State:    restful.CircuitClosed,
+			OpenedAt: time.Date(2022, 1, 1, 0, 0, 30, 0, time.UTC),
+			Opened:   2,
+			Rejected: 1,
+		},
+	}, states)
+}
+
+func Test_Server_CircuitBreaker_Panics(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	dependency := &downstream{}
+	dependency.set(httpstatus.OK, true)
+	server := newCircuitBreakerServer(clock, dependency, 0)
+
+	for i := 0; i < 4; i++ {
+		httpStatus, _, _ := executeGet(server, "/orders")
+		assert.Equal(t, httpstatus.InternalServerError, httpStatus)
+	}
+
+	httpStatus, _, _ := executeGet(server, "/orders")
+	assert.Equal(t, httpstatus.ServiceUnavailable, httpStatus)
+	assert.Equal(t, 4, dependency.requests)
+}
+
+func Test_Server_CircuitBreaker_Timeout(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	dependency := &downstream{status: httpstatus.OK}
+	hang := make(chan struct{})
+	dependency.setHang(hang)
+	server := newCircuitBreakerServer(clock, dependency, 20*time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		httpStatus, _, _ := executeGet(server, "/orders")
+		assert.Equal(t, httpstatus.RequestTimeout, httpStatus)
+	}
+
+	// Timeouts are failures as soon as they are answered, even though the handlers have not returned.
+	assert.Eventually(t, func() bool {
+		return server.CircuitBreakers()[0].State == restful.CircuitOpen
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 4, server.CircuitBreakers()[0].Failures)
+
+	close(hang)
+	assert.Eventually(t, func() bool {
+		return server.AbandonedHandlers().Running == 0
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 4, server.CircuitBreakers()[0].Failures)
+}
+
+func Test_Server_CircuitBreaker_Hanging_Probe(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	dependency := &downstream{status: httpstatus.BadGateway}
+	server := newCircuitBreakerServer(clock, dependency, time.Minute)
+
+	for i := 0; i < 4; i++ {
+		httpStatus, _, _ := executeGet(server, "/orders")
+		assert.Equal(t, httpstatus.BadGateway, httpStatus)
+	}
+	assert.Equal(t, restful.CircuitOpen, server.CircuitBreakers()[0].State)
+
+	// The probe hangs.
+	clock.Advance(30 * time.Second)
+	hang := make(chan struct{})
+	dependency.setHang(hang)
+
+	probeDone := make(chan int, 1)
+	go func() {
+		httpStatus, _, _ := executeGet(server, "/orders")
+		probeDone <- httpStatus
+	}()
+	assert.Eventually(t, func() bool {
+		return dependency.requestCount() == 5
+	}, time.Second, time.Millisecond)
+
+	httpStatus, _, _ := executeGet(server, "/orders")
+	assert.Equal(t, httpstatus.ServiceUnavailable, httpStatus)
+
+	// After OpenDuration, another probe is allowed.
+	clock.Advance(30 * time.Second)
+	dependency.setHang(nil)
+	dependency.set(httpstatus.OK, false)
+	httpStatus, _, _ = executeGet(server, "/orders")
+	assert.Equal(t, httpstatus.OK, httpStatus)
+	assert.Equal(t, restful.CircuitClosed, server.CircuitBreakers()[0].State)
+
+	// The late result of the hanging probe is ignored.
+	dependency.set(httpstatus.BadGateway, false)
+	close(hang)
+	assert.Equal(t, httpstatus.BadGateway, <-probeDone)
+	assert.Equal(t, restful.CircuitBreakerState{
+		Method:   http.MethodGet,
+		Path:     "/orders",
+		State:    restful.CircuitClosed,
+		OpenedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		Opened:   1,
+		Rejected: 1,
+	}, server.CircuitBreakers()[0])
+}
@@ -1,6 +1,10 @@
 package restful
 
 import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
 	"strconv"
 
 	"github.com/fasthttp/router"
@@ -11,11 +15,17 @@ import (
 
 type fasthttpRequester struct {
 	requestCtx *fasthttp.RequestCtx
+	// response that response headers are written to.
+	// This is the response of the request context, unless the response is buffered.
+	response *fasthttp.Response
+
+	responseBodyStream io.Reader
 }
 
 func newFasthttpRequester(requestCtx *fasthttp.RequestCtx) *fasthttpRequester {
 	return &fasthttpRequester{
 		requestCtx: requestCtx,
+		response:   &requestCtx.Response,
 	}
 }
 
@@ -70,27 +80,122 @@ func (self *fasthttpRequester) QueryParam(param string) ([]byte, bool) {
 	return value, value != nil
 }
 
+func (self *fasthttpRequester) QueryParamValues(param string) ([][]byte, bool) {
+	values := self.requestCtx.URI().QueryArgs().PeekMulti(param)
+
+	return values, len(values) != 0
+}
+
+func (self *fasthttpRequester) VisitQueryParams(f func(key []byte, value []byte)) {
+	self.requestCtx.URI().QueryArgs().VisitAll(f)
+}
+
 func (self *fasthttpRequester) RequestBody() []byte {
 	return self.requestCtx.Request.Body()
 }
 
+// RequestBodyStream returns the request body stream when the server streams request bodies.
+// Otherwise, the already read request body is returned as a stream.
+func (self *fasthttpRequester) RequestBodyStream() io.Reader {
+	if stream := self.requestCtx.RequestBodyStream(); stream != nil {
+		return stream
+	}
+
+	return bytes.NewReader(self.requestCtx.Request.Body())
+}
+
 func (self *fasthttpRequester) SetResponseHeader(header string, value string) {
-	self.requestCtx.Response.Header.Set(header, value)
+	self.response.Header.Set(header, value)
+}
+
+func (self *fasthttpRequester) SetResponseCookie(cookie *http.Cookie) {
+	responseCookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(responseCookie)
+
+	if err := responseCookie.Parse(cookie.String()); err != nil {
+		return
+	}
+
+	self.response.Header.SetCookie(responseCookie)
 }
 
 func (self *fasthttpRequester) SetResponseContentType(contentType string) {
-	self.requestCtx.SetContentType(contentType)
+	self.response.Header.SetContentType(contentType)
 }
 
 func (self *fasthttpRequester) ResponseContentType() string {
-	return string(self.requestCtx.Response.Header.Peek("Content-Type"))
+	return string(self.response.Header.Peek("Content-Type"))
 }
 
 func (self *fasthttpRequester) ResponseHeaders() map[string]string {
 	headers := map[string]string{}
-	self.requestCtx.Response.Header.VisitAll(func(key []byte, value []byte) {
+	self.response.Header.VisitAll(func(key []byte, value []byte) {
 		headers[string(key)] = string(value)
 	})
 
 	return headers
 }
+
+func (self *fasthttpRequester) SetResponseBodyStream(stream io.Reader) {
+	self.responseBodyStream = stream
+}
+
+func (self *fasthttpRequester) ResponseBodyStream() io.Reader {
+	return self.responseBodyStream
+}
+
+// bufferResponse headers in a separate response so that a handler abandoned after a timeout never modifies the request context.
+func (self *fasthttpRequester) bufferResponse() {
+	self.response = fasthttp.AcquireResponse()
+	self.response.Header.SetNoDefaultContentType(true)
+}
+
+// flushResponse headers from the buffer to the request context.
+func (self *fasthttpRequester) flushResponse() {
+	buffer := self.response
+	buffer.Header.VisitAll(func(key []byte, value []byte) {
+		self.requestCtx.Response.Header.SetBytesKV(key, value)
+	})
+
+	self.response = &self.requestCtx.Response
+	fasthttp.ReleaseResponse(buffer)
+}
+
+// discardResponse of an abandoned handler.
+func (self *fasthttpRequester) discardResponse() {
+	if closer, ok := self.responseBodyStream.(io.Closer); ok {
+		closer.Close()
+	}
+
+	fasthttp.ReleaseResponse(self.response)
+}
+
+// writeResponse to the request context.
+// A response body stream is written using a stream writer so it is never buffered in memory.
+// written, if not nil, is called once the response body has been written, which for a stream is after the handler returns.
+func (self *fasthttpRequester) writeResponse(httpStatus int, responseBody []byte, written func()) {
+	self.requestCtx.SetStatusCode(httpStatus)
+
+	if self.responseBodyStream == nil {
+		self.requestCtx.SetBody(responseBody)
+		if written != nil {
+			written()
+		}
+
+		return
+	}
+
+	stream := self.responseBodyStream
+	self.requestCtx.SetBodyStreamWriter(func(writer *bufio.Writer) {
+		if written != nil {
+			defer written()
+		}
+		if closer, ok := stream.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		if _, err := io.Copy(writer, stream); err != nil {
+			self.requestCtx.Logger().Printf("failed to stream response body: %v", err)
+		}
+	})
+}
@@ -1,14 +1,13 @@
 package restful
 
 import (
-	"fmt"
+	"net"
 	"net/http"
 
 	// nolint:gosec // reason: FIXME: Do not include this for release builds.
 	_ "net/http/pprof"
 	"os"
-	"os/signal"
-	"syscall"
+	"sync"
 	"time"
 
 	"github.com/fasthttp/router"
@@ -18,7 +17,9 @@ import (
 	"github.com/wspowell/log"
 
 	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpheader"
 	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/openapi"
 	"github.com/wspowell/spiderweb/server/route"
 )
 
@@ -31,19 +32,56 @@ type ServerConfig struct {
 	WriteTimeout time.Duration
 	LogConfig    log.LoggerConfig
 	EnablePprof  bool
+	// StreamRequestBody passes large request bodies to the endpoint as a stream instead of buffering them.
+	// Required for "request,stream" endpoints to avoid reading the entire request body into memory.
+	StreamRequestBody bool
+	// MaxRequestBodySize in bytes. Defaults to 4MB.
+	MaxRequestBodySize int
+	// Middleware wraps every endpoint, in order, before the Middleware of the endpoint.
+	Middleware []endpoint.Middleware
+	// CircuitBreaker of each route. Routes fail fast with a 503 while their circuit is open. Optional.
+	CircuitBreaker *CircuitBreakerConfig
+	// Listener to serve requests on instead of listening on Host and Port. Optional.
+	Listener net.Listener
+	// ShutdownTimeout is how long Shutdown waits for running requests to drain. Defaults to 30 seconds.
+	ShutdownTimeout time.Duration
+	// ReadinessDelay is how long Shutdown reports the server as not ready before it stops accepting requests.
+	// This gives load balancers time to stop sending requests to the server. Optional.
+	ReadinessDelay time.Duration
+	// ShutdownSignals that shut down the server once it is started. Ex: DefaultShutdownSignals
+	// No signals are handled unless they are set.
+	ShutdownSignals []os.Signal
 }
 
 // Server listens for incoming requests and routes them to the registered endpoint handlers.
 type Server struct {
+	// abandonedRunning and abandonedTotal are accessed atomically and must be 64-bit aligned.
+	abandonedRunning int64
+	abandonedTotal   int64
+
 	serverConfig *ServerConfig
 
 	server *fasthttp.Server
 	router *router.Router
 
 	routes map[string]*endpoint.Endpoint
-
-	serverContext    context.Context
-	shutdownComplete <-chan bool
+	// routeInfos in the order they were handled.
+	routeInfos []endpoint.RouteInfo
+	// circuitBreakers in the order routes were handled.
+	circuitBreakers []*circuitBreaker
+
+	serverContext       context.Context
+	cancelServerContext context.CancelFunc
+
+	mutex         sync.Mutex
+	listener      net.Listener
+	shutdownHooks []shutdownHook
+	// ready is 1 while the server is accepting requests. Accessed atomically.
+	ready        int32
+	shutdownOnce sync.Once
+	shutdownErr  error
+	// stopped is closed once Shutdown completes.
+	stopped chan struct{}
 }
 
 // NewServer sets up a new server.
@@ -67,6 +105,9 @@ func NewServer(serverConfig *ServerConfig) *Server {
 	if serverConfig.Port == 0 {
 		serverConfig.Port = 8080
 	}
+	if serverConfig.ShutdownTimeout == 0 {
+		serverConfig.ShutdownTimeout = 30 * time.Second
+	}
 
 	httpServer := &fasthttp.Server{}
 	httpServer.Name = "spiderweb"
@@ -74,8 +115,13 @@ func NewServer(serverConfig *ServerConfig) *Server {
 	httpServer.Logger = log.NewLog(serverConfig.LogConfig)
 	httpServer.ReadTimeout = serverConfig.ReadTimeout
 	httpServer.WriteTimeout = serverConfig.WriteTimeout
+	httpServer.StreamRequestBody = serverConfig.StreamRequestBody
+	httpServer.MaxRequestBodySize = serverConfig.MaxRequestBodySize
+	// Requests running during shutdown close their connection so that it does not hold up draining.
+	httpServer.CloseOnShutdown = true
 
-	ctx, shutdownComplete := newServerContext(httpServer)
+	// All requests and goroutines of the server should be derived from the server context, which is canceled on shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
 	ctx = log.WithContext(ctx, serverConfig.LogConfig)
 
 	restfulRouter := router.New()
@@ -97,24 +143,19 @@ func NewServer(serverConfig *ServerConfig) *Server {
 
 		routes: map[string]*endpoint.Endpoint{},
 
-		serverContext:    ctx,
-		shutdownComplete: shutdownComplete,
+		serverContext:       ctx,
+		cancelServerContext: cancel,
+
+		stopped: make(chan struct{}),
 	}
 }
 
 func (self *Server) HandleNotFound(endpointConfig *endpoint.Config, handler endpoint.Handler) {
-	routeEndpoint := endpoint.NewEndpoint(self.serverContext, endpointConfig, handler)
-
-	requestHandler := fasthttp.TimeoutWithCodeHandler(func(requestCtx *fasthttp.RequestCtx) {
-		httpStatus, responseBody := routeEndpoint.Execute(requestCtx, newFasthttpRequester(requestCtx))
+	routeEndpoint := endpoint.NewEndpoint(self.serverContext, self.withServerMiddleware(endpointConfig), handler)
 
-		requestCtx.SetStatusCode(httpStatus)
-		requestCtx.SetBody(responseBody)
-
-		// Set the Connection header to "close".
-		// Closes the connection after this function returns.
-		requestCtx.Response.SetConnectionClose()
-	}, endpointConfig.Timeout, "", httpstatus.RequestTimeout)
+	requestHandler := func(requestCtx *fasthttp.RequestCtx) {
+		self.executeWithTimeout(requestCtx, requestCtx, routeEndpoint)
+	}
 
 	self.router.NotFound = requestHandler
 }
@@ -122,101 +163,116 @@ func (self *Server) HandleNotFound(endpointConfig *endpoint.Config, handler endp
 // Handle the given route to the provided endpoint handler.
 // This starts a builder pattern where the endpoint may be modified from the root endpoint configuration.
 func (self *Server) Handle(endpointConfig *endpoint.Config, routeDefinition route.Route) {
-	wrappedHandler := self.wrapFasthttpHandler(endpointConfig, routeDefinition.HttpMethod, routeDefinition.Path, routeDefinition.Handler)
+	wrappedHandler := self.wrapFasthttpHandler(routeDefinition.EndpointConfig(endpointConfig), routeDefinition.HttpMethod, routeDefinition.Path, routeDefinition.Handler)
 	self.router.Handle(routeDefinition.HttpMethod, routeDefinition.Path, wrappedHandler)
 }
 
-// newServerContext that will be canceled when the process receives an interrupt.
-// This should be propagated to all running requests and goroutines in
-// order to shutdown gracefully.
-func newServerContext(server *fasthttp.Server) (context.Context, <-chan bool) {
-	shutdownComplete := make(chan bool, 1)
-	shutdown := make(chan os.Signal, 1)
-
-	// Get notified on signals from the OS.
-	signal.Notify(shutdown, os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGTSTP)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	go func() {
-		// Wait for the world to end.
-		<-shutdown
-
-		// Notify all request contexts that the server is shutting down.
-		cancel()
-
-		// Stop listening for new requests and wait for processes to finish.
-		if err := server.Shutdown(); err != nil {
-			server.Logger.Printf("failed to gracefully shutdown server: %v", err)
-		}
-
-		// Notify the server that shutdown is complete and that the process can now close.
-		shutdownComplete <- true
-		close(shutdownComplete)
-	}()
-
-	return ctx, shutdownComplete
-}
-
 // Execute one request.
 // Useful for testing.
-func (self Server) Execute(fasthttpCtx *fasthttp.RequestCtx) (int, []byte) {
+func (self *Server) Execute(fasthttpCtx *fasthttp.RequestCtx) (int, []byte) {
 	self.router.Handler(fasthttpCtx)
 
 	return fasthttpCtx.Response.StatusCode(), fasthttpCtx.Response.Body()
 }
 
 // Listen for incoming requests.
-// This is a blocking call. It will not return until after the server as received a shutdown
-//   signal and has drained all running requests.
-func (self Server) Listen() {
-	self.listenForever()
+// This is a blocking call. It will not return until the server has shut down and drained all running requests.
+// The server only shuts down on a signal if ServerConfig.ShutdownSignals is set.
+func (self *Server) Listen() {
+	if err := self.Start(context.Background()); err != nil {
+		log.Fatal(self.serverContext, "server failed: %v", err)
+	}
+
+	// Wait for the server to gracefully stop before exiting the process.
+	<-self.stopped
 }
 
-func (self *Server) listenForever() {
-	for key, list := range self.router.List() {
-		log.Debug(self.serverContext, "%v", key)
-		for _, item := range list {
-			log.Debug(self.serverContext, "  %v", item)
+func (self *Server) Endpoint(httpMethod string, path string) *endpoint.Endpoint {
+	return self.routes[path+" "+httpMethod]
+}
+
+// OpenApi describes every handled route as an OpenAPI document.
+func (self *Server) OpenApi(info openapi.Info) *openapi.Document {
+	return openapi.New(self.serverContext, info, self.routeInfos)
+}
+
+// HandleOpenApi serves the OpenAPI document of every handled route at "<path>.json" and "<path>.yaml".
+// The document is created on the first request so that it includes routes handled after this call.
+// These routes are not included in the document.
+func (self *Server) HandleOpenApi(path string, info openapi.Info) {
+	var once sync.Once
+	var jsonDocument []byte
+	var yamlDocument []byte
+	var err error
+
+	newDocument := func() {
+		document := self.OpenApi(info)
+		if jsonDocument, err = document.Json(); err != nil {
+			return
 		}
+		yamlDocument, err = document.Yaml()
 	}
 
-	log.Info(self.serverContext, "listening for requests")
+	serve := func(contentType string, documentBytes *[]byte) fasthttp.RequestHandler {
+		return func(requestCtx *fasthttp.RequestCtx) {
+			once.Do(newDocument)
+			if err != nil {
+				log.Error(self.serverContext, "failed to create OpenAPI document: %v", err)
+				requestCtx.SetStatusCode(httpstatus.InternalServerError)
 
-	self.server.Handler = self.router.Handler
+				return
+			}
 
-	listenAddress := fmt.Sprintf("%s:%d", self.serverConfig.Host, self.serverConfig.Port)
-	if err := self.server.ListenAndServe(listenAddress); err != nil {
-		log.Fatal(self.serverContext, "server failed: %v", err)
+			requestCtx.Response.Header.Set(httpheader.ContentType, contentType)
+			requestCtx.SetStatusCode(httpstatus.OK)
+			requestCtx.SetBody(*documentBytes)
+		}
 	}
 
-	log.Info(self.serverContext, "shutting down")
+	self.router.GET(path+".json", serve("application/json", &jsonDocument))
+	self.router.GET(path+".yaml", serve("application/yaml", &yamlDocument))
+}
 
-	// Wait for the server to gracefully stop before exiting the process.
-	<-self.shutdownComplete
-	log.Info(self.serverContext, "server stopped")
+// withServerMiddleware returns a copy of the endpoint config with the server Middleware in front of the endpoint Middleware.
+func (self *Server) withServerMiddleware(endpointConfig *endpoint.Config) *endpoint.Config {
+	return withMiddleware(endpointConfig, self.serverConfig.Middleware...)
 }
 
-func (self *Server) Endpoint(httpMethod string, path string) *endpoint.Endpoint {
-	return self.routes[path+" "+httpMethod]
+// withMiddleware returns a copy of the endpoint config with the Middleware in front of the endpoint Middleware.
+func withMiddleware(endpointConfig *endpoint.Config, middleware ...endpoint.Middleware) *endpoint.Config {
+	if len(middleware) == 0 {
+		return endpointConfig
+	}
+
+	configCopy := *endpointConfig
+	configCopy.Middleware = make([]endpoint.Middleware, 0, len(middleware)+len(endpointConfig.Middleware))
+	configCopy.Middleware = append(configCopy.Middleware, middleware...)
+	configCopy.Middleware = append(configCopy.Middleware, endpointConfig.Middleware...)
+
+	return &configCopy
 }
 
 func (self *Server) wrapFasthttpHandler(endpointConfig *endpoint.Config, httpMethod string, path string, handler endpoint.Handler) fasthttp.RequestHandler {
-	routeEndpoint := endpoint.NewEndpoint(self.serverContext, endpointConfig, handler)
+	routeConfig := self.withServerMiddleware(endpointConfig)
+	if self.serverConfig.CircuitBreaker != nil {
+		// The circuit breaker is outermost so that it sees the final status of the request.
+		breaker := newCircuitBreaker(httpMethod, path, *self.serverConfig.CircuitBreaker)
+		self.circuitBreakers = append(self.circuitBreakers, breaker)
+		routeConfig = withMiddleware(routeConfig, breaker)
+	}
+
+	routeEndpoint := endpoint.NewEndpoint(self.serverContext, routeConfig, handler)
 	self.routes[path+" "+httpMethod] = routeEndpoint
+	self.routeInfos = append(self.routeInfos, endpoint.RouteInfo{
+		Method:   httpMethod,
+		Path:     path,
+		Endpoint: routeEndpoint,
+	})
 
-	// Wrapping the handler in a timeout will force a timeout response.
-	// This does not stop the endpoint from running. The endpoint itself will need to check if it should continue.
-	return fasthttp.TimeoutWithCodeHandler(func(requestCtx *fasthttp.RequestCtx) {
+	return func(requestCtx *fasthttp.RequestCtx) {
 		span, ctx := opentracing.StartSpanFromContextWithTracer(requestCtx, routeEndpoint.Config.Tracer, string(requestCtx.Method())+" "+matchedPath(requestCtx))
 		defer span.Finish()
 
-		httpStatus, responseBody := routeEndpoint.Execute(ctx, newFasthttpRequester(requestCtx))
-
-		requestCtx.SetStatusCode(httpStatus)
-		requestCtx.SetBody(responseBody)
-
-		// Set the Connection header to "close".
-		// Closes the connection after this function returns.
-		requestCtx.Response.SetConnectionClose()
-	}, endpointConfig.Timeout, "", httpstatus.RequestTimeout)
+		self.executeWithTimeout(ctx, requestCtx, routeEndpoint)
+	}
 }
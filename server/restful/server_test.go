@@ -1,3 +1,148 @@
 package restful_test
 
-// TODO
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"github.com/wspowell/context"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/openapi"
+	"github.com/wspowell/spiderweb/server/restful"
+	"github.com/wspowell/spiderweb/server/route"
+	"github.com/wspowell/spiderweb/test"
+)
+
+// newTestServer that handles the route with logging disabled.
+// Tests only set the config that they depend on.
+func newTestServer(serverConfig *restful.ServerConfig, endpointConfig *endpoint.Config, routeDefinition route.Route) *restful.Server {
+	logConfig := &test.NoopLogConfig{
+		Config: log.NewConfig().WithLevel(log.LevelFatal),
+	}
+
+	serverConfig.LogConfig = logConfig
+	endpointConfig.LogConfig = logConfig
+
+	server := restful.NewServer(serverConfig)
+	server.Handle(endpointConfig, routeDefinition)
+
+	return server
+}
+
+// executeGet the path on the server without a connection.
+func executeGet(server *restful.Server, path string) (int, []byte, *fasthttp.ResponseHeader) {
+	req := fasthttp.Request{}
+	req.Header.SetMethod(http.MethodGet)
+	req.SetRequestURI(path)
+
+	requestCtx := fasthttp.RequestCtx{}
+	requestCtx.Init(&req, nil, nil)
+
+	httpStatus, responseBody := server.Execute(&requestCtx)
+
+	return httpStatus, responseBody, &requestCtx.Response.Header
+}
+
+func Test_Server_HandleOpenApi(t *testing.T) {
+	t.Parallel()
+
+	logConfig := &test.NoopLogConfig{
+		Config: log.NewConfig().WithLevel(log.LevelFatal),
+	}
+
+	server := restful.NewServer(&restful.ServerConfig{
+		LogConfig: logConfig,
+	})
+	server.HandleOpenApi("/openapi", openapi.Info{Title: "Sample", Version: "1.0.0"})
+
+	config := &endpoint.Config{
+		LogConfig: logConfig,
+		Resources: map[string]any{
+			"datastore": &test.Database{},
+		},
+	}
+	// Routes handled after HandleOpenApi are still included.
+	server.Handle(config, route.Post("/sample", &test.Create{}))
+	server.Handle(config, route.Get("/sample/{id}", &test.Get{}))
+
+	execute := func(path string) (int, string, []byte) {
+		req := fasthttp.Request{}
+		req.Header.SetMethod(http.MethodGet)
+		req.SetRequestURI(path)
+
+		requestCtx := fasthttp.RequestCtx{}
+		requestCtx.Init(&req, nil, nil)
+
+		httpStatus, responseBody := server.Execute(&requestCtx)
+
+		return httpStatus, string(requestCtx.Response.Header.ContentType()), responseBody
+	}
+
+	httpStatus, contentType, responseBody := execute("/openapi.json")
+	assert.Equal(t, http.StatusOK, httpStatus)
+	assert.Equal(t, "application/json", contentType)
+
+	document := &openapi.Document{}
+	assert.Nil(t, json.Unmarshal(responseBody, document))
+	assert.Equal(t, "Sample", document.Info.Title)
+	assert.Len(t, document.Paths, 2)
+	assert.Equal(t, "Create", document.Paths["/sample"].Post.OperationId)
+	assert.Equal(t, "Get", document.Paths["/sample/{id}"].Get.OperationId)
+
+	httpStatus, contentType, responseBody = execute("/openapi.yaml")
+	assert.Equal(t, http.StatusOK, httpStatus)
+	assert.Equal(t, "application/yaml", contentType)
+
+	loaded, err := openapi.Load(responseBody)
+	assert.Nil(t, err)
+	assert.Equal(t, document, loaded)
+}
+
+func Test_Server_Middleware(t *testing.T) {
+	t.Parallel()
+
+	logConfig := &test.NoopLogConfig{
+		Config: log.NewConfig().WithLevel(log.LevelFatal),
+	}
+
+	var calls []string
+	recordMiddleware := func(name string) endpoint.Middleware {
+		return endpoint.MiddlewareFunc(func(ctx context.Context, requester endpoint.Requester, next endpoint.Next) (int, []byte, error) {
+			calls = append(calls, name)
+			httpStatus, responseBody := next(ctx)
+
+			return httpStatus, responseBody, nil
+		})
+	}
+
+	server := restful.NewServer(&restful.ServerConfig{
+		LogConfig:  logConfig,
+		Middleware: []endpoint.Middleware{recordMiddleware("server")},
+	})
+
+	config := &endpoint.Config{
+		LogConfig:  logConfig,
+		Middleware: []endpoint.Middleware{recordMiddleware("endpoint")},
+		Resources: map[string]any{
+			"datastore": &test.Database{},
+		},
+	}
+	server.Handle(config, route.Get("/sample/{id}", &test.Get{}))
+
+	req := fasthttp.Request{}
+	req.Header.SetMethod(http.MethodGet)
+	req.SetRequestURI("/sample/34")
+
+	requestCtx := fasthttp.RequestCtx{}
+	requestCtx.Init(&req, nil, nil)
+
+	httpStatus, _ := server.Execute(&requestCtx)
+	assert.Equal(t, http.StatusOK, httpStatus)
+	assert.Equal(t, []string{"server", "endpoint"}, calls)
+	// The endpoint config is not modified.
+	assert.Len(t, config.Middleware, 1)
+}
@@ -0,0 +1,223 @@
+package restful
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/valyala/fasthttp"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/httpheader"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+var (
+	ErrServerStarted   = errors.New("server already started")
+	ErrServerStopped   = errors.New("server stopped")
+	ErrShutdownTimeout = errors.New("shutdown timed out before requests drained")
+)
+
+// DefaultShutdownSignals are the usual signals that a process manager sends to stop a server.
+var DefaultShutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// ShutdownHook runs after the server has drained its requests.
+// Ex: close the resources in endpoint.Config.Resources.
+type ShutdownHook func(ctx context.Context) error
+
+type shutdownHook struct {
+	name string
+	hook ShutdownHook
+}
+
+// OnShutdown registers a hook that runs when the server shuts down.
+// Hooks run in the order they were registered, after requests have drained.
+func (self *Server) OnShutdown(name string, hook ShutdownHook) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.shutdownHooks = append(self.shutdownHooks, shutdownHook{
+		name: name,
+		hook: hook,
+	})
+}
+
+// Start listening for requests without blocking.
+// The server shuts down when the context is canceled, when Shutdown is called, or when it receives one of ServerConfig.ShutdownSignals.
+func (self *Server) Start(ctx context.Context) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	select {
+	case <-self.stopped:
+		return ErrServerStopped
+	default:
+	}
+	if self.listener != nil {
+		return ErrServerStarted
+	}
+
+	for key, list := range self.router.List() {
+		log.Debug(self.serverContext, "%v", key)
+		for _, item := range list {
+			log.Debug(self.serverContext, "  %v", item)
+		}
+	}
+
+	listener := self.serverConfig.Listener
+	if listener == nil {
+		var err error
+		listenAddress := fmt.Sprintf("%s:%d", self.serverConfig.Host, self.serverConfig.Port)
+		if listener, err = net.Listen("tcp4", listenAddress); err != nil {
+			return errors.New("failed to listen on %s: %v", listenAddress, err)
+		}
+	}
+
+	self.listener = listener
+	self.server.Handler = self.router.Handler
+
+	go func() {
+		// Serve also fails once the listener is closed by Shutdown, which is not an error.
+		if err := self.server.Serve(listener); err != nil && self.Ready() {
+			log.Error(self.serverContext, "server failed: %v", err)
+		}
+	}()
+
+	atomic.StoreInt32(&self.ready, 1)
+	log.Info(self.serverContext, "listening for requests: %s", listener.Addr())
+
+	go self.shutdownOn(ctx)
+
+	return nil
+}
+
+// shutdownOn the context being canceled or any of the shutdown signals.
+func (self *Server) shutdownOn(ctx context.Context) {
+	shutdown := make(chan os.Signal, 1)
+	if len(self.serverConfig.ShutdownSignals) != 0 {
+		signal.Notify(shutdown, self.serverConfig.ShutdownSignals...)
+		defer signal.Stop(shutdown)
+	}
+
+	select {
+	case <-self.stopped:
+		return
+	case <-ctx.Done():
+		log.Info(self.serverContext, "context canceled, shutting down")
+	case received := <-shutdown:
+		log.Info(self.serverContext, "received %s, shutting down", received)
+	}
+
+	if err := self.Shutdown(context.Background()); err != nil {
+		log.Error(self.serverContext, "failed to gracefully shutdown server: %v", err)
+	}
+}
+
+// Shutdown the server gracefully.
+//  1. Readiness reports unhealthy, then waits ServerConfig.ReadinessDelay so load balancers stop sending requests.
+//  2. The listener closes and running requests drain, for at most ServerConfig.ShutdownTimeout.
+//  3. Shutdown hooks run in order.
+//
+// Calling Shutdown again waits for the first shutdown and returns its result.
+func (self *Server) Shutdown(ctx context.Context) error {
+	self.shutdownOnce.Do(func() {
+		self.shutdownErr = self.shutdown(ctx)
+		close(self.stopped)
+	})
+
+	<-self.stopped
+
+	return self.shutdownErr
+}
+
+func (self *Server) shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&self.ready, 0)
+	log.Info(self.serverContext, "shutting down")
+
+	if self.serverConfig.ReadinessDelay != 0 {
+		readinessCtx, cancel := context.WithTimeout(ctx, self.serverConfig.ReadinessDelay)
+		<-readinessCtx.Done()
+		cancel()
+	}
+
+	// Notify everything running on the server context that the server is shutting down.
+	self.cancelServerContext()
+
+	var shutdownErr error
+
+	drainCtx, cancel := context.WithTimeout(ctx, self.serverConfig.ShutdownTimeout)
+	defer cancel()
+
+	drained := make(chan error, 1)
+	go func() {
+		// Stop listening for new requests and wait for running requests to finish.
+		drained <- self.server.Shutdown()
+	}()
+
+	self.mutex.Lock()
+	listener := self.listener
+	hooks := self.shutdownHooks
+	self.mutex.Unlock()
+
+	select {
+	case err := <-drained:
+		if err != nil {
+			shutdownErr = errors.New("failed to drain requests: %v", err)
+		}
+	case <-drainCtx.Done():
+		shutdownErr = ErrShutdownTimeout
+	}
+	if shutdownErr != nil {
+		log.Error(self.serverContext, "%v", shutdownErr)
+	}
+
+	if listener != nil {
+		// Serve may not have started using the listener before Shutdown, so it is closed here as well.
+		_ = listener.Close()
+	}
+
+	for _, hook := range hooks {
+		log.Debug(self.serverContext, "running shutdown hook: %s", hook.name)
+		if err := hook.hook(ctx); err != nil {
+			log.Error(self.serverContext, "shutdown hook %s failed: %v", hook.name, err)
+			if shutdownErr == nil {
+				shutdownErr = errors.New("shutdown hook %s failed: %v", hook.name, err)
+			}
+		}
+	}
+
+	log.Info(self.serverContext, "server stopped")
+
+	return shutdownErr
+}
+
+// Ready returns true while the server is accepting requests.
+// Ready is false before the server starts and as soon as it begins shutting down.
+func (self *Server) Ready() bool {
+	return atomic.LoadInt32(&self.ready) == 1
+}
+
+// HandleReadiness serves the readiness of the server at the path.
+// Responds 200 while the server is ready and 503 once it begins shutting down.
+func (self *Server) HandleReadiness(path string) {
+	self.router.GET(path, func(requestCtx *fasthttp.RequestCtx) {
+		requestCtx.Response.Header.Set(httpheader.ContentType, "application/json")
+		// Readiness is polled frequently, so an idle keep-alive connection must not hold up draining on shutdown.
+		requestCtx.Response.SetConnectionClose()
+
+		if !self.Ready() {
+			requestCtx.SetStatusCode(httpstatus.ServiceUnavailable)
+			requestCtx.SetBodyString(`{"status":"unavailable"}`)
+
+			return
+		}
+
+		requestCtx.SetStatusCode(httpstatus.OK)
+		requestCtx.SetBodyString(`{"status":"ready"}`)
+	})
+}
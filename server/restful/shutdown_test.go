@@ -0,0 +1,155 @@
+package restful_test
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"github.com/wspowell/context"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/server/restful"
+	"github.com/wspowell/spiderweb/server/route"
+)
+
+type shutdownRecorder struct {
+	mutex sync.Mutex
+	hooks []string
+}
+
+func (self *shutdownRecorder) hook(name string, server *restful.Server) restful.ShutdownHook {
+	return func(ctx context.Context) error {
+		self.mutex.Lock()
+		defer self.mutex.Unlock()
+
+		if server.Ready() {
+			name += " (ready)"
+		}
+		self.hooks = append(self.hooks, name)
+
+		return nil
+	}
+}
+
+func newShutdownServer(gate *gate, shutdownTimeout time.Duration, readinessDelay time.Duration) (*restful.Server, *fasthttp.Client) {
+	listener := fasthttputil.NewInmemoryListener()
+
+	server := newTestServer(&restful.ServerConfig{
+		Listener:        listener,
+		ShutdownTimeout: shutdownTimeout,
+		ReadinessDelay:  readinessDelay,
+	}, &endpoint.Config{
+		Resources: map[string]any{
+			"gate": gate,
+		},
+	}, route.Get("/slow", &blockingEndpoint{}))
+	server.HandleReadiness("/ready")
+
+	client := &fasthttp.Client{
+		Dial: func(addr string) (net.Conn, error) {
+			return listener.Dial()
+		},
+	}
+
+	return server, client
+}
+
+func get(client *fasthttp.Client, path string) int {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.Header.SetMethod(http.MethodGet)
+	req.SetRequestURI("http://spiderweb" + path)
+
+	if err := client.Do(req, resp); err != nil {
+		return 0
+	}
+
+	return resp.StatusCode()
+}
+
+func Test_Server_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	gate := newGate()
+	server, client := newShutdownServer(gate, time.Second, 200*time.Millisecond)
+
+	recorder := &shutdownRecorder{}
+	server.OnShutdown("first", recorder.hook("first", server))
+	server.OnShutdown("second", recorder.hook("second", server))
+
+	assert.False(t, server.Ready())
+	assert.Nil(t, server.Start(context.Background()))
+	assert.ErrorIs(t, server.Start(context.Background()), restful.ErrServerStarted)
+	assert.True(t, server.Ready())
+	assert.Equal(t, httpstatus.OK, get(client, "/ready"))
+
+	// A request is running when the server shuts down.
+	slowStatus := make(chan int, 1)
+	go func() {
+		slowStatus <- get(client, "/slow")
+	}()
+	<-gate.deadline
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- server.Shutdown(context.Background())
+	}()
+
+	// Readiness is unhealthy before the listener closes.
+	assert.Eventually(t, func() bool {
+		return !server.Ready()
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, httpstatus.ServiceUnavailable, get(client, "/ready"))
+
+	close(gate.release)
+	assert.Equal(t, httpstatus.OK, <-slowStatus)
+	assert.Nil(t, <-shutdownErr)
+	assert.Equal(t, []string{"first", "second"}, recorder.hooks)
+
+	// Shutting down again returns the same result.
+	assert.Nil(t, server.Shutdown(context.Background()))
+}
+
+func Test_Server_Shutdown_Timeout(t *testing.T) {
+	t.Parallel()
+
+	gate := newGate()
+	defer close(gate.release)
+	server, client := newShutdownServer(gate, 50*time.Millisecond, 0)
+
+	recorder := &shutdownRecorder{}
+	server.OnShutdown("close", recorder.hook("close", server))
+
+	assert.Nil(t, server.Start(context.Background()))
+
+	go get(client, "/slow")
+	<-gate.deadline
+
+	assert.ErrorIs(t, server.Shutdown(context.Background()), restful.ErrShutdownTimeout)
+	// Hooks still run after the requests fail to drain.
+	assert.Equal(t, []string{"close"}, recorder.hooks)
+}
+
+func Test_Server_Shutdown_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newShutdownServer(newGate(), time.Second, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, server.Start(ctx))
+	assert.True(t, server.Ready())
+
+	cancel()
+	assert.Nil(t, server.Shutdown(context.Background()))
+	assert.False(t, server.Ready())
+	assert.ErrorIs(t, server.Start(context.Background()), restful.ErrServerStopped)
+}
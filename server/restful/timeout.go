@@ -0,0 +1,108 @@
+package restful
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/wspowell/context"
+	"github.com/wspowell/errors"
+	"github.com/wspowell/log"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+)
+
+const (
+	handlerRunning int32 = iota
+	handlerCompleted
+	handlerAbandoned
+)
+
+// AbandonedHandlers are handlers that were still running when their request timed out.
+type AbandonedHandlers struct {
+	// Running is the number of abandoned handlers that have not returned yet.
+	Running int64 `json:"running"`
+	// Total is the number of handlers abandoned since the server started.
+	Total int64 `json:"total"`
+}
+
+// AbandonedHandlers returns the number of handlers that were abandoned when their request timed out.
+// Handlers that keep running long after their timeout should check endpoint.ShouldContinue().
+func (self *Server) AbandonedHandlers() AbandonedHandlers {
+	return AbandonedHandlers{
+		Running: atomic.LoadInt64(&self.abandonedRunning),
+		Total:   atomic.LoadInt64(&self.abandonedTotal),
+	}
+}
+
+// executeWithTimeout executes the endpoint with a context that is canceled after the endpoint timeout.
+// If the endpoint has not returned by then, the request receives a timeout error response and the handler is abandoned.
+func (self *Server) executeWithTimeout(ctx context.Context, requestCtx *fasthttp.RequestCtx, routeEndpoint *endpoint.Endpoint) {
+	ctx, cancel := context.WithTimeout(ctx, routeEndpoint.Config.Timeout)
+
+	requester := newFasthttpRequester(requestCtx)
+	requester.bufferResponse()
+
+	var httpStatus int
+	var responseBody []byte
+	state := handlerRunning
+	done := make(chan struct{})
+	start := time.Now()
+
+	go func() {
+		httpStatus, responseBody = routeEndpoint.Execute(ctx, requester)
+
+		if atomic.CompareAndSwapInt32(&state, handlerRunning, handlerCompleted) {
+			close(done)
+
+			return
+		}
+
+		// The request has already received the timeout response.
+		requester.discardResponse()
+		running := atomic.AddInt64(&self.abandonedRunning, -1)
+		log.Warn(self.serverContext, "abandoned handler %s returned after %s (%d still running)", routeEndpoint.Name(), time.Since(start), running)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// A server shutdown cancels the context, but the handler is given the chance to finish.
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// Counted before the handler can return and decrement it.
+			atomic.AddInt64(&self.abandonedRunning, 1)
+			if atomic.CompareAndSwapInt32(&state, handlerRunning, handlerAbandoned) {
+				self.writeTimeoutResponse(ctx, requestCtx, routeEndpoint)
+				cancel()
+
+				return
+			}
+			atomic.AddInt64(&self.abandonedRunning, -1)
+		}
+
+		<-done
+	}
+
+	requester.flushResponse()
+	// A response body stream reads with the context after this returns, so the context is canceled once the body is written.
+	// If the stream is never written, the context is released when its deadline passes.
+	requester.writeResponse(httpStatus, responseBody, cancel)
+
+	// Set the Connection header to "close".
+	// Closes the connection after this function returns.
+	requestCtx.Response.SetConnectionClose()
+}
+
+func (self *Server) writeTimeoutResponse(ctx context.Context, requestCtx *fasthttp.RequestCtx, routeEndpoint *endpoint.Endpoint) {
+	total := atomic.AddInt64(&self.abandonedTotal, 1)
+	log.Warn(self.serverContext, "request timed out after %s, abandoning handler %s (%d abandoned)", routeEndpoint.Config.Timeout, routeEndpoint.Name(), total)
+
+	requester := newFasthttpRequester(requestCtx)
+	httpStatus, responseBody := routeEndpoint.ErrorResponse(ctx, requester, httpstatus.RequestTimeout, endpoint.ErrRequestTimeout)
+	requester.writeResponse(httpStatus, responseBody, nil)
+	requestCtx.Response.SetConnectionClose()
+
+	// The abandoned handler still references the request context, so it must not be reused by the server.
+	requestCtx.TimeoutErrorWithResponse(&requestCtx.Response)
+}
@@ -0,0 +1,140 @@
+package restful_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wspowell/context"
+
+	"github.com/wspowell/spiderweb/endpoint"
+	"github.com/wspowell/spiderweb/httpstatus"
+	"github.com/wspowell/spiderweb/server/restful"
+	"github.com/wspowell/spiderweb/server/route"
+)
+
+type blocker interface {
+	block(ctx context.Context)
+}
+
+// gate blocks handlers until it is released, ignoring the context.
+type gate struct {
+	release  chan struct{}
+	deadline chan time.Time
+	err      chan error
+}
+
+func newGate() *gate {
+	return &gate{
+		release:  make(chan struct{}),
+		deadline: make(chan time.Time, 1),
+		err:      make(chan error, 1),
+	}
+}
+
+func (self *gate) block(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	self.deadline <- deadline
+
+	<-self.release
+
+	self.err <- ctx.Err()
+}
+
+type blockingEndpoint struct {
+	Gate blocker `spiderweb:"resource=gate"`
+}
+
+func (self *blockingEndpoint) Handle(ctx context.Context) (int, error) {
+	self.Gate.block(ctx)
+
+	return httpstatus.OK, nil
+}
+
+func newTimeoutServer(gate *gate, timeout time.Duration) *restful.Server {
+	return newTestServer(&restful.ServerConfig{}, &endpoint.Config{
+		ErrorHandler: endpoint.ProblemErrorHandler{},
+		Timeout:      timeout,
+		Resources: map[string]any{
+			"gate": gate,
+		},
+	}, route.Get("/slow", &blockingEndpoint{}))
+}
+
+func Test_Server_Timeout(t *testing.T) {
+	t.Parallel()
+
+	gate := newGate()
+	server := newTimeoutServer(gate, 20*time.Millisecond)
+
+	start := time.Now()
+	httpStatus, responseBody, responseHeader := executeGet(server, "/slow")
+	assert.Equal(t, httpstatus.RequestTimeout, httpStatus)
+	assert.Equal(t, "application/problem+json", string(responseHeader.ContentType()))
+	assert.NotEmpty(t, string(responseHeader.Peek("X-Request-Id")))
+
+	problem := endpoint.Problem{}
+	assert.Nil(t, json.Unmarshal(responseBody, &problem))
+	assert.Equal(t, "urn:spiderweb:problem:request-timeout", problem.Type)
+	assert.Equal(t, httpstatus.RequestTimeout, problem.Status)
+
+	// The handler context carries the deadline.
+	deadline := <-gate.deadline
+	assert.WithinDuration(t, start.Add(20*time.Millisecond), deadline, 10*time.Millisecond)
+
+	assert.Equal(t, restful.AbandonedHandlers{Running: 1, Total: 1}, server.AbandonedHandlers())
+
+	close(gate.release)
+	assert.ErrorIs(t, <-gate.err, context.DeadlineExceeded)
+	assert.Eventually(t, func() bool {
+		return server.AbandonedHandlers().Running == 0
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, restful.AbandonedHandlers{Running: 0, Total: 1}, server.AbandonedHandlers())
+}
+
+func Test_Server_Timeout_NotExceeded(t *testing.T) {
+	t.Parallel()
+
+	gate := newGate()
+	close(gate.release)
+	server := newTimeoutServer(gate, time.Minute)
+
+	httpStatus, _, responseHeader := executeGet(server, "/slow")
+	assert.Equal(t, httpstatus.OK, httpStatus)
+	assert.NotEmpty(t, string(responseHeader.Peek("X-Request-Id")))
+	assert.Nil(t, <-gate.err)
+
+	deadline := <-gate.deadline
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+	assert.Equal(t, restful.AbandonedHandlers{}, server.AbandonedHandlers())
+}
+
+var streamedBody = bytes.Repeat([]byte("spiderweb"), 1024)
+
+type streamEndpoint struct {
+	ResponseBody io.Reader `spiderweb:"response,stream,mime=application/octet-stream"`
+}
+
+func (self *streamEndpoint) Handle(ctx context.Context) (int, error) {
+	self.ResponseBody = bytes.NewReader(streamedBody)
+
+	return httpstatus.OK, nil
+}
+
+func Test_Server_Timeout_Stream(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(&restful.ServerConfig{}, &endpoint.Config{
+		Timeout: time.Minute,
+	}, route.Get("/stream", &streamEndpoint{}))
+
+	// The body is streamed after the handler returns.
+	httpStatus, responseBody, responseHeader := executeGet(server, "/stream")
+	assert.Equal(t, httpstatus.OK, httpStatus)
+	assert.Equal(t, "application/octet-stream", string(responseHeader.ContentType()))
+	assert.Equal(t, streamedBody, responseBody)
+}
@@ -10,6 +10,28 @@ type Route struct {
 	HttpMethod string
 	Path       string
 	Handler    endpoint.Handler
+	// Policies that authorize the principal of the request, in addition to the Policies of the endpoint config.
+	Policies []endpoint.Policy
+}
+
+// WithPolicies returns a copy of the route that requires the policies.
+func (self Route) WithPolicies(policies ...endpoint.Policy) Route {
+	self.Policies = append(append([]endpoint.Policy{}, self.Policies...), policies...)
+
+	return self
+}
+
+// EndpointConfig returns the endpoint config with the route Policies.
+// The given config is not modified.
+func (self Route) EndpointConfig(endpointConfig *endpoint.Config) *endpoint.Config {
+	if len(self.Policies) == 0 {
+		return endpointConfig
+	}
+
+	configCopy := *endpointConfig
+	configCopy.Policies = append(append([]endpoint.Policy{}, endpointConfig.Policies...), self.Policies...)
+
+	return &configCopy
 }
 
 func New(httpMethod string, path string, handler endpoint.Handler) Route {